@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/insidieux/pinchy/internal/extension/registry"
+	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/insidieux/pinchy/pkg/core"
+	multiRegistry "github.com/insidieux/pinchy/pkg/core/registry/multi"
+	"github.com/insidieux/pinchy/pkg/core/source/multi"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	chainName     = `multi`
+	chainAliasKey = `alias`
+
+	flagChain           = `chain`
+	flagChainPrecedence = `chain-precedence`
+	flagChainStrict     = `chain-strict`
+)
+
+type (
+	// chainEntry is parsed chain flag value in format "name?flag=value&alias=name"
+	chainEntry struct {
+		name      string
+		alias     string
+		overrides url.Values
+	}
+
+	// chainFlagSet is flags of single chained extension
+	chainFlagSet struct {
+		name  string
+		flags *pflag.FlagSet
+	}
+
+	// chainFlagNamer is helper for generation extension flag name, e.g. source.MakeFlagName
+	chainFlagNamer func(name string) string
+)
+
+// Chain source and registry are registered in command package, because their flags contain flags of all other registered extensions
+func init() {
+	registerChainSource()
+	registerChainRegistry()
+}
+
+func registerChainSource() {
+	list := source.GetProviderList()
+	set := pflag.NewFlagSet(chainName, pflag.ExitOnError)
+	set.StringSlice(
+		source.MakeFlagName(flagChain),
+		nil,
+		`Ordered list of chained sources in format "name?flag=value&alias=name", flags override common flag values for single source`,
+	)
+	set.String(
+		source.MakeFlagName(flagChainPrecedence),
+		string(multi.PrecedenceLast),
+		fmt.Sprintf(`Which chained source wins for services with same id (%s, %s)`, multi.PrecedenceFirst, multi.PrecedenceLast),
+	)
+	set.Bool(source.MakeFlagName(flagChainStrict), false, `Fail on duplicated services within or across chained sources`)
+	sets := make([]chainFlagSet, 0, len(list))
+	for _, p := range list {
+		sets = append(sets, chainFlagSet{name: p.Name(), flags: p.Flags()})
+	}
+	if err := addChainFlags(set, sets, source.MakeFlagName); err != nil {
+		panic(err)
+	}
+	if err := source.Register(chainName, set, newChainSourceFactory(list), false); err != nil {
+		panic(err)
+	}
+}
+
+func registerChainRegistry() {
+	list := registry.GetProviderList()
+	set := pflag.NewFlagSet(chainName, pflag.ExitOnError)
+	set.StringSlice(
+		registry.MakeFlagName(flagChain),
+		nil,
+		`List of registries in format "name?flag=value&alias=name", every registry receives same services, flags override common flag values for single registry`,
+	)
+	sets := make([]chainFlagSet, 0, len(list))
+	for _, p := range list {
+		sets = append(sets, chainFlagSet{name: p.Name(), flags: p.Flags()})
+	}
+	if err := addChainFlags(set, sets, registry.MakeFlagName); err != nil {
+		panic(err)
+	}
+	if err := registry.Register(chainName, set, newChainRegistryFactory(list), false); err != nil {
+		panic(err)
+	}
+}
+
+// newChainSourceFactory provide source.Factory, which merges all chained sources with multi.Source
+func newChainSourceFactory(list source.ProviderList) source.Factory {
+	return func(v *viper.Viper) (core.Source, func(), error) {
+		flag := source.MakeFlagName(flagChain)
+		entries, err := parseChain(v.GetStringSlice(flag))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, `flag "%s" is invalid`, flag)
+		}
+		if len(entries) == 0 {
+			return nil, nil, errors.Errorf(`flag "%s" is required`, flag)
+		}
+
+		var cleanups []func()
+		cleanup := func() {
+			for _, c := range cleanups {
+				c()
+			}
+		}
+		members := make([]multi.Member, 0, len(entries))
+		for _, entry := range entries {
+			p, err := list.Lookup(entry.name)
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			s, c, err := p.Factory()(chainViper(v, source.MakeFlagName, entry.name, entry.overrides))
+			if c != nil {
+				cleanups = append(cleanups, c)
+			}
+			if err != nil {
+				cleanup()
+				return nil, nil, errors.Wrapf(err, `failed to create chained source "%s"`, entry.alias)
+			}
+			members = append(members, multi.Member{Name: entry.alias, Source: s})
+		}
+
+		s, err := multi.NewSource(
+			members,
+			multi.Precedence(v.GetString(source.MakeFlagName(flagChainPrecedence))),
+			multi.Strict(v.GetBool(source.MakeFlagName(flagChainStrict))),
+		)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return s, cleanup, nil
+	}
+}
+
+// newChainRegistryFactory provide registry.Factory, which writes services to all chained registries with multi.Registry
+func newChainRegistryFactory(list registry.ProviderList) registry.Factory {
+	return func(v *viper.Viper) (core.Registry, func(), error) {
+		flag := registry.MakeFlagName(flagChain)
+		entries, err := parseChain(v.GetStringSlice(flag))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, `flag "%s" is invalid`, flag)
+		}
+		if len(entries) == 0 {
+			return nil, nil, errors.Errorf(`flag "%s" is required`, flag)
+		}
+
+		var cleanups []func()
+		cleanup := func() {
+			for _, c := range cleanups {
+				c()
+			}
+		}
+		members := make([]core.RegistryMember, 0, len(entries))
+		for _, entry := range entries {
+			p, err := list.Lookup(entry.name)
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			r, c, err := p.Factory()(chainViper(v, registry.MakeFlagName, entry.name, entry.overrides))
+			if c != nil {
+				cleanups = append(cleanups, c)
+			}
+			if err != nil {
+				cleanup()
+				return nil, nil, errors.Wrapf(err, `failed to create chained registry "%s"`, entry.alias)
+			}
+			members = append(members, core.RegistryMember{Name: entry.alias, Registry: r})
+		}
+
+		r, err := multiRegistry.NewRegistry(members)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return r, cleanup, nil
+	}
+}
+
+// parseChain parse chain flag values. Duplicated aliases are suffixed with position in chain
+func parseChain(values []string) ([]chainEntry, error) {
+	entries := make([]chainEntry, 0, len(values))
+	aliases := make(map[string]bool)
+	for index, value := range values {
+		entry, err := parseChainEntry(value)
+		if err != nil {
+			return nil, err
+		}
+		if aliases[entry.alias] {
+			entry.alias = fmt.Sprintf(`%s#%d`, entry.alias, index+1)
+		}
+		aliases[entry.alias] = true
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseChainEntry parse single chain flag value in format "name?flag=value&alias=name"
+func parseChainEntry(value string) (chainEntry, error) {
+	name, query := value, ``
+	if index := strings.Index(value, `?`); index >= 0 {
+		name, query = value[:index], value[index+1:]
+	}
+	if name == `` || name == chainName {
+		return chainEntry{}, errors.Errorf(`chain entry "%s" has invalid name`, value)
+	}
+	overrides, err := url.ParseQuery(query)
+	if err != nil {
+		return chainEntry{}, errors.Wrapf(err, `chain entry "%s" has invalid flags`, value)
+	}
+	alias := overrides.Get(chainAliasKey)
+	overrides.Del(chainAliasKey)
+	if alias == `` {
+		alias = name
+	}
+	return chainEntry{
+		name:      name,
+		alias:     alias,
+		overrides: overrides,
+	}, nil
+}
+
+// chainViper provide copy of viper.Viper with values for single chained extension:
+// common values, values of flags namespaced by extension name, e.g. source.file.watch for source.watch, and overrides
+func chainViper(base *viper.Viper, namer chainFlagNamer, name string, overrides url.Values) *viper.Viper {
+	v := viper.New()
+	prefix := namer(name) + `.`
+	for _, key := range base.AllKeys() {
+		v.SetDefault(key, base.Get(key))
+	}
+	for _, key := range base.AllKeys() {
+		if strings.HasPrefix(key, prefix) {
+			v.SetDefault(namer(strings.TrimPrefix(key, prefix)), base.Get(key))
+		}
+	}
+	for key, values := range overrides {
+		if len(values) == 1 {
+			v.Set(key, values[0])
+			continue
+		}
+		v.Set(key, values)
+	}
+	return v
+}
+
+// addChainFlags adds flags of all chained extensions to set. Flag, which has the same type, default value and usage
+// in all extensions, is added once as common flag. Other flags are namespaced by extension name, e.g. source.file.watch,
+// so every extension keeps own default value and usage
+func addChainFlags(set *pflag.FlagSet, sets []chainFlagSet, namer chainFlagNamer) error {
+	first := make(map[string]*pflag.Flag)
+	conflicts := make(map[string]bool)
+	for _, cs := range sets {
+		cs.flags.VisitAll(func(f *pflag.Flag) {
+			prev, ok := first[f.Name]
+			if !ok {
+				first[f.Name] = f
+				return
+			}
+			if prev.Value.Type() != f.Value.Type() || prev.DefValue != f.DefValue || prev.Usage != f.Usage {
+				conflicts[f.Name] = true
+			}
+		})
+	}
+
+	var err error
+	for _, cs := range sets {
+		cs := cs
+		cs.flags.VisitAll(func(f *pflag.Flag) {
+			if err != nil {
+				return
+			}
+			if !conflicts[f.Name] {
+				if set.Lookup(f.Name) == nil {
+					set.AddFlag(f)
+				}
+				return
+			}
+			err = cloneFlag(set, cs.flags, f, namer(cs.name+`.`+strings.TrimPrefix(f.Name, namer(``))))
+		})
+	}
+	return err
+}
+
+// cloneFlag adds flag with new name, own value and the same default value and usage to set
+func cloneFlag(set *pflag.FlagSet, flags *pflag.FlagSet, f *pflag.Flag, name string) error {
+	var err error
+	switch f.Value.Type() {
+	case `string`:
+		var value string
+		if value, err = flags.GetString(f.Name); err == nil {
+			set.String(name, value, f.Usage)
+		}
+	case `bool`:
+		var value bool
+		if value, err = flags.GetBool(f.Name); err == nil {
+			set.Bool(name, value, f.Usage)
+		}
+	case `int`:
+		var value int
+		if value, err = flags.GetInt(f.Name); err == nil {
+			set.Int(name, value, f.Usage)
+		}
+	case `duration`:
+		var value time.Duration
+		if value, err = flags.GetDuration(f.Name); err == nil {
+			set.Duration(name, value, f.Usage)
+		}
+	case `stringSlice`:
+		var value []string
+		if value, err = flags.GetStringSlice(f.Name); err == nil {
+			set.StringSlice(name, value, f.Usage)
+		}
+	case `stringToString`:
+		var value map[string]string
+		if value, err = flags.GetStringToString(f.Name); err == nil {
+			set.StringToString(name, value, f.Usage)
+		}
+	default:
+		err = errors.Errorf(`flag "%s" has unsupported type "%s"`, f.Name, f.Value.Type())
+	}
+	return err
+}
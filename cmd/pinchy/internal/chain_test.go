@@ -0,0 +1,510 @@
+package internal
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/internal/extension/registry"
+	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/insidieux/pinchy/pkg/core"
+	multiRegistry "github.com/insidieux/pinchy/pkg/core/registry/multi"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func Test_parseChain(t *testing.T) {
+	suite.Run(t, new(parseChainTestSuite))
+}
+
+func Test_chainViper(t *testing.T) {
+	suite.Run(t, new(chainViperTestSuite))
+}
+
+func Test_newChainRegistryFactory(t *testing.T) {
+	suite.Run(t, new(newChainRegistryFactoryTestSuite))
+}
+
+func Test_addChainFlags(t *testing.T) {
+	suite.Run(t, new(addChainFlagsTestSuite))
+}
+
+func Test_cloneFlag(t *testing.T) {
+	suite.Run(t, new(cloneFlagTestSuite))
+}
+
+// --- Suites ---
+
+type parseChainTestSuite struct {
+	suite.Suite
+}
+
+func (s *parseChainTestSuite) TestSuccess() {
+	for _, tc := range []struct {
+		values   []string
+		expected []chainEntry
+	}{
+		{
+			values:   nil,
+			expected: []chainEntry{},
+		},
+		{
+			values:   []string{`file`},
+			expected: []chainEntry{{name: `file`, alias: `file`, overrides: url.Values{}}},
+		},
+		{
+			values: []string{`file?source.path=/etc/base.yml&alias=base`},
+			expected: []chainEntry{{
+				name:      `file`,
+				alias:     `base`,
+				overrides: url.Values{`source.path`: {`/etc/base.yml`}},
+			}},
+		},
+		{
+			values: []string{`http?source.header=a&source.header=b`},
+			expected: []chainEntry{{
+				name:      `http`,
+				alias:     `http`,
+				overrides: url.Values{`source.header`: {`a`, `b`}},
+			}},
+		},
+		{
+			values: []string{`consul-agent`, `consul-catalog`, `consul-agent?alias=consul-agent`},
+			expected: []chainEntry{
+				{name: `consul-agent`, alias: `consul-agent`, overrides: url.Values{}},
+				{name: `consul-catalog`, alias: `consul-catalog`, overrides: url.Values{}},
+				{name: `consul-agent`, alias: `consul-agent#3`, overrides: url.Values{}},
+			},
+		},
+		{
+			values:   []string{`file?`},
+			expected: []chainEntry{{name: `file`, alias: `file`, overrides: url.Values{}}},
+		},
+	} {
+		entries, err := parseChain(tc.values)
+		s.NoError(err, tc.values)
+		s.Equal(tc.expected, entries, tc.values)
+	}
+}
+
+func (s *parseChainTestSuite) TestErrorMalformed() {
+	for _, tc := range []struct {
+		values   []string
+		expected string
+	}{
+		{
+			values:   []string{``},
+			expected: `chain entry "" has invalid name`,
+		},
+		{
+			values:   []string{`?source.path=/etc/base.yml`},
+			expected: `chain entry "?source.path=/etc/base.yml" has invalid name`,
+		},
+		{
+			values:   []string{`file`, `multi?alias=nested`},
+			expected: `chain entry "multi?alias=nested" has invalid name`,
+		},
+		{
+			values:   []string{`file?source.path=%zz`},
+			expected: `chain entry "file?source.path=%zz" has invalid flags: invalid URL escape "%zz"`,
+		},
+	} {
+		entries, err := parseChain(tc.values)
+		s.Nil(entries, tc.values)
+		s.EqualError(err, tc.expected, tc.values)
+	}
+}
+
+type chainViperTestSuite struct {
+	suite.Suite
+	base *viper.Viper
+}
+
+func (s *chainViperTestSuite) SetupTest() {
+	set := pflag.NewFlagSet(`test`, pflag.ContinueOnError)
+	set.String(`source.path`, `common.yml`, ``)
+	set.Bool(`source.file.watch`, false, ``)
+	set.Bool(`source.consul.watch`, true, ``)
+	set.StringSlice(`source.chain`, nil, ``)
+	if err := set.Parse([]string{`--source.file.watch`, `--source.chain`, `file,consul`}); err != nil {
+		panic(err)
+	}
+	s.base = viper.New()
+	if err := s.base.BindPFlags(set); err != nil {
+		panic(err)
+	}
+}
+
+func (s *chainViperTestSuite) TestCommon() {
+	v := chainViper(s.base, source.MakeFlagName, `file`, nil)
+	s.Equal(`common.yml`, v.GetString(`source.path`))
+	s.Equal([]string{`file`, `consul`}, v.GetStringSlice(`source.chain`))
+}
+
+func (s *chainViperTestSuite) TestNamespaced() {
+	for _, tc := range []struct {
+		name     string
+		expected bool
+	}{
+		{name: `file`, expected: true},
+		{name: `consul`, expected: true},
+		{name: `http`, expected: false},
+	} {
+		v := chainViper(s.base, source.MakeFlagName, tc.name, nil)
+		s.Equal(tc.expected, v.GetBool(`source.watch`), tc.name)
+	}
+}
+
+func (s *chainViperTestSuite) TestNamespacedOverCommon() {
+	s.base.Set(`source.watch`, false)
+	s.base.Set(`source.file.path`, `file.yml`)
+
+	v := chainViper(s.base, source.MakeFlagName, `file`, nil)
+	s.True(v.GetBool(`source.watch`))
+	s.Equal(`file.yml`, v.GetString(`source.path`))
+}
+
+func (s *chainViperTestSuite) TestOverrides() {
+	for _, tc := range []struct {
+		overrides url.Values
+		key       string
+		expected  interface{}
+	}{
+		{
+			overrides: url.Values{`source.path`: {`base.yml`}},
+			key:       `source.path`,
+			expected:  `base.yml`,
+		},
+		{
+			overrides: url.Values{`source.watch`: {`false`}},
+			key:       `source.watch`,
+			expected:  false,
+		},
+		{
+			overrides: url.Values{`source.tag`: {`a`, `b`}},
+			key:       `source.tag`,
+			expected:  []string{`a`, `b`},
+		},
+	} {
+		v := chainViper(s.base, source.MakeFlagName, `file`, tc.overrides)
+		switch expected := tc.expected.(type) {
+		case bool:
+			s.Equal(expected, v.GetBool(tc.key), tc.key)
+		case []string:
+			s.Equal(expected, v.GetStringSlice(tc.key), tc.key)
+		default:
+			s.Equal(expected, v.Get(tc.key), tc.key)
+		}
+	}
+	s.Equal(`common.yml`, s.base.GetString(`source.path`))
+}
+
+func (s *chainViperTestSuite) TestRegistry() {
+	set := pflag.NewFlagSet(`test`, pflag.ContinueOnError)
+	set.String(`registry.address`, `127.0.0.1:8500`, ``)
+	set.String(`registry.consul-agent.tag`, `agent`, ``)
+	set.String(`registry.consul-catalog.tag`, `catalog`, ``)
+	if err := set.Parse([]string{`--registry.consul-catalog.tag`, `mirror`}); err != nil {
+		panic(err)
+	}
+	base := viper.New()
+	if err := base.BindPFlags(set); err != nil {
+		panic(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		overrides url.Values
+		address   string
+		tag       string
+	}{
+		{name: `consul-agent`, address: `127.0.0.1:8500`, tag: `agent`},
+		{name: `consul-catalog`, address: `127.0.0.1:8500`, tag: `mirror`},
+		{
+			name:      `consul-catalog`,
+			overrides: url.Values{`registry.address`: {`consul.dc2:8500`}, `registry.tag`: {`dc2`}},
+			address:   `consul.dc2:8500`,
+			tag:       `dc2`,
+		},
+	} {
+		v := chainViper(base, registry.MakeFlagName, tc.name, tc.overrides)
+		s.Equal(tc.address, v.GetString(`registry.address`), tc.name)
+		s.Equal(tc.tag, v.GetString(`registry.tag`), tc.name)
+	}
+}
+
+type newChainRegistryFactoryTestSuite struct {
+	suite.Suite
+	base     *viper.Viper
+	vipers   []*viper.Viper
+	cleanups []string
+}
+
+func (s *newChainRegistryFactoryTestSuite) SetupTest() {
+	s.base = viper.New()
+	s.base.Set(`registry.address`, `127.0.0.1:8500`)
+	s.base.Set(`registry.consul-agent.tag`, `agent`)
+	s.base.Set(`registry.consul-catalog.tag`, `catalog`)
+	s.vipers = nil
+	s.cleanups = nil
+}
+
+func (s *newChainRegistryFactoryTestSuite) provider(name string, err error) *MockProviderInterface {
+	p := new(MockProviderInterface)
+	p.On(`Name`).Return(name).Maybe()
+	p.On(`Factory`).Return(registry.Factory(func(v *viper.Viper) (core.Registry, func(), error) {
+		if err != nil {
+			return nil, nil, err
+		}
+		s.vipers = append(s.vipers, v)
+		return nil, func() {
+			s.cleanups = append(s.cleanups, name)
+		}, nil
+	})).Maybe()
+	return p
+}
+
+func (s *newChainRegistryFactoryTestSuite) TestSuccess() {
+	s.base.Set(`registry.chain`, []string{
+		`consul-agent?alias=agent`,
+		`consul-catalog?alias=catalog`,
+		`consul-catalog?registry.address=consul.dc2:8500&registry.tag=dc2&alias=dc2`,
+	})
+	list := registry.ProviderList{s.provider(`consul-agent`, nil), s.provider(`consul-catalog`, nil)}
+
+	r, cleanup, err := newChainRegistryFactory(list)(s.base)
+	s.NoError(err)
+	s.IsType(new(multiRegistry.Registry), r)
+	s.Len(s.vipers, 3)
+	for index, tc := range []struct {
+		address string
+		tag     string
+	}{
+		{address: `127.0.0.1:8500`, tag: `agent`},
+		{address: `127.0.0.1:8500`, tag: `catalog`},
+		{address: `consul.dc2:8500`, tag: `dc2`},
+	} {
+		s.Equal(tc.address, s.vipers[index].GetString(`registry.address`), index)
+		s.Equal(tc.tag, s.vipers[index].GetString(`registry.tag`), index)
+	}
+
+	s.Empty(s.cleanups)
+	cleanup()
+	s.Equal([]string{`consul-agent`, `consul-catalog`, `consul-catalog`}, s.cleanups)
+}
+
+func (s *newChainRegistryFactoryTestSuite) TestErrorRequired() {
+	list := registry.ProviderList{s.provider(`consul-agent`, nil)}
+
+	r, cleanup, err := newChainRegistryFactory(list)(s.base)
+	s.Nil(r)
+	s.Nil(cleanup)
+	s.EqualError(err, `flag "registry.chain" is required`)
+}
+
+func (s *newChainRegistryFactoryTestSuite) TestErrorInvalid() {
+	s.base.Set(`registry.chain`, []string{`consul-agent`, `multi`})
+	list := registry.ProviderList{s.provider(`consul-agent`, nil)}
+
+	r, cleanup, err := newChainRegistryFactory(list)(s.base)
+	s.Nil(r)
+	s.Nil(cleanup)
+	s.EqualError(err, `flag "registry.chain" is invalid: chain entry "multi" has invalid name`)
+}
+
+func (s *newChainRegistryFactoryTestSuite) TestErrorUnknownRegistry() {
+	s.base.Set(`registry.chain`, []string{`consul-agent`, `etcd`})
+	list := registry.ProviderList{s.provider(`consul-agent`, nil)}
+
+	r, cleanup, err := newChainRegistryFactory(list)(s.base)
+	s.Nil(r)
+	s.Nil(cleanup)
+	s.EqualError(err, `registry provider with name "etcd" was not registered`)
+	s.Equal([]string{`consul-agent`}, s.cleanups)
+}
+
+func (s *newChainRegistryFactoryTestSuite) TestErrorFactory() {
+	s.base.Set(`registry.chain`, []string{`consul-agent`, `consul-catalog?alias=dc2`})
+	list := registry.ProviderList{
+		s.provider(`consul-agent`, nil),
+		s.provider(`consul-catalog`, errors.New(`connection refused`)),
+	}
+
+	r, cleanup, err := newChainRegistryFactory(list)(s.base)
+	s.Nil(r)
+	s.Nil(cleanup)
+	s.EqualError(err, `failed to create chained registry "dc2": connection refused`)
+	s.Equal([]string{`consul-agent`}, s.cleanups)
+}
+
+type addChainFlagsTestSuite struct {
+	suite.Suite
+}
+
+func (s *addChainFlagsTestSuite) TestFlags() {
+	file := pflag.NewFlagSet(`file`, pflag.ContinueOnError)
+	file.Bool(`source.watch`, false, `Watch file`)
+	file.Bool(`source.env.allow-missing`, false, `Allow missing`)
+	dir := pflag.NewFlagSet(`dir`, pflag.ContinueOnError)
+	dir.Bool(`source.watch`, false, `Watch files`)
+	dir.Bool(`source.env.allow-missing`, false, `Allow missing`)
+	consul := pflag.NewFlagSet(`consul`, pflag.ContinueOnError)
+	consul.Bool(`source.watch`, true, `Watch catalog`)
+	consul.String(`source.token`, ``, `Consul ACL token`)
+
+	set := pflag.NewFlagSet(`multi`, pflag.ContinueOnError)
+	err := addChainFlags(set, []chainFlagSet{
+		{name: `file`, flags: file},
+		{name: `dir`, flags: dir},
+		{name: `consul`, flags: consul},
+	}, source.MakeFlagName)
+	s.NoError(err)
+
+	names := make([]string, 0)
+	set.VisitAll(func(f *pflag.Flag) {
+		names = append(names, f.Name)
+	})
+	s.ElementsMatch([]string{
+		`source.env.allow-missing`,
+		`source.token`,
+		`source.file.watch`,
+		`source.dir.watch`,
+		`source.consul.watch`,
+	}, names)
+	for _, tc := range []struct {
+		name     string
+		defValue string
+		usage    string
+	}{
+		{name: `source.file.watch`, defValue: `false`, usage: `Watch file`},
+		{name: `source.dir.watch`, defValue: `false`, usage: `Watch files`},
+		{name: `source.consul.watch`, defValue: `true`, usage: `Watch catalog`},
+		{name: `source.token`, defValue: ``, usage: `Consul ACL token`},
+	} {
+		f := set.Lookup(tc.name)
+		s.Equal(tc.defValue, f.DefValue, tc.name)
+		s.Equal(tc.usage, f.Usage, tc.name)
+	}
+
+	s.NoError(set.Parse([]string{`--source.dir.watch`}))
+	s.Equal(`true`, set.Lookup(`source.dir.watch`).Value.String())
+	s.Equal(`false`, set.Lookup(`source.file.watch`).Value.String())
+	s.Equal(`false`, dir.Lookup(`source.watch`).Value.String())
+}
+
+func (s *addChainFlagsTestSuite) TestErrorUnsupportedType() {
+	file := pflag.NewFlagSet(`file`, pflag.ContinueOnError)
+	file.Float64(`source.ratio`, 1, `Ratio`)
+	dir := pflag.NewFlagSet(`dir`, pflag.ContinueOnError)
+	dir.Float64(`source.ratio`, 2, `Ratio`)
+
+	err := addChainFlags(pflag.NewFlagSet(`multi`, pflag.ContinueOnError), []chainFlagSet{
+		{name: `file`, flags: file},
+		{name: `dir`, flags: dir},
+	}, source.MakeFlagName)
+	s.EqualError(err, `flag "source.ratio" has unsupported type "float64"`)
+}
+
+type cloneFlagTestSuite struct {
+	suite.Suite
+}
+
+func (s *cloneFlagTestSuite) TestTypes() {
+	flags := pflag.NewFlagSet(`file`, pflag.ContinueOnError)
+	flags.String(`string`, `value`, `String`)
+	flags.Bool(`bool`, true, `Bool`)
+	flags.Int(`int`, 3, `Int`)
+	flags.Duration(`duration`, time.Minute, `Duration`)
+	flags.StringSlice(`slice`, []string{`a`, `b`}, `Slice`)
+	flags.StringToString(`map`, map[string]string{`k`: `v`}, `Map`)
+
+	set := pflag.NewFlagSet(`multi`, pflag.ContinueOnError)
+	flags.VisitAll(func(f *pflag.Flag) {
+		s.NoError(cloneFlag(set, flags, f, `file.`+f.Name))
+	})
+	flags.VisitAll(func(f *pflag.Flag) {
+		clone := set.Lookup(`file.` + f.Name)
+		s.Equal(f.Value.Type(), clone.Value.Type(), f.Name)
+		s.Equal(f.DefValue, clone.DefValue, f.Name)
+		s.Equal(f.Value.String(), clone.Value.String(), f.Name)
+		s.Equal(f.Usage, clone.Usage, f.Name)
+		s.NotSame(f.Value, clone.Value, f.Name)
+	})
+
+	s.NoError(set.Parse([]string{`--file.slice`, `c`}))
+	value, err := set.GetStringSlice(`file.slice`)
+	s.NoError(err)
+	s.Equal([]string{`c`}, value)
+}
+
+// --- Mocks ---
+
+// MockProviderInterface is an autogenerated mock type for the ProviderInterface type
+type MockProviderInterface struct {
+	mock.Mock
+}
+
+// Deprecated provides a mock function with given fields:
+func (_m *MockProviderInterface) Deprecated() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Factory provides a mock function with given fields:
+func (_m *MockProviderInterface) Factory() registry.Factory {
+	ret := _m.Called()
+
+	var r0 registry.Factory
+	if rf, ok := ret.Get(0).(func() registry.Factory); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(registry.Factory)
+		}
+	}
+
+	return r0
+}
+
+// Flags provides a mock function with given fields:
+func (_m *MockProviderInterface) Flags() *pflag.FlagSet {
+	ret := _m.Called()
+
+	var r0 *pflag.FlagSet
+	if rf, ok := ret.Get(0).(func() *pflag.FlagSet); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pflag.FlagSet)
+		}
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *MockProviderInterface) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
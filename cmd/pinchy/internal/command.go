@@ -2,10 +2,16 @@ package internal
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	consulClient "github.com/insidieux/pinchy/internal/extension/consul"
 	"github.com/insidieux/pinchy/internal/extension/registry"
 	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/metrics"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,6 +23,7 @@ const (
 
 // NewCommand provide root cobra.Command
 // Root command contains pre-generated subcommands for all registered core.Source and core.Registry
+// Watch command reloads configuration on every SIGHUP, other commands keep default SIGHUP handling
 func NewCommand(version string) *cobra.Command {
 	rootCommand := &cobra.Command{
 		Use:     name,
@@ -25,6 +32,7 @@ func NewCommand(version string) *cobra.Command {
 	rootCommand.SetOut(logrus.New().Out)
 
 	for _, sourceProvider := range source.GetProviderList() {
+		sourceProvider := sourceProvider
 		sourceCmd := &cobra.Command{
 			Use:   sourceProvider.Name(),
 			Short: fmt.Sprintf(`Fetch data from source "%s"`, sourceProvider.Name()),
@@ -33,6 +41,7 @@ func NewCommand(version string) *cobra.Command {
 			sourceCmd.Deprecated = fmt.Sprintf(`source "%s" is deprecated`, sourceProvider.Name())
 		}
 		for _, registryProvider := range registry.GetProviderList() {
+			registryProvider := registryProvider
 			registryCmd := &cobra.Command{
 				Use:   registryProvider.Name(),
 				Short: fmt.Sprintf(`Save data in registry "%s"`, registryProvider.Name()),
@@ -51,31 +60,102 @@ func NewCommand(version string) *cobra.Command {
 					if err != nil {
 						return errors.Wrap(err, `failed to bootstrap manager`)
 					}
-					return manager.Run(cmd.Context())
+					report, err := manager.Run(cmd.Context())
+					if report != nil {
+						if err := printReport(cmd.OutOrStdout(), report); err != nil {
+							return errors.Wrap(err, `failed to print report`)
+						}
+					}
+					if err != nil {
+						return err
+					}
+					if report.HasFailures() {
+						return errors.Errorf(`failed to sync %d services`, report.Count(core.ActionFailed))
+					}
+					return nil
+				},
+			}
+			onceCommand.SetOut(os.Stdout)
+			planCommand := &cobra.Command{
+				Use:   `plan`,
+				Short: `Show changes which sync is going to apply, without any changes in registry`,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					printer, err := lookupPlanPrinter(cmd.Flag(`plan.format`).Value.String())
+					if err != nil {
+						return err
+					}
+					manager, cleanup, err := newManager(cmd.Flags(), sourceProvider.Factory(), registryProvider.Factory())
+					if cleanup != nil {
+						cleanup()
+					}
+					if err != nil {
+						return errors.Wrap(err, `failed to bootstrap manager`)
+					}
+					plans, err := manager.Plan(cmd.Context())
+					if err != nil {
+						return err
+					}
+					return printer(cmd.OutOrStdout(), plans)
 				},
 			}
+			planCommand.SetOut(os.Stdout)
 			watchCommand := &cobra.Command{
 				Use:   `watch`,
-				Short: `Run main process as daemon: sync repeatedly with constant interval`,
+				Short: `Run main process as daemon: sync immediately and then repeatedly by interval or cron schedule`,
 				RunE: func(cmd *cobra.Command, args []string) error {
-					sc, cleanup, err := newScheduler(cmd.Flags(), sourceProvider.Factory(), registryProvider.Factory())
+					factory := func(m core.Metrics) (*pipeline, func(), error) {
+						return newPipeline(cmd.Flags(), sourceProvider.Factory(), registryProvider.Factory(), m)
+					}
+					d, cleanup, err := newDaemon(cmd.Flags(), metrics.DefaultRegistry(registryProvider.Name()), factory)
 					if cleanup != nil {
-						cleanup()
+						defer cleanup()
 					}
 					if err != nil {
 						return errors.Wrap(err, `failed to bootstrap scheduler`)
 					}
-					sc.Run(cmd.Context())
-					return nil
+					reload := make(chan os.Signal, 1)
+					signal.Notify(reload, syscall.SIGHUP)
+					defer signal.Stop(reload)
+					return d.Run(cmd.Context(), reload, factory)
 				},
 			}
+			planCommand.Flags().String(`plan.format`, planFormatText, fmt.Sprintf(`Plan output format (%s, %s)`, planFormatText, planFormatJSON))
 			watchCommand.Flags().Duration(`scheduler.interval`, time.Minute, `Interval between manager runs (1s, 1m, 5m, 1h and others)`)
+			watchCommand.Flags().String(`scheduler.cron`, ``, `Cron expression for manager runs, e.g. "*/5 * * * *" or "@hourly", overrides "scheduler.interval"`)
+			watchCommand.Flags().Duration(`scheduler.jitter`, 0, `Max random delay added to every scheduled manager run (0 means no jitter)`)
+			watchCommand.Flags().Duration(`scheduler.failure-backoff`, 0, `Max delay between scheduled manager runs, which is doubled after every consecutive failed run (0 means no backoff)`)
+			watchCommand.Flags().Duration(`scheduler.debounce`, time.Second, `Time to collect source change notifications into single manager run`)
+			watchCommand.Flags().Duration(`scheduler.shutdown-grace`, 30*time.Second, `Time to finish manager run in progress after SIGINT or SIGTERM, before it is canceled (0 means cancel immediately)`)
+			watchCommand.Flags().String(`admin.address`, ``, `Address for HTTP listener with admin API: /healthz, /readyz, /status and POST /sync, e.g. :8080 (empty means disabled)`)
+			watchCommand.Flags().Int(`admin.failure-threshold`, 3, `Count of consecutive failed runs, after which /readyz responds with 503 status code`)
+			watchCommand.Flags().String(`election.key`, ``, `Consul KV key used as lock for leader election between several watch replicas, e.g. service/pinchy/leader (empty means disabled)`)
+			consulClient.RegisterFlags(watchCommand.Flags(), electionFlagName)
+			watchCommand.Flags().Duration(`election.session-ttl`, 15*time.Second, `TTL of consul session, which holds leader lock. Leadership is lost, if session is not renewed in time`)
+			watchCommand.Flags().Duration(`election.retry-delay`, 5*time.Second, `Delay before next lock attempt after consul error, also used as lock wait time`)
+			watchCommand.Flags().String(`metrics.address`, ``, `Address for HTTP listener with Prometheus metrics on /metrics, e.g. :9100 (empty means disabled)`)
 			registryCmd.PersistentFlags().Bool(`manager.continue-on-error`, false, `Omit errors during process manager`)
 			registryCmd.PersistentFlags().Bool(`manager.exit-on-error`, false, `Stop manager process on first error and by pass it to command line`)
+			registryCmd.PersistentFlags().Int(`manager.concurrency`, 1, `Count of concurrent register and deregister requests to registry`)
+			registryCmd.PersistentFlags().Int(`manager.max-deregister`, 0, `Max count of services, which can be deregistered by single run (0 means no limit)`)
+			registryCmd.PersistentFlags().Float64(`manager.max-deregister-percent`, 0, `Max percent of registered services, which can be deregistered by single run (0 means no limit)`)
+			registryCmd.PersistentFlags().Bool(`manager.force`, false, `Ignore deregistration limits for intentional large cleanups`)
+			registryCmd.PersistentFlags().Bool(`manager.full-resync`, false, `Register all incoming services on every run, even if they are not changed in registry`)
 			_ = registryCmd.PersistentFlags().MarkDeprecated(`manager.continue-on-error`, `Flag "manager.continue-on-error" is deprecated, use "manager.exit-on-error" instead`)
 			_ = registryCmd.PersistentFlags().MarkHidden(`manager.continue-on-error`)
+			registryCmd.PersistentFlags().Int(`retry.max-attempts`, 1, `Max attempts for every registry request, transient errors are retried with exponential backoff`)
+			registryCmd.PersistentFlags().Duration(`retry.base-delay`, 500*time.Millisecond, `Delay before first retry of registry request`)
+			registryCmd.PersistentFlags().Duration(`retry.max-delay`, 30*time.Second, `Max delay between retries of registry request`)
+			registryCmd.PersistentFlags().Float64(`retry.jitter`, 0.2, `Fraction of retry delay (from 0 to 1), which is randomly subtracted from every delay`)
+			registryCmd.PersistentFlags().StringArray(`filter.include`, nil, `Sync only services matched by all expressions in format "field=glob" or "field~regexp" (fields: name, id, tag, meta.<key>)`)
+			registryCmd.PersistentFlags().StringArray(`filter.exclude`, nil, `Skip services matched by any expression in format "field=glob" or "field~regexp" (fields: name, id, tag, meta.<key>)`)
+			registryCmd.PersistentFlags().StringSlice(`transform.drop`, nil, `Drop incoming services with name or id matched by glob pattern`)
+			registryCmd.PersistentFlags().String(`transform.name-prefix`, ``, `Prefix added to name and id of every incoming service`)
+			registryCmd.PersistentFlags().Int(`transform.default-port`, 0, `Port set for incoming services without port (0 means no default port)`)
+			registryCmd.PersistentFlags().StringSlice(`transform.tags`, nil, `Tags added to every incoming service`)
+			registryCmd.PersistentFlags().StringToString(`transform.meta`, nil, `Meta values set for every incoming service, values are Go templates with service as data, e.g. url=http://{{.Address}}`)
 			registryCmd.PersistentFlags().AddFlagSet(registryProvider.Flags())
 			registryCmd.AddCommand(onceCommand)
+			registryCmd.AddCommand(planCommand)
 			registryCmd.AddCommand(watchCommand)
 			sourceCmd.AddCommand(registryCmd)
 		}
@@ -83,5 +163,6 @@ func NewCommand(version string) *cobra.Command {
 		rootCommand.AddCommand(sourceCmd)
 	}
 	rootCommand.PersistentFlags().String(`logger.level`, logrus.InfoLevel.String(), `Log level`)
+	rootCommand.PersistentFlags().String(`config`, ``, `Path to config file (yaml, json, toml) with flag values, command line flags and environment variables take precedence`)
 	return rootCommand
 }
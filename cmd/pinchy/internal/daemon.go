@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/admin"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+type (
+	// daemon contains core.Scheduler with initial pipeline and background services for watch mode
+	daemon struct {
+		scheduler *core.Scheduler
+		pipeline  *pipeline
+		metrics   core.Metrics
+		logger    core.LoggerInterface
+	}
+
+	// adminServer is marker of started admin HTTP listener, nil means admin API is disabled
+	adminServer struct{}
+
+	// pipeline contains core.ManagerInterface with core.Source, which are replaced in core.Scheduler on reload.
+	// Cleanup of pipeline, e.g. source and registry connections, is called once by pipeline.close.
+	// Settings are values of non reloadable settings from configuration, which pipeline was built with
+	pipeline struct {
+		manager  core.ManagerInterface
+		source   core.Source
+		settings map[string]string
+		cleanup  func()
+		once     sync.Once
+	}
+
+	// pipelineFactory builds pipeline from current configuration with core.Metrics of running daemon
+	pipelineFactory func(metrics core.Metrics) (*pipeline, func(), error)
+)
+
+// nonReloadablePrefixes are prefixes of settings, which are applied only on start of watch mode
+var nonReloadablePrefixes = []string{`scheduler.`, `election.`, `admin.`, `metrics.`}
+
+// Provider for daemon
+func provideDaemon(scheduler *core.Scheduler, p *pipeline, metrics core.Metrics, logger core.LoggerInterface, _ *adminServer) *daemon {
+	return &daemon{
+		scheduler: scheduler,
+		pipeline:  p,
+		metrics:   metrics,
+		logger:    logger,
+	}
+}
+
+// Provider for pipeline
+func providePipeline(commandViper *viper.Viper, manager core.ManagerInterface, src core.Source) *pipeline {
+	return &pipeline{
+		manager:  manager,
+		source:   src,
+		settings: nonReloadableSettings(commandViper),
+	}
+}
+
+// nonReloadableSettings return values of settings with nonReloadablePrefixes from flags, environment and config file
+func nonReloadableSettings(commandViper *viper.Viper) map[string]string {
+	settings := make(map[string]string)
+	for _, key := range commandViper.AllKeys() {
+		for _, prefix := range nonReloadablePrefixes {
+			if strings.HasPrefix(key, prefix) {
+				settings[key] = fmt.Sprint(commandViper.Get(key))
+				break
+			}
+		}
+	}
+	return settings
+}
+
+// changedSettings return sorted names of settings, which have different values in previous and next settings
+func changedSettings(previous, next map[string]string) []string {
+	changed := make([]string, 0)
+	for key, value := range next {
+		if previousValue, ok := previous[key]; !ok || previousValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := next[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Provider for initial pipeline of daemon built with factory.
+// Pipeline is owned by daemon, which closes it on reload, cleanup only closes pipeline, which was not closed yet
+func provideInitialPipeline(factory pipelineFactory, metrics core.Metrics) (*pipeline, func(), error) {
+	p, cleanup, err := factory(metrics)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.cleanup = cleanup
+	return p, p.close, nil
+}
+
+// Provider for core.ManagerInterface of pipeline
+func providePipelineManager(p *pipeline) core.ManagerInterface {
+	return p.manager
+}
+
+// Provider for core.Source of pipeline
+func providePipelineSource(p *pipeline) core.Source {
+	return p.source
+}
+
+// close calls pipeline cleanup only once
+func (p *pipeline) close() {
+	p.once.Do(func() {
+		if p.cleanup != nil {
+			p.cleanup()
+		}
+	})
+}
+
+// Provider for admin HTTP listener with health, readiness, status and sync endpoints.
+// Admin API is disabled if flag "admin.address" is empty
+func provideAdminServer(commandViper *viper.Viper, scheduler *core.Scheduler, logger core.LoggerInterface) (*adminServer, func(), error) {
+	address := commandViper.GetString(`admin.address`)
+	if address == `` {
+		return nil, func() {}, nil
+	}
+	handler := admin.NewHandler(scheduler, commandViper.GetInt(`admin.failure-threshold`))
+	shutdown, err := serveHTTP(`admin api`, address, handler, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &adminServer{}, shutdown, nil
+}
+
+// Run daemon until context.Context canceled.
+// Every signal received from reload channel rebuilds pipeline with factory and replaces it in core.Scheduler.
+// Previous pipeline is kept, if factory failed. Changes of non reloadable settings are logged with warning and ignored. Replaced pipeline is closed, when core.Scheduler does not use it anymore,
+// the last pipeline is closed after core.Scheduler stopped
+func (d *daemon) Run(ctx context.Context, reload <-chan os.Signal, factory pipelineFactory) error {
+	ctx, cancel := context.WithCancel(ctx)
+	current := d.pipeline
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var released sync.WaitGroup
+		defer released.Wait()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				d.logger.Infoln(`Reloading configuration`)
+				p, cleanup, err := factory(d.metrics)
+				if err != nil {
+					d.logger.Errorln(errors.Wrap(err, `failed to reload configuration`).Error())
+					continue
+				}
+				p.cleanup = cleanup
+				if changed := changedSettings(d.pipeline.settings, p.settings); len(changed) > 0 {
+					d.logger.Warningf(`Settings "%s" are changed, but applied only after restart`, strings.Join(changed, `", "`))
+				}
+				previous := current
+				current = p
+				released.Add(1)
+				go func(wait <-chan struct{}) {
+					defer released.Done()
+					<-wait
+					previous.close()
+				}(d.scheduler.Reload(p.manager, watchableSource(p.source)))
+			}
+		}
+	}()
+
+	err := d.scheduler.Run(ctx)
+	cancel()
+	<-done
+	current.close()
+	return err
+}
@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func Test_nonReloadableSettings(t *testing.T) {
+	suite.Run(t, new(nonReloadableSettingsTestSuite))
+}
+
+func Test_changedSettings(t *testing.T) {
+	suite.Run(t, new(changedSettingsTestSuite))
+}
+
+func Test_daemon_Run(t *testing.T) {
+	suite.Run(t, new(daemonRunTestSuite))
+}
+
+// --- Suites ---
+
+type nonReloadableSettingsTestSuite struct {
+	suite.Suite
+}
+
+func (s *nonReloadableSettingsTestSuite) TestSettings() {
+	v := viper.New()
+	v.Set(`scheduler.interval`, time.Minute)
+	v.Set(`election.key`, `service/pinchy/leader`)
+	v.Set(`admin.address`, `:8080`)
+	v.Set(`metrics.address`, ``)
+	v.Set(`filter.include`, []string{`name=web-*`})
+	v.Set(`logger.level`, `debug`)
+	s.Equal(map[string]string{
+		`scheduler.interval`: `1m0s`,
+		`election.key`:       `service/pinchy/leader`,
+		`admin.address`:      `:8080`,
+		`metrics.address`:    ``,
+	}, nonReloadableSettings(v))
+}
+
+type changedSettingsTestSuite struct {
+	suite.Suite
+}
+
+func (s *changedSettingsTestSuite) TestUnchanged() {
+	settings := map[string]string{`admin.address`: `:8080`}
+	s.Empty(changedSettings(settings, map[string]string{`admin.address`: `:8080`}))
+}
+
+func (s *changedSettingsTestSuite) TestChanged() {
+	previous := map[string]string{
+		`admin.address`:      `:8080`,
+		`election.key`:       `service/pinchy/leader`,
+		`scheduler.interval`: `1m0s`,
+	}
+	next := map[string]string{
+		`admin.address`:      `:8080`,
+		`metrics.address`:    `:9100`,
+		`scheduler.interval`: `5m0s`,
+	}
+	s.Equal([]string{`election.key`, `metrics.address`, `scheduler.interval`}, changedSettings(previous, next))
+}
+
+type daemonRunTestSuite struct {
+	suite.Suite
+	manager *MockManagerInterface
+	logger  *logrus.Logger
+	hook    *test.Hook
+	daemon  *daemon
+}
+
+func (s *daemonRunTestSuite) SetupTest() {
+	s.manager = new(MockManagerInterface)
+	s.manager.On(`Run`, mock.Anything).Return(core.NewReport(), nil)
+	s.logger, s.hook = test.NewNullLogger()
+	s.daemon = &daemon{
+		scheduler: core.NewScheduler(core.IntervalSchedule(time.Hour), s.manager, s.logger),
+		pipeline: &pipeline{
+			manager:  s.manager,
+			settings: map[string]string{`admin.address`: ``, `scheduler.interval`: `1m0s`},
+		},
+		logger: s.logger,
+	}
+}
+
+func (s *daemonRunTestSuite) TestReloadNonReloadableSettings() {
+	s.run(map[string]string{`admin.address`: `:8080`, `scheduler.interval`: `5m0s`})
+	s.Equal(
+		`Settings "admin.address", "scheduler.interval" are changed, but applied only after restart`,
+		s.warning(),
+	)
+}
+
+func (s *daemonRunTestSuite) TestReloadSameSettings() {
+	s.run(map[string]string{`admin.address`: ``, `scheduler.interval`: `1m0s`})
+	s.Empty(s.warning())
+}
+
+// run sends reload signal to daemon with factory, which provides pipeline with passed settings,
+// and stops daemon after reloaded pipeline is used by scheduler
+func (s *daemonRunTestSuite) run(settings map[string]string) {
+	reloaded := new(MockManagerInterface)
+	ran := make(chan struct{})
+	reloaded.On(`Run`, mock.Anything).Return(core.NewReport(), nil).Run(func(mock.Arguments) {
+		close(ran)
+	}).Once()
+	closed := make(chan struct{})
+	factory := func(core.Metrics) (*pipeline, func(), error) {
+		return &pipeline{manager: reloaded, settings: settings}, func() { close(closed) }, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reload := make(chan os.Signal, 1)
+	reload <- syscall.SIGHUP
+	done := make(chan error, 1)
+	go func() {
+		done <- s.daemon.Run(ctx, reload, factory)
+	}()
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		s.FailNow(`reloaded pipeline is not used by scheduler`)
+	}
+	cancel()
+	s.NoError(<-done)
+	<-closed
+}
+
+func (s *daemonRunTestSuite) warning() string {
+	for _, entry := range s.hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			return entry.Message
+		}
+	}
+	return ``
+}
+
+// --- Mocks ---
+
+// MockManagerInterface is an autogenerated mock type for the ManagerInterface type
+type MockManagerInterface struct {
+	mock.Mock
+}
+
+// Plan provides a mock function with given fields: ctx
+func (_m *MockManagerInterface) Plan(ctx context.Context) ([]*core.Plan, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*core.Plan
+	if rf, ok := ret.Get(0).(func(context.Context) []*core.Plan); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*core.Plan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Run provides a mock function with given fields: ctx
+func (_m *MockManagerInterface) Run(ctx context.Context) (*core.Report, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *core.Report
+	if rf, ok := ret.Get(0).(func(context.Context) *core.Report); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.Report)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
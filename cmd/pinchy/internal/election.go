@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	consulClient "github.com/insidieux/pinchy/internal/extension/consul"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/election/consul"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	electionSessionName = `pinchy`
+	electionFlagPrefix  = `election`
+)
+
+// electionFlagName is consulClient.FlagNamer for Consul client flags of leader election, e.g. election.address
+func electionFlagName(name string) string {
+	return fmt.Sprintf(`%s.%s`, electionFlagPrefix, name)
+}
+
+// Provider for core.LeaderElector based on Consul lock.
+// Nil core.LeaderElector is returned if flag "election.key" is empty
+func provideLeaderElector(commandViper *viper.Viper, logger core.LoggerInterface) (core.LeaderElector, error) {
+	key := commandViper.GetString(`election.key`)
+	if key == `` {
+		return nil, nil
+	}
+
+	config, err := consulClient.NewClientConfig(commandViper, electionFlagName)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create consul client config for leader election`)
+	}
+	client, err := consulClient.NewClient(config, consulClient.NewFactory())
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create consul client for leader election`)
+	}
+
+	lock, err := client.LockOpts(&api.LockOptions{
+		Key:          key,
+		SessionName:  electionSessionName,
+		SessionTTL:   commandViper.GetDuration(`election.session-ttl`).String(),
+		LockWaitTime: commandViper.GetDuration(`election.retry-delay`),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create consul lock for leader election`)
+	}
+
+	elector := consul.NewElector(lock, consul.RetryDelay(commandViper.GetDuration(`election.retry-delay`)))
+	elector.WithLogger(logger)
+	return elector, nil
+}
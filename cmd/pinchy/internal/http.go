@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	httpShutdownTimeout = 5 * time.Second
+)
+
+// serveHTTP starts HTTP listener on address in background and return func for graceful shutdown.
+// Listen errors are returned immediately, serve errors are logged
+func serveHTTP(name string, address string, handler http.Handler, logger core.LoggerInterface) (func(), error) {
+	listener, err := net.Listen(`tcp`, address)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to listen %s address "%s"`, name, address)
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		logger.Infof(`Serve %s on "%s"`, name, listener.Addr())
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorln(errors.Wrapf(err, `failed to serve %s`, name).Error())
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Errorln(errors.Wrapf(err, `failed to shutdown %s server`, name).Error())
+		}
+	}, nil
+}
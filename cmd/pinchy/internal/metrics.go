@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+)
+
+const (
+	metricsPath = `/metrics`
+)
+
+// Provider for core.Metrics exposed by HTTP listener with Prometheus handler.
+// Nil core.Metrics is returned if flag "metrics.address" is empty
+// metrics.DefaultRegistry is registry provider name, so services of single registry are labeled with it
+func provideMetrics(commandViper *viper.Viper, logger core.LoggerInterface, defaultRegistry metrics.DefaultRegistry) (core.Metrics, func(), error) {
+	address := commandViper.GetString(`metrics.address`)
+	if address == `` {
+		return nil, func() {}, nil
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	m, err := metrics.NewPrometheus(registry, defaultRegistry)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, `failed to create metrics`)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	shutdown, err := serveHTTP(`metrics`, address, mux, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, shutdown, nil
+}
+
+// Provider for disabled core.Metrics, used by commands, which do not expose metrics
+func provideDisabledMetrics() core.Metrics {
+	return nil
+}
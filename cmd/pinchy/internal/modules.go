@@ -4,5 +4,8 @@ import (
 	// List of imports for registry extensions
 	_ "github.com/insidieux/pinchy/internal/extension/registry/consul"
 	// List of imports for source extensions
+	_ "github.com/insidieux/pinchy/internal/extension/source/consul"
+	_ "github.com/insidieux/pinchy/internal/extension/source/dir"
 	_ "github.com/insidieux/pinchy/internal/extension/source/file"
+	_ "github.com/insidieux/pinchy/internal/extension/source/http"
 )
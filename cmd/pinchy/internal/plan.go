@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	planFormatText = `text`
+	planFormatJSON = `json`
+)
+
+type (
+	planPrinter func(io.Writer, []*core.Plan) error
+
+	planUpdate struct {
+		Current  *core.Service `json:","`
+		Incoming *core.Service `json:","`
+	}
+
+	planOutput struct {
+		Registry      string                         `json:",omitempty"`
+		Create        core.Services                  `json:","`
+		Update        []planUpdate                   `json:","`
+		Unchanged     []string                       `json:","`
+		Deregister    core.Services                  `json:","`
+		LimitExceeded *core.DeregistrationLimitError `json:",omitempty"`
+	}
+)
+
+// lookupPlanPrinter return planPrinter by output format name
+func lookupPlanPrinter(format string) (planPrinter, error) {
+	switch format {
+	case planFormatText:
+		return printPlanText, nil
+	case planFormatJSON:
+		return printPlanJSON, nil
+	}
+	return nil, errors.Errorf(`unknown plan format "%s", available formats: %s, %s`, format, planFormatText, planFormatJSON)
+}
+
+// printPlanJSON write core.Plan list as JSON document. Updated services contain both current and incoming state.
+// LimitExceeded is written only for plans, which exceed deregistration limit.
+// Single plan without registry name is written as object, plans of registry group are written as array
+func printPlanJSON(w io.Writer, plans []*core.Plan) error {
+	outputs := make([]planOutput, 0, len(plans))
+	for _, plan := range plans {
+		output := planOutput{
+			Registry:      plan.Registry,
+			Create:        plan.Create,
+			Update:        make([]planUpdate, 0, len(plan.Update)),
+			Unchanged:     plan.Unchanged.IDs(),
+			Deregister:    plan.Deregister,
+			LimitExceeded: plan.LimitExceeded,
+		}
+		for _, service := range plan.Update {
+			output.Update = append(output.Update, planUpdate{
+				Current:  plan.Registered.Lookup(service.RegistrationID()),
+				Incoming: service,
+			})
+		}
+		outputs = append(outputs, output)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent(``, `  `)
+	if len(outputs) == 1 && outputs[0].Registry == `` {
+		return encoder.Encode(outputs[0])
+	}
+	return encoder.Encode(outputs)
+}
+
+// printPlanText write core.Plan list as human-readable diff, plans of registry group are prefixed with registry name
+func printPlanText(w io.Writer, plans []*core.Plan) error {
+	b := new(strings.Builder)
+	for index, plan := range plans {
+		if index > 0 {
+			_, _ = fmt.Fprintln(b)
+		}
+		if plan.Registry != `` {
+			_, _ = fmt.Fprintf(b, "Registry \"%s\"\n", plan.Registry)
+		}
+		writePlanText(b, plan)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePlanText(b *strings.Builder, plan *core.Plan) {
+	_, _ = fmt.Fprintf(
+		b,
+		"Plan: %d to register, %d to update, %d unchanged, %d to deregister\n",
+		len(plan.Create),
+		len(plan.Update),
+		len(plan.Unchanged),
+		len(plan.Deregister),
+	)
+	if plan.LimitExceeded != nil {
+		_, _ = fmt.Fprintf(b, "\nNo changes will be applied: %s\n", plan.LimitExceeded)
+	}
+	for _, service := range plan.Create {
+		_, _ = fmt.Fprintf(b, "\n+ %s\n", service.RegistrationID())
+		for _, field := range describeService(service) {
+			if field[1] != `` {
+				_, _ = fmt.Fprintf(b, "    %s: %s\n", field[0], field[1])
+			}
+		}
+	}
+	for _, service := range plan.Update {
+		_, _ = fmt.Fprintf(b, "\n~ %s\n", service.RegistrationID())
+		current := describeService(plan.Registered.Lookup(service.RegistrationID()))
+		incoming := describeService(service)
+		for index := range incoming {
+			if current[index][1] != incoming[index][1] {
+				_, _ = fmt.Fprintf(b, "    %s: %s => %s\n", incoming[index][0], current[index][1], incoming[index][1])
+			}
+		}
+	}
+	for _, service := range plan.Deregister {
+		_, _ = fmt.Fprintf(b, "\n- %s\n", service.RegistrationID())
+	}
+}
+
+// describeService return ordered list of field name and value pairs for printing
+func describeService(service *core.Service) [][2]string {
+	fields := [][2]string{
+		{`name`, ``},
+		{`address`, ``},
+		{`port`, ``},
+		{`tags`, ``},
+		{`meta`, ``},
+		{`node`, ``},
+		{`checks`, ``},
+	}
+	if service == nil {
+		return fields
+	}
+	fields[0][1] = service.Name
+	fields[1][1] = service.Address
+	if service.Port != nil {
+		fields[2][1] = fmt.Sprintf(`%d`, *service.Port)
+	}
+	if service.Tags != nil {
+		tags := append([]string{}, *service.Tags...)
+		sort.Strings(tags)
+		fields[3][1] = strings.Join(tags, `, `)
+	}
+	if service.Meta != nil {
+		fields[4][1] = describeMap(*service.Meta)
+	}
+	if service.Node != nil {
+		fields[5][1] = fmt.Sprintf(`%s (%s)`, service.Node.Node, service.Node.Address)
+	}
+	if service.Checks != nil {
+		checks := make([]string, 0, len(*service.Checks))
+		for _, check := range *service.Checks {
+			checks = append(checks, fmt.Sprintf(`%s (%s)`, check.Name, check.Type()))
+		}
+		sort.Strings(checks)
+		fields[6][1] = strings.Join(checks, `, `)
+	}
+	return fields
+}
+
+func describeMap(values map[string]string) string {
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, fmt.Sprintf(`%s=%s`, key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, `, `)
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func Test_lookupPlanPrinter(t *testing.T) {
+	suite.Run(t, new(lookupPlanPrinterTestSuite))
+}
+
+func Test_printPlanText(t *testing.T) {
+	suite.Run(t, new(printPlanTextTestSuite))
+}
+
+func Test_printPlanJSON(t *testing.T) {
+	suite.Run(t, new(printPlanJSONTestSuite))
+}
+
+// --- Suites ---
+
+type lookupPlanPrinterTestSuite struct {
+	suite.Suite
+}
+
+func (s *lookupPlanPrinterTestSuite) TestSuccess() {
+	for _, format := range []string{planFormatText, planFormatJSON} {
+		printer, err := lookupPlanPrinter(format)
+		s.NoError(err, format)
+		s.NotNil(printer, format)
+	}
+}
+
+func (s *lookupPlanPrinterTestSuite) TestErrorUnknownFormat() {
+	printer, err := lookupPlanPrinter(`yaml`)
+	s.Nil(printer)
+	s.EqualError(err, `unknown plan format "yaml", available formats: text, json`)
+}
+
+type printPlanTextTestSuite struct {
+	suite.Suite
+}
+
+func (s *printPlanTextTestSuite) TestSingle() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanText(b, []*core.Plan{newTestPlan()}))
+	s.Equal(`Plan: 1 to register, 1 to update, 1 unchanged, 1 to deregister
+
++ first
+    name: first
+    address: 10.0.0.1
+    port: 80
+    tags: a, b
+    meta: env=prod, team=core
+    node: node (10.0.0.100)
+
+~ second
+    address: 10.0.0.2 => 10.0.0.3
+    port: 80 => 8080
+
+- fourth
+`, b.String())
+}
+
+func (s *printPlanTextTestSuite) TestGrouped() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanText(b, []*core.Plan{
+		{Registry: `agent`},
+		{Registry: `catalog`, Deregister: core.Services{{Name: `fourth`, Address: `10.0.0.4`}}},
+	}))
+	s.Equal(`Registry "agent"
+Plan: 0 to register, 0 to update, 0 unchanged, 0 to deregister
+
+Registry "catalog"
+Plan: 0 to register, 0 to update, 0 unchanged, 1 to deregister
+
+- fourth
+`, b.String())
+}
+
+func (s *printPlanTextTestSuite) TestLimitExceeded() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanText(b, []*core.Plan{{
+		Deregister:    core.Services{{Name: `fourth`, Address: `10.0.0.4`}},
+		LimitExceeded: &core.DeregistrationLimitError{Limit: core.DeregistrationLimit{MaxPercent: 50}, Orphan: 1, Registered: 1},
+	}}))
+	s.Equal(`Plan: 0 to register, 0 to update, 0 unchanged, 1 to deregister
+
+No changes will be applied: refusing to deregister 1 of 1 registered services: deregistration limit is exceeded (max count 0, max percent 50.00), use force to override
+
+- fourth
+`, b.String())
+}
+
+func (s *printPlanTextTestSuite) TestEmpty() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanText(b, []*core.Plan{}))
+	s.Empty(b.String())
+}
+
+type printPlanJSONTestSuite struct {
+	suite.Suite
+}
+
+func (s *printPlanJSONTestSuite) TestSingle() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanJSON(b, []*core.Plan{newTestPlan()}))
+	s.JSONEq(`{
+		"Create": [{
+			"Name": "first",
+			"Address": "10.0.0.1",
+			"Port": 80,
+			"Tags": ["b", "a"],
+			"Meta": {"env": "prod", "team": "core"},
+			"Node": {"Node": "node", "Address": "10.0.0.100"}
+		}],
+		"Update": [{
+			"Current": {"Name": "second", "Address": "10.0.0.2", "Port": 80},
+			"Incoming": {"Name": "second", "Address": "10.0.0.3", "Port": 8080}
+		}],
+		"Unchanged": ["third"],
+		"Deregister": [{"Name": "fourth", "Address": "10.0.0.4"}]
+	}`, b.String())
+}
+
+func (s *printPlanJSONTestSuite) TestGrouped() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanJSON(b, []*core.Plan{
+		{Registry: `agent`},
+		{Registry: `catalog`, Unchanged: core.Services{{Name: `third`, Address: `10.0.0.5`}}},
+	}))
+	s.JSONEq(`[
+		{"Registry": "agent", "Create": null, "Update": [], "Unchanged": [], "Deregister": null},
+		{"Registry": "catalog", "Create": null, "Update": [], "Unchanged": ["third"], "Deregister": null}
+	]`, b.String())
+}
+
+func (s *printPlanJSONTestSuite) TestLimitExceeded() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanJSON(b, []*core.Plan{{
+		Deregister:    core.Services{{Name: `fourth`, Address: `10.0.0.4`}},
+		LimitExceeded: &core.DeregistrationLimitError{Limit: core.DeregistrationLimit{MaxPercent: 50}, Orphan: 1, Registered: 1},
+	}}))
+	s.JSONEq(`{
+		"Create": null,
+		"Update": [],
+		"Unchanged": [],
+		"Deregister": [{"Name": "fourth", "Address": "10.0.0.4"}],
+		"LimitExceeded": {"Limit": {"MaxCount": 0, "MaxPercent": 50}, "Orphan": 1, "Registered": 1}
+	}`, b.String())
+}
+
+func (s *printPlanJSONTestSuite) TestSingleGrouped() {
+	b := new(bytes.Buffer)
+	s.NoError(printPlanJSON(b, []*core.Plan{{Registry: `agent`}}))
+	s.JSONEq(`[
+		{"Registry": "agent", "Create": null, "Update": [], "Unchanged": [], "Deregister": null}
+	]`, b.String())
+}
+
+func newTestPlan() *core.Plan {
+	port, updatedPort := 80, 8080
+	tags := []string{`b`, `a`}
+	meta := map[string]string{`team`: `core`, `env`: `prod`}
+	current := &core.Service{Name: `second`, Address: `10.0.0.2`, Port: &port}
+	unchanged := &core.Service{Name: `third`, Address: `10.0.0.5`}
+	return &core.Plan{
+		Create: core.Services{{
+			Name:    `first`,
+			Address: `10.0.0.1`,
+			Port:    &port,
+			Tags:    &tags,
+			Meta:    &meta,
+			Node:    &core.Node{Node: `node`, Address: `10.0.0.100`},
+		}},
+		Update:     core.Services{{Name: `second`, Address: `10.0.0.3`, Port: &updatedPort}},
+		Unchanged:  core.Services{unchanged},
+		Deregister: core.Services{{Name: `fourth`, Address: `10.0.0.4`}},
+		Registered: core.Services{current, unchanged},
+	}
+}
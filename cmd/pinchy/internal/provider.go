@@ -7,7 +7,11 @@ import (
 	"github.com/insidieux/pinchy/internal/extension/registry"
 	"github.com/insidieux/pinchy/internal/extension/source"
 	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/filter"
+	multiRegistry "github.com/insidieux/pinchy/pkg/core/registry/multi"
+	"github.com/insidieux/pinchy/pkg/core/transformer"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -22,6 +26,12 @@ func provideViper(set *pflag.FlagSet) (*viper.Viper, error) {
 	if err := v.BindPFlags(set); err != nil {
 		return nil, errors.Wrap(err, `failed to bind command line arguments`)
 	}
+	if path := v.GetString(`config`); path != `` {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, errors.Wrapf(err, `failed to read config file "%s"`, path)
+		}
+	}
 	return v, nil
 }
 
@@ -42,16 +52,56 @@ func provideLogger(level logrus.Level) core.LoggerInterface {
 	return logger
 }
 
+// Provider for core.RetryPolicy
+func provideRetryPolicy(commandViper *viper.Viper) core.RetryPolicy {
+	return core.RetryPolicy{
+		MaxAttempts: commandViper.GetInt(`retry.max-attempts`),
+		BaseDelay:   commandViper.GetDuration(`retry.base-delay`),
+		MaxDelay:    commandViper.GetDuration(`retry.max-delay`),
+		Jitter:      commandViper.GetFloat64(`retry.jitter`),
+	}
+}
+
 // Provider for core.Registry
-func provideRegistry(commandViper *viper.Viper, factory registry.Factory, logger core.LoggerInterface) (core.Registry, func(), error) {
+// Registry is wrapped with core.RetryRegistry, if core.RetryPolicy allows more than one attempt.
+// Every member of core.RegistryGroup is wrapped separately, because core.Manager calls members directly
+func provideRegistry(commandViper *viper.Viper, factory registry.Factory, logger core.LoggerInterface, policy core.RetryPolicy) (core.Registry, func(), error) {
 	r, cleanup, err := factory(commandViper)
-	if r != nil {
-		lr, ok := r.(core.Loggable)
-		if ok {
-			lr.WithLogger(logger)
+	if r == nil {
+		return r, cleanup, err
+	}
+	lr, ok := r.(core.Loggable)
+	if ok {
+		lr.WithLogger(logger)
+	}
+	if policy.MaxAttempts <= 1 {
+		return r, cleanup, err
+	}
+	group, ok := r.(core.RegistryGroup)
+	if !ok {
+		return newRetryRegistry(r, policy, logger), cleanup, err
+	}
+	members := make([]core.RegistryMember, 0, len(group.Members()))
+	for _, member := range group.Members() {
+		members = append(members, core.RegistryMember{
+			Name:     member.Name,
+			Registry: newRetryRegistry(member.Registry, policy, logger),
+		})
+	}
+	mr, mErr := multiRegistry.NewRegistry(members)
+	if mErr != nil {
+		if cleanup != nil {
+			cleanup()
 		}
+		return nil, nil, mErr
 	}
-	return r, cleanup, err
+	return mr, cleanup, err
+}
+
+func newRetryRegistry(r core.Registry, policy core.RetryPolicy, logger core.LoggerInterface) core.Registry {
+	rr := core.NewRetryRegistry(r, policy)
+	rr.WithLogger(logger)
+	return rr
 }
 
 // Provider for core.Source
@@ -71,7 +121,132 @@ func provideManagerExitOnError(commandViper *viper.Viper) core.ManagerExitOnErro
 	return core.ManagerExitOnError(commandViper.GetBool(`manager.exit-on-error`))
 }
 
-// Provider for time.Ticker
-func provideTicker(commandViper *viper.Viper) *time.Ticker {
-	return time.NewTicker(commandViper.GetDuration(`scheduler.interval`))
+// Provider for core.Transformer list
+// Built-in transformers are applied in fixed order: drop, name prefix, default port, tags, meta
+func provideTransformers(commandViper *viper.Viper) ([]core.Transformer, error) {
+	var transformers []core.Transformer
+	if patterns := commandViper.GetStringSlice(`transform.drop`); len(patterns) > 0 {
+		drop, err := transformer.NewDrop(patterns)
+		if err != nil {
+			return nil, errors.Wrap(err, `flag "transform.drop" is invalid`)
+		}
+		transformers = append(transformers, drop)
+	}
+	if prefix := commandViper.GetString(`transform.name-prefix`); prefix != `` {
+		transformers = append(transformers, transformer.NamePrefix(prefix))
+	}
+	if port := commandViper.GetInt(`transform.default-port`); port != 0 {
+		transformers = append(transformers, transformer.DefaultPort(port))
+	}
+	if tags := commandViper.GetStringSlice(`transform.tags`); len(tags) > 0 {
+		transformers = append(transformers, transformer.Tags(tags))
+	}
+	if values := commandViper.GetStringMapString(`transform.meta`); len(values) > 0 {
+		meta, err := transformer.NewMeta(values)
+		if err != nil {
+			return nil, errors.Wrap(err, `flag "transform.meta" is invalid`)
+		}
+		transformers = append(transformers, meta)
+	}
+	return transformers, nil
+}
+
+// Provider for core.Filter
+// Filter is nil, if no include and exclude expressions are passed, so all services are owned by manager
+func provideFilter(commandViper *viper.Viper, set *pflag.FlagSet) (core.Filter, error) {
+	include, err := stringArray(commandViper, set, `filter.include`)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := stringArray(commandViper, set, `filter.exclude`)
+	if err != nil {
+		return nil, err
+	}
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	f, err := filter.NewFilter(include, exclude)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create filter`)
+	}
+	return f, nil
+}
+
+// stringArray return values of pflag.StringArray flag, which are not split by comma.
+// Viper returns StringArray flag value as single string, so value of changed flag is read from pflag.FlagSet,
+// values from environment and config file are read by viper
+func stringArray(commandViper *viper.Viper, set *pflag.FlagSet, key string) ([]string, error) {
+	if flag := set.Lookup(key); flag != nil && flag.Changed {
+		values, err := set.GetStringArray(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, `flag "%s" is invalid`, key)
+		}
+		return values, nil
+	}
+	if !commandViper.IsSet(key) {
+		return nil, nil
+	}
+	return commandViper.GetStringSlice(key), nil
+}
+
+// Provider for core.ManagerOption list
+func provideManagerOptions(commandViper *viper.Viper, transformers []core.Transformer, serviceFilter core.Filter, metrics core.Metrics) []core.ManagerOption {
+	return []core.ManagerOption{
+		core.WithTransformers(transformers...),
+		core.WithFilter(serviceFilter),
+		core.WithMetrics(metrics),
+		core.WithFullResync(commandViper.GetBool(`manager.full-resync`)),
+		core.WithDeregistrationLimit(core.DeregistrationLimit{
+			MaxCount:   commandViper.GetInt(`manager.max-deregister`),
+			MaxPercent: commandViper.GetFloat64(`manager.max-deregister-percent`),
+		}),
+		core.WithForce(commandViper.GetBool(`manager.force`)),
+		core.WithConcurrency(commandViper.GetInt(`manager.concurrency`)),
+	}
+}
+
+// watchableSource return core.Source as core.WatchableSource or nil, if core.Source does not support change notifications
+func watchableSource(src core.Source) core.WatchableSource {
+	if ws, ok := src.(core.WatchableSource); ok {
+		return ws
+	}
+	return nil
+}
+
+// Provider for core.SchedulerOption list
+// Scheduler listens source change notifications, if source implements core.WatchableSource
+// Scheduler runs manager only while leadership is held, if core.LeaderElector is provided
+func provideSchedulerOptions(commandViper *viper.Viper, metrics core.Metrics, src core.Source, elector core.LeaderElector) []core.SchedulerOption {
+	options := []core.SchedulerOption{
+		core.WithSchedulerMetrics(metrics),
+		core.WithJitter(commandViper.GetDuration(`scheduler.jitter`)),
+		core.WithFailureBackoff(commandViper.GetDuration(`scheduler.failure-backoff`)),
+		core.WithShutdownGrace(commandViper.GetDuration(`scheduler.shutdown-grace`)),
+	}
+	options = append(options, core.WithWatch(watchableSource(src), commandViper.GetDuration(`scheduler.debounce`)))
+	if elector != nil {
+		options = append(options, core.WithLeaderElection(elector))
+	}
+	return options
+}
+
+// Provider for core.Schedule
+// Cron expression from flag "scheduler.cron" has priority over flag "scheduler.interval"
+func provideSchedule(commandViper *viper.Viper) (core.Schedule, error) {
+	expression := commandViper.GetString(`scheduler.cron`)
+	if expression == `` {
+		interval := commandViper.GetDuration(`scheduler.interval`)
+		if interval <= 0 {
+			return nil, errors.Errorf(`scheduler interval must be positive, got "%s"`, interval)
+		}
+		return core.IntervalSchedule(interval), nil
+	}
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to parse scheduler cron expression "%s"`, expression)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, errors.Errorf(`scheduler cron expression "%s" never matches`, expression)
+	}
+	return schedule, nil
 }
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func Test_provideFilter(t *testing.T) {
+	suite.Run(t, new(provideFilterTestSuite))
+}
+
+// --- Suites ---
+
+type provideFilterTestSuite struct {
+	suite.Suite
+	set *pflag.FlagSet
+}
+
+func (s *provideFilterTestSuite) SetupTest() {
+	s.set = pflag.NewFlagSet(`test`, pflag.ContinueOnError)
+	s.set.StringArray(`filter.include`, nil, ``)
+	s.set.StringArray(`filter.exclude`, nil, ``)
+}
+
+func (s *provideFilterTestSuite) TestWithoutExpressions() {
+	s.NoError(s.set.Parse(nil))
+	v, err := provideViper(s.set)
+	s.NoError(err)
+	f, err := provideFilter(v, s.set)
+	s.NoError(err)
+	s.Nil(f)
+}
+
+func (s *provideFilterTestSuite) TestCommaInRegexp() {
+	s.NoError(s.set.Parse([]string{
+		`--filter.include`, `name~^(a|b){1,3}$`,
+		`--filter.include`, `meta.env~prod,stage`,
+		`--filter.exclude`, `tag~^(canary|debug)$`,
+	}))
+	v, err := provideViper(s.set)
+	s.NoError(err)
+	f, err := provideFilter(v, s.set)
+	s.NoError(err)
+	s.NotNil(f)
+
+	service := func(name string, env string, tags ...string) *core.Service {
+		return &core.Service{Name: name, Meta: &map[string]string{`env`: env}, Tags: &tags}
+	}
+	s.True(f.Match(service(`ab`, `prod,stage`)))
+	s.True(f.Match(service(`bab`, `prod,stage`, `stable`)))
+	s.False(f.Match(service(`abab`, `prod,stage`)))
+	s.False(f.Match(service(`ab`, `prod`)))
+	s.False(f.Match(service(`ab`, `prod,stage`, `canary`)))
+}
+
+func (s *provideFilterTestSuite) TestConfig() {
+	s.NoError(s.set.Parse(nil))
+	v, err := provideViper(s.set)
+	s.NoError(err)
+	v.Set(`filter.include`, []interface{}{`name~^a{1,2}$`})
+	f, err := provideFilter(v, s.set)
+	s.NoError(err)
+	s.NotNil(f)
+	s.True(f.Match(&core.Service{Name: `aa`}))
+	s.False(f.Match(&core.Service{Name: `aaa`}))
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/insidieux/pinchy/pkg/core"
+)
+
+// printReport write core.Report as table with result for every service and summary line.
+// Registry column is printed only for registry group
+func printReport(w io.Writer, report *core.Report) error {
+	grouped := false
+	for _, item := range report.Items {
+		grouped = grouped || item.Registry != ``
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if grouped {
+		_, _ = fmt.Fprint(tw, "REGISTRY\t")
+	}
+	_, _ = fmt.Fprintln(tw, "ID\tACTION\tDURATION\tERROR")
+	for _, item := range report.Items {
+		message := ``
+		if item.Error != nil {
+			message = item.Error.Error()
+		}
+		if grouped {
+			_, _ = fmt.Fprintf(tw, "%s\t", item.Registry)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.ID, item.Action, item.Duration, message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nSync finished in %s: %s\n", report.Duration(), report)
+	return err
+}
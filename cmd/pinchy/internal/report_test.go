@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func Test_printReport(t *testing.T) {
+	suite.Run(t, new(printReportTestSuite))
+}
+
+// --- Suites ---
+
+type printReportTestSuite struct {
+	suite.Suite
+	report *core.Report
+}
+
+func (s *printReportTestSuite) SetupTest() {
+	started := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.report = &core.Report{
+		StartedAt:  started,
+		FinishedAt: started.Add(1500 * time.Millisecond),
+		Items:      make([]*core.ReportItem, 0),
+	}
+}
+
+func (s *printReportTestSuite) TestSingle() {
+	s.report.Add(``, `first`, core.ActionRegistered, nil, time.Second)
+	s.report.Add(``, `second-service`, core.ActionFailed, errors.New(`connection refused`), 20*time.Millisecond)
+
+	b := new(bytes.Buffer)
+	s.NoError(printReport(b, s.report))
+	s.Equal(`ID              ACTION      DURATION  ERROR
+first           registered  1s        
+second-service  failed      20ms      connection refused
+
+Sync finished in 1.5s: registered: 1, updated: 0, unchanged: 0, deregistered: 0, skipped: 0, failed: 1
+`, b.String())
+}
+
+func (s *printReportTestSuite) TestGrouped() {
+	s.report.Add(`agent`, `first`, core.ActionUnchanged, nil, 0)
+	s.report.Add(`catalog-dc2`, `first`, core.ActionDeregistered, nil, 5*time.Millisecond)
+
+	b := new(bytes.Buffer)
+	s.NoError(printReport(b, s.report))
+	s.Equal(`REGISTRY     ID     ACTION        DURATION  ERROR
+agent        first  unchanged     0s        
+catalog-dc2  first  deregistered  5ms       
+
+Sync finished in 1.5s: registered: 0, updated: 0, unchanged: 1, deregistered: 1, skipped: 0, failed: 0
+`, b.String())
+}
+
+func (s *printReportTestSuite) TestEmpty() {
+	b := new(bytes.Buffer)
+	s.NoError(printReport(b, s.report))
+	s.Equal(`ID  ACTION  DURATION  ERROR
+
+Sync finished in 1.5s: registered: 0, updated: 0, unchanged: 0, deregistered: 0, skipped: 0, failed: 0
+`, b.String())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/insidieux/pinchy/internal/extension/registry"
 	"github.com/insidieux/pinchy/internal/extension/source"
 	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/metrics"
 	"github.com/spf13/pflag"
 )
 
@@ -17,26 +18,53 @@ var (
 			provideLoggerLevel,
 			provideLogger,
 		),
+		provideRetryPolicy,
 		provideRegistry,
 		provideSource,
 		provideManagerExitOnError,
+		provideTransformers,
+		provideFilter,
+		provideManagerOptions,
 		core.NewManager,
 	)
 	schedulerWireSet = wire.NewSet(
-		provideTicker,
-		managerWireSet,
+		provideViper,
+		wire.NewSet(
+			provideLoggerLevel,
+			provideLogger,
+		),
+		provideSchedule,
+		provideMetrics,
+		provideInitialPipeline,
+		providePipelineManager,
+		providePipelineSource,
+		provideLeaderElector,
+		provideSchedulerOptions,
 		core.NewScheduler,
 	)
+	daemonWireSet = wire.NewSet(
+		schedulerWireSet,
+		provideAdminServer,
+		provideDaemon,
+	)
 )
 
 func newManager(_ *pflag.FlagSet, _ source.Factory, _ registry.Factory) (core.ManagerInterface, func(), error) {
 	panic(wire.Build(
 		managerWireSet,
+		provideDisabledMetrics,
 	))
 }
 
-func newScheduler(_ *pflag.FlagSet, _ source.Factory, _ registry.Factory) (*core.Scheduler, func(), error) {
+func newDaemon(_ *pflag.FlagSet, _ metrics.DefaultRegistry, _ pipelineFactory) (*daemon, func(), error) {
 	panic(wire.Build(
-		schedulerWireSet,
+		daemonWireSet,
+	))
+}
+
+func newPipeline(_ *pflag.FlagSet, _ source.Factory, _ registry.Factory, _ core.Metrics) (*pipeline, func(), error) {
+	panic(wire.Build(
+		managerWireSet,
+		providePipeline,
 	))
 }
@@ -4,9 +4,14 @@ go 1.15
 
 require (
 	github.com/agrea/ptr v0.0.0-20180711073057-77a518d99b7b
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/google/wire v0.5.0
-	github.com/hashicorp/consul/api v1.8.1
+	github.com/hashicorp/consul/api v1.13.1
+	github.com/hashicorp/hcl v1.0.0
+	github.com/pelletier/go-toml v1.2.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.9.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sethvargo/go-signalcontext v0.1.0
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/afero v1.5.1
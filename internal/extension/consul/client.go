@@ -0,0 +1,141 @@
+package consul
+
+import (
+	pkgConsul "github.com/insidieux/pinchy/pkg/core/consul"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagConsulAddress         = `address`
+	flagToken                 = `token`
+	flagTokenFile             = `token-file`
+	flagTLSCAFile             = `tls.ca-file`
+	flagTLSCertFile           = `tls.cert-file`
+	flagTLSKeyFile            = `tls.key-file`
+	flagTLSServerName         = `tls.server-name`
+	flagTLSInsecureSkipVerify = `tls.insecure-skip-verify`
+	flagDatacenter            = `datacenter`
+	flagNamespace             = `namespace`
+	flagPartition             = `partition`
+)
+
+type (
+	// Client contains Consul HTTP API endpoints used by registry and source extensions and leader election
+	Client interface {
+		Agent() *api.Agent
+		Catalog() *api.Catalog
+		Health() *api.Health
+		Raw() *api.Raw
+		LockOpts(*api.LockOptions) (*api.Lock, error)
+	}
+
+	// Factory creates Consul client by api.Config
+	Factory func(*api.Config) (*api.Client, error)
+
+	// FlagNamer is helper for generation valid extension flag name, e.g. registry.MakeFlagName
+	FlagNamer func(name string) string
+)
+
+// RegisterFlags adds Consul client flags: address, ACL token, TLS, datacenter, namespace and admin partition
+func RegisterFlags(set *pflag.FlagSet, name FlagNamer) {
+	set.String(name(flagConsulAddress), `127.0.0.1:8500`, `Consul http api address`)
+	set.String(name(flagToken), ``, `Consul ACL token`)
+	set.String(name(flagTokenFile), ``, `Path to file with Consul ACL token`)
+	set.String(name(flagTLSCAFile), ``, `Path to CA certificate file for verify Consul server certificate`)
+	set.String(name(flagTLSCertFile), ``, `Path to client certificate file for Consul TLS authentication`)
+	set.String(name(flagTLSKeyFile), ``, `Path to client private key file for Consul TLS authentication`)
+	set.String(name(flagTLSServerName), ``, `Server name used for verify Consul server certificate`)
+	set.Bool(name(flagTLSInsecureSkipVerify), false, `Skip Consul server certificate verification`)
+	set.String(name(flagDatacenter), ``, `Consul datacenter, default is datacenter of Consul agent`)
+	set.String(name(flagNamespace), ``, `Consul namespace (Consul Enterprise only)`)
+	set.String(name(flagPartition), ``, `Consul admin partition (Consul Enterprise only)`)
+}
+
+// NewClientConfig provide api.Config from flags added by RegisterFlags
+func NewClientConfig(v *viper.Viper, name FlagNamer) (*api.Config, error) {
+	flag := name(flagConsulAddress)
+	address := v.GetString(flag)
+	if address == `` {
+		return nil, errors.Errorf(`Flag "%s" is required`, flag)
+	}
+
+	token := v.GetString(name(flagToken))
+	tokenFile := v.GetString(name(flagTokenFile))
+	if token != `` && tokenFile != `` {
+		return nil, errors.Errorf(
+			`Flags "%s" and "%s" cannot be used together`,
+			name(flagToken),
+			name(flagTokenFile),
+		)
+	}
+
+	certFile := v.GetString(name(flagTLSCertFile))
+	keyFile := v.GetString(name(flagTLSKeyFile))
+	if (certFile == ``) != (keyFile == ``) {
+		return nil, errors.Errorf(
+			`Flags "%s" and "%s" must be used together`,
+			name(flagTLSCertFile),
+			name(flagTLSKeyFile),
+		)
+	}
+
+	cfg := api.DefaultConfig()
+	cfg.Address = address
+	if token != `` {
+		cfg.Token = token
+	}
+	if tokenFile != `` {
+		cfg.Token = ``
+		cfg.TokenFile = tokenFile
+	}
+	if dc := v.GetString(name(flagDatacenter)); dc != `` {
+		cfg.Datacenter = dc
+	}
+	if ns := v.GetString(name(flagNamespace)); ns != `` {
+		cfg.Namespace = ns
+	}
+	if partition := v.GetString(name(flagPartition)); partition != `` {
+		cfg.Partition = partition
+	}
+	if ca := v.GetString(name(flagTLSCAFile)); ca != `` {
+		cfg.TLSConfig.CAFile = ca
+	}
+	if certFile != `` {
+		cfg.TLSConfig.CertFile = certFile
+		cfg.TLSConfig.KeyFile = keyFile
+	}
+	if serverName := v.GetString(name(flagTLSServerName)); serverName != `` {
+		cfg.TLSConfig.Address = serverName
+	}
+	if v.GetBool(name(flagTLSInsecureSkipVerify)) {
+		cfg.TLSConfig.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
+// NewScope provide pkgConsul.Scope bound to datacenter, namespace and admin partition of api.Config
+func NewScope(cfg *api.Config) pkgConsul.Scope {
+	return pkgConsul.Scope{
+		Datacenter: cfg.Datacenter,
+		Namespace:  cfg.Namespace,
+		Partition:  cfg.Partition,
+	}
+}
+
+// NewFactory provide default Factory
+func NewFactory() Factory {
+	return api.NewClient
+}
+
+// NewClient provide Client created by Factory
+func NewClient(cfg *api.Config, factory Factory) (Client, error) {
+	c, err := factory(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create consul client`)
+	}
+	return c, nil
+}
@@ -4,6 +4,7 @@ import (
 	pkgConsul "github.com/insidieux/pinchy/pkg/core/registry/consul"
 
 	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/internal/extension/consul"
 	"github.com/insidieux/pinchy/internal/extension/registry"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul/agent"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul/catalog"
@@ -17,21 +18,12 @@ const (
 	registryAgentName   = `consul-agent`
 	registryCatalogName = `consul-catalog`
 
-	flagConsulAddress = `address`
-	flagTag           = `tag`
-)
-
-type (
-	client interface {
-		Agent() *api.Agent
-		Catalog() *api.Catalog
-	}
-	factory func(*api.Config) (*api.Client, error)
+	flagTag = `tag`
 )
 
 func init() {
 	set := pflag.NewFlagSet(registryName, pflag.ExitOnError)
-	set.String(registry.MakeFlagName(flagConsulAddress), `127.0.0.1:8500`, `Consul http api address`)
+	consul.RegisterFlags(set, registry.MakeFlagName)
 	set.String(registry.MakeFlagName(flagTag), `pinchy`, `Common service tag added for all registered service`)
 	// register deprecated consul agent registry
 	if err := registry.Register(registryName, set, NewAgentRegistry, true); err != nil {
@@ -57,33 +49,21 @@ func provideTag(v *viper.Viper) (pkgConsul.Tag, error) {
 }
 
 func provideClientConfig(v *viper.Viper) (*api.Config, error) {
-	flag := registry.MakeFlagName(flagConsulAddress)
-	address := v.GetString(flag)
-	if address == `` {
-		return nil, errors.Errorf(`Flag "%s" is required`, flag)
-	}
-
-	cfg := api.DefaultConfig()
-	cfg.Address = address
-	return cfg, nil
+	return consul.NewClientConfig(v, registry.MakeFlagName)
 }
 
-func provideConsulClientFactory() factory {
-	return api.NewClient
+func provideAgent(c consul.Client) agent.Agent {
+	return c.Agent()
 }
 
-func provideClient(cfg *api.Config, factory factory) (client, error) {
-	c, err := factory(cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, `failed to create consul client`)
-	}
-	return c, nil
+func provideRaw(c consul.Client) agent.Raw {
+	return c.Raw()
 }
 
-func provideAgent(c client) agent.Agent {
-	return c.Agent()
+func provideCatalog(c consul.Client) catalog.Catalog {
+	return c.Catalog()
 }
 
-func provideCatalog(c client) catalog.Catalog {
-	return c.Catalog()
+func provideHealth(c consul.Client) catalog.Health {
+	return c.Health()
 }
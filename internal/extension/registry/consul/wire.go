@@ -4,6 +4,7 @@ package consul
 
 import (
 	"github.com/google/wire"
+	"github.com/insidieux/pinchy/internal/extension/consul"
 	"github.com/insidieux/pinchy/pkg/core"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul/agent"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul/catalog"
@@ -13,8 +14,8 @@ import (
 var (
 	wireSet = wire.NewSet(
 		provideClientConfig,
-		provideConsulClientFactory,
-		provideClient,
+		consul.NewFactory,
+		consul.NewClient,
 		provideTag,
 	)
 )
@@ -23,6 +24,8 @@ func NewAgentRegistry(*viper.Viper) (core.Registry, func(), error) {
 	panic(wire.Build(
 		wireSet,
 		provideAgent,
+		provideRaw,
+		consul.NewScope,
 		agent.NewRegistry,
 		wire.Bind(new(core.Registry), new(*agent.Registry)),
 	))
@@ -32,6 +35,8 @@ func NewCatalogRegistry(*viper.Viper) (core.Registry, func(), error) {
 	panic(wire.Build(
 		wireSet,
 		provideCatalog,
+		provideHealth,
+		consul.NewScope,
 		catalog.NewRegistry,
 		wire.Bind(new(core.Registry), new(*catalog.Registry)),
 	))
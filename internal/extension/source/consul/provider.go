@@ -0,0 +1,83 @@
+package consul
+
+import (
+	"time"
+
+	pkgConsul "github.com/insidieux/pinchy/pkg/core/source/consul"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/internal/extension/consul"
+	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	sourceName = `consul`
+
+	flagService       = `service`
+	flagTag           = `tag`
+	flagFilter        = `filter`
+	flagExcludeTag    = `exclude-tag`
+	flagWatch         = `watch`
+	flagWaitTime      = `wait-time`
+	flagRetryDelay    = `retry-delay`
+	flagRetryMaxDelay = `retry-max-delay`
+)
+
+func init() {
+	set := pflag.NewFlagSet(sourceName, pflag.ExitOnError)
+	consul.RegisterFlags(set, source.MakeFlagName)
+	set.StringSlice(source.MakeFlagName(flagService), nil, `Names of services for fetch from catalog, all services except "consul" by default`)
+	set.StringSlice(source.MakeFlagName(flagTag), nil, `Tags, which service instance must contain`)
+	set.String(source.MakeFlagName(flagFilter), ``, `Consul filter expression for service instances, e.g. ServiceMeta.env == "prod"`)
+	set.String(source.MakeFlagName(flagExcludeTag), `pinchy`, `Skip service instances with tag, e.g. registered by pinchy (empty means disabled)`)
+	set.Bool(source.MakeFlagName(flagWatch), false, `Watch catalog with blocking queries and sync immediately in watch mode`)
+	set.Duration(source.MakeFlagName(flagWaitTime), 5*time.Minute, `Maximum duration of blocking query`)
+	set.Duration(source.MakeFlagName(flagRetryDelay), 5*time.Second, `Delay before next blocking query after failure, delay is doubled for every next failure`)
+	set.Duration(source.MakeFlagName(flagRetryMaxDelay), time.Minute, `Max delay before next blocking query after failures`)
+	if err := source.Register(sourceName, set, NewSource, false); err != nil {
+		panic(err)
+	}
+}
+
+func provideClientConfig(v *viper.Viper) (*api.Config, error) {
+	return consul.NewClientConfig(v, source.MakeFlagName)
+}
+
+func provideCatalog(c consul.Client) *api.Catalog {
+	return c.Catalog()
+}
+
+func provideQuery(v *viper.Viper) pkgConsul.Query {
+	return pkgConsul.Query{
+		Services:   v.GetStringSlice(source.MakeFlagName(flagService)),
+		Tags:       v.GetStringSlice(source.MakeFlagName(flagTag)),
+		Filter:     v.GetString(source.MakeFlagName(flagFilter)),
+		ExcludeTag: v.GetString(source.MakeFlagName(flagExcludeTag)),
+	}
+}
+
+func provideWatch(v *viper.Viper) (pkgConsul.Watch, error) {
+	watch := pkgConsul.Watch{
+		Enabled:       v.GetBool(source.MakeFlagName(flagWatch)),
+		WaitTime:      v.GetDuration(source.MakeFlagName(flagWaitTime)),
+		RetryDelay:    v.GetDuration(source.MakeFlagName(flagRetryDelay)),
+		RetryMaxDelay: v.GetDuration(source.MakeFlagName(flagRetryMaxDelay)),
+	}
+	if watch.WaitTime <= 0 {
+		return pkgConsul.Watch{}, errors.Errorf(`flag "%s" must be positive`, source.MakeFlagName(flagWaitTime))
+	}
+	if watch.RetryDelay <= 0 {
+		return pkgConsul.Watch{}, errors.Errorf(`flag "%s" must be positive`, source.MakeFlagName(flagRetryDelay))
+	}
+	if watch.RetryMaxDelay < watch.RetryDelay {
+		return pkgConsul.Watch{}, errors.Errorf(
+			`flag "%s" must not be less than "%s"`,
+			source.MakeFlagName(flagRetryMaxDelay),
+			source.MakeFlagName(flagRetryDelay),
+		)
+	}
+	return watch, nil
+}
@@ -0,0 +1,28 @@
+// +build wireinject
+
+package consul
+
+import (
+	pkgConsul "github.com/insidieux/pinchy/pkg/core/source/consul"
+
+	"github.com/google/wire"
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/internal/extension/consul"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/spf13/viper"
+)
+
+func NewSource(*viper.Viper) (core.Source, func(), error) {
+	panic(wire.Build(
+		provideClientConfig,
+		consul.NewFactory,
+		consul.NewClient,
+		consul.NewScope,
+		provideCatalog,
+		wire.Bind(new(pkgConsul.Catalog), new(*api.Catalog)),
+		provideQuery,
+		provideWatch,
+		pkgConsul.NewSource,
+		wire.Bind(new(core.Source), new(*pkgConsul.Source)),
+	))
+}
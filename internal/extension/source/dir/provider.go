@@ -0,0 +1,87 @@
+package dir
+
+import (
+	"os"
+
+	pkgFile "github.com/insidieux/pinchy/pkg/core/source/file"
+
+	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	sourceName = `dir`
+
+	flagPattern     = `pattern`
+	flagMetaKey     = `meta-key`
+	flagWatch       = `watch`
+	flagFormat      = `format`
+	flagColumns     = `csv.columns`
+	flagInterpolate = `interpolate`
+	flagTemplate    = `template`
+	flagMissing     = `env.allow-missing`
+)
+
+func init() {
+	set := pflag.NewFlagSet(sourceName, pflag.ExitOnError)
+	set.String(source.MakeFlagName(flagPattern), ``, `Glob pattern of files with services, e.g. /etc/pinchy/services.d/*.yml`)
+	set.String(source.MakeFlagName(flagMetaKey), `source-file`, `Service meta key with name of file, which contains service (empty means disabled)`)
+	set.Bool(source.MakeFlagName(flagWatch), false, `Watch files for changes and sync immediately in watch mode`)
+	set.String(source.MakeFlagName(flagFormat), ``, `Format of files: yaml, json, toml, hcl or csv (detected by file extension by default)`)
+	set.StringToString(source.MakeFlagName(flagColumns), nil, `Mapping of csv header to service field: name, id, address, port, tags, meta.<key> or "-" for skip column`)
+	set.Bool(source.MakeFlagName(flagInterpolate), false, `Replace ${VAR} placeholders with environment variables`)
+	set.Bool(source.MakeFlagName(flagTemplate), false, `Render files as Go template before ${VAR} interpolation`)
+	set.Bool(source.MakeFlagName(flagMissing), false, `Replace missing environment variables with empty string instead of error`)
+	if err := source.Register(sourceName, set, NewSource, false); err != nil {
+		panic(err)
+	}
+}
+
+func provideFs() afero.Afero {
+	return afero.Afero{
+		Fs: afero.NewReadOnlyFs(afero.NewOsFs()),
+	}
+}
+
+func provideGlobber(fs afero.Afero) pkgFile.FsGlobber {
+	return pkgFile.FsGlobber{
+		Fs: fs.Fs,
+	}
+}
+
+func providePattern(v *viper.Viper) (pkgFile.Pattern, error) {
+	flag := source.MakeFlagName(flagPattern)
+	pattern := v.GetString(flag)
+	if pattern == `` {
+		return ``, errors.Errorf(`flag "%s" is required`, flag)
+	}
+	return pkgFile.Pattern(pattern), nil
+}
+
+func provideMetaKey(v *viper.Viper) pkgFile.MetaKey {
+	return pkgFile.MetaKey(v.GetString(source.MakeFlagName(flagMetaKey)))
+}
+
+func provideWatch(v *viper.Viper) pkgFile.Watch {
+	return pkgFile.Watch(v.GetBool(source.MakeFlagName(flagWatch)))
+}
+
+func provideDecoder(v *viper.Viper) (*pkgFile.Decoder, error) {
+	format, err := pkgFile.ParseFormat(v.GetString(source.MakeFlagName(flagFormat)))
+	if err != nil {
+		return nil, errors.Wrapf(err, `invalid flag "%s"`, source.MakeFlagName(flagFormat))
+	}
+	return pkgFile.NewDecoder(format, v.GetStringMapString(source.MakeFlagName(flagColumns))), nil
+}
+
+func provideRenderer(v *viper.Viper) *pkgFile.Renderer {
+	return pkgFile.NewRenderer(
+		os.LookupEnv,
+		pkgFile.Interpolate(v.GetBool(source.MakeFlagName(flagInterpolate))),
+		pkgFile.Template(v.GetBool(source.MakeFlagName(flagTemplate))),
+		pkgFile.AllowMissing(v.GetBool(source.MakeFlagName(flagMissing))),
+	)
+}
@@ -0,0 +1,28 @@
+// +build wireinject
+
+package dir
+
+import (
+	pkgFile "github.com/insidieux/pinchy/pkg/core/source/file"
+
+	"github.com/google/wire"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+)
+
+func NewSource(*viper.Viper) (core.Source, func(), error) {
+	panic(wire.Build(
+		provideFs,
+		wire.Bind(new(pkgFile.Reader), new(afero.Afero)),
+		provideGlobber,
+		wire.Bind(new(pkgFile.Globber), new(pkgFile.FsGlobber)),
+		providePattern,
+		provideMetaKey,
+		provideWatch,
+		provideRenderer,
+		provideDecoder,
+		pkgFile.NewDirSource,
+		wire.Bind(new(core.Source), new(*pkgFile.DirSource)),
+	))
+}
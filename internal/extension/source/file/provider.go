@@ -1,6 +1,8 @@
 package file
 
 import (
+	"os"
+
 	pkgFile "github.com/insidieux/pinchy/pkg/core/source/file"
 
 	"github.com/insidieux/pinchy/internal/extension/source"
@@ -13,12 +15,24 @@ import (
 const (
 	sourceName = `file`
 
-	flagFilePath = `path`
+	flagFilePath    = `path`
+	flagWatch       = `watch`
+	flagFormat      = `format`
+	flagColumns     = `csv.columns`
+	flagInterpolate = `interpolate`
+	flagTemplate    = `template`
+	flagMissing     = `env.allow-missing`
 )
 
 func init() {
 	set := pflag.NewFlagSet(sourceName, pflag.ExitOnError)
-	set.String(source.MakeFlagName(flagFilePath), `$HOME/services.yml`, `YML file config path`)
+	set.String(source.MakeFlagName(flagFilePath), `$HOME/services.yml`, `Services file config path`)
+	set.Bool(source.MakeFlagName(flagWatch), false, `Watch file for changes and sync immediately in watch mode`)
+	set.String(source.MakeFlagName(flagFormat), ``, `Format of file: yaml, json, toml, hcl or csv (detected by file extension by default)`)
+	set.StringToString(source.MakeFlagName(flagColumns), nil, `Mapping of csv header to service field: name, id, address, port, tags, meta.<key> or "-" for skip column`)
+	set.Bool(source.MakeFlagName(flagInterpolate), false, `Replace ${VAR} placeholders with environment variables`)
+	set.Bool(source.MakeFlagName(flagTemplate), false, `Render file as Go template before ${VAR} interpolation`)
+	set.Bool(source.MakeFlagName(flagMissing), false, `Replace missing environment variables with empty string instead of error`)
 
 	if err := source.Register(sourceName, set, NewSource, false); err != nil {
 		panic(err)
@@ -39,3 +53,24 @@ func providePath(v *viper.Viper) (pkgFile.Path, error) {
 	}
 	return pkgFile.Path(path), nil
 }
+
+func provideWatch(v *viper.Viper) pkgFile.Watch {
+	return pkgFile.Watch(v.GetBool(source.MakeFlagName(flagWatch)))
+}
+
+func provideDecoder(v *viper.Viper) (*pkgFile.Decoder, error) {
+	format, err := pkgFile.ParseFormat(v.GetString(source.MakeFlagName(flagFormat)))
+	if err != nil {
+		return nil, errors.Wrapf(err, `invalid flag "%s"`, source.MakeFlagName(flagFormat))
+	}
+	return pkgFile.NewDecoder(format, v.GetStringMapString(source.MakeFlagName(flagColumns))), nil
+}
+
+func provideRenderer(v *viper.Viper) *pkgFile.Renderer {
+	return pkgFile.NewRenderer(
+		os.LookupEnv,
+		pkgFile.Interpolate(v.GetBool(source.MakeFlagName(flagInterpolate))),
+		pkgFile.Template(v.GetBool(source.MakeFlagName(flagTemplate))),
+		pkgFile.AllowMissing(v.GetBool(source.MakeFlagName(flagMissing))),
+	)
+}
@@ -16,6 +16,9 @@ func NewSource(*viper.Viper) (core.Source, func(), error) {
 		provideReader,
 		wire.Bind(new(pkgFile.Reader), new(afero.Afero)),
 		providePath,
+		provideWatch,
+		provideRenderer,
+		provideDecoder,
 		pkgFile.NewSource,
 		wire.Bind(new(core.Source), new(*pkgFile.Source)),
 	))
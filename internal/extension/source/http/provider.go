@@ -0,0 +1,133 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	pkgHTTP "github.com/insidieux/pinchy/pkg/core/source/http"
+
+	"github.com/insidieux/pinchy/internal/extension/source"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	sourceName = `http`
+
+	flagURL                   = `url`
+	flagHeader                = `header`
+	flagToken                 = `token`
+	flagTokenFile             = `token-file`
+	flagTimeout               = `timeout`
+	flagTLSCAFile             = `tls.ca-file`
+	flagTLSCertFile           = `tls.cert-file`
+	flagTLSKeyFile            = `tls.key-file`
+	flagTLSServerName         = `tls.server-name`
+	flagTLSInsecureSkipVerify = `tls.insecure-skip-verify`
+
+	headerAuthorization = `Authorization`
+)
+
+func init() {
+	set := pflag.NewFlagSet(sourceName, pflag.ExitOnError)
+	set.String(source.MakeFlagName(flagURL), ``, `URL of endpoint, which responds with JSON or YAML list of services`)
+	set.StringToString(source.MakeFlagName(flagHeader), nil, `Headers added to every request, e.g. X-Api-Key=secret`)
+	set.String(source.MakeFlagName(flagToken), ``, `Bearer token sent in Authorization header`)
+	set.String(source.MakeFlagName(flagTokenFile), ``, `Path to file with bearer token sent in Authorization header`)
+	set.Duration(source.MakeFlagName(flagTimeout), 10*time.Second, `Timeout of request to endpoint`)
+	set.String(source.MakeFlagName(flagTLSCAFile), ``, `Path to CA certificate file for verify endpoint certificate`)
+	set.String(source.MakeFlagName(flagTLSCertFile), ``, `Path to client certificate file for TLS authentication`)
+	set.String(source.MakeFlagName(flagTLSKeyFile), ``, `Path to client private key file for TLS authentication`)
+	set.String(source.MakeFlagName(flagTLSServerName), ``, `Server name used for verify endpoint certificate`)
+	set.Bool(source.MakeFlagName(flagTLSInsecureSkipVerify), false, `Skip endpoint certificate verification`)
+	if err := source.Register(sourceName, set, NewSource, false); err != nil {
+		panic(err)
+	}
+}
+
+func provideURL(v *viper.Viper) (pkgHTTP.URL, error) {
+	flag := source.MakeFlagName(flagURL)
+	url := v.GetString(flag)
+	if url == `` {
+		return ``, errors.Errorf(`flag "%s" is required`, flag)
+	}
+	return pkgHTTP.URL(url), nil
+}
+
+func provideHeaders(v *viper.Viper) (pkgHTTP.Headers, error) {
+	headers := pkgHTTP.Headers(v.GetStringMapString(source.MakeFlagName(flagHeader)))
+	if headers == nil {
+		headers = make(pkgHTTP.Headers)
+	}
+
+	token := v.GetString(source.MakeFlagName(flagToken))
+	tokenFile := v.GetString(source.MakeFlagName(flagTokenFile))
+	if token != `` && tokenFile != `` {
+		return nil, errors.Errorf(
+			`flags "%s" and "%s" cannot be used together`,
+			source.MakeFlagName(flagToken),
+			source.MakeFlagName(flagTokenFile),
+		)
+	}
+	if tokenFile != `` {
+		contents, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to read token file "%s"`, tokenFile)
+		}
+		token = strings.TrimSpace(string(contents))
+	}
+	if token != `` {
+		headers[headerAuthorization] = `Bearer ` + token
+	}
+	return headers, nil
+}
+
+func provideTLSConfig(v *viper.Viper) (*tls.Config, error) {
+	certFile := v.GetString(source.MakeFlagName(flagTLSCertFile))
+	keyFile := v.GetString(source.MakeFlagName(flagTLSKeyFile))
+	if (certFile == ``) != (keyFile == ``) {
+		return nil, errors.Errorf(
+			`flags "%s" and "%s" must be used together`,
+			source.MakeFlagName(flagTLSCertFile),
+			source.MakeFlagName(flagTLSKeyFile),
+		)
+	}
+
+	cfg := &tls.Config{
+		ServerName:         v.GetString(source.MakeFlagName(flagTLSServerName)),
+		InsecureSkipVerify: v.GetBool(source.MakeFlagName(flagTLSInsecureSkipVerify)),
+	}
+	if caFile := v.GetString(source.MakeFlagName(flagTLSCAFile)); caFile != `` {
+		contents, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to read CA certificate file "%s"`, caFile)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, errors.Errorf(`failed to parse CA certificate file "%s"`, caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != `` {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to load client certificate`)
+		}
+		cfg.Certificates = []tls.Certificate{certificate}
+	}
+	return cfg, nil
+}
+
+func provideClient(v *viper.Viper, cfg *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	return &http.Client{
+		Transport: transport,
+		Timeout:   v.GetDuration(source.MakeFlagName(flagTimeout)),
+	}
+}
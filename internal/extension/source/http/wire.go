@@ -0,0 +1,25 @@
+// +build wireinject
+
+package http
+
+import (
+	"net/http"
+
+	pkgHTTP "github.com/insidieux/pinchy/pkg/core/source/http"
+
+	"github.com/google/wire"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/spf13/viper"
+)
+
+func NewSource(*viper.Viper) (core.Source, func(), error) {
+	panic(wire.Build(
+		provideTLSConfig,
+		provideClient,
+		wire.Bind(new(pkgHTTP.Client), new(*http.Client)),
+		provideURL,
+		provideHeaders,
+		pkgHTTP.NewSource,
+		wire.Bind(new(core.Source), new(*pkgHTTP.Source)),
+	))
+}
@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+)
+
+const (
+	// PathHealth is path of liveness probe, which always responds with 200 status code
+	PathHealth = `/healthz`
+	// PathReady is path of readiness probe, which responds with 503 status code when last runs failed
+	PathReady = `/readyz`
+	// PathStatus is path of last run status with report in JSON format
+	PathStatus = `/status`
+	// PathSync is path for trigger immediate run with POST request, which responds with 503 status code when
+	// Scheduler does not hold leadership
+	PathSync = `/sync`
+)
+
+type (
+	// Scheduler interface provides status of last run and possibility to trigger next one, e.g. core.Scheduler
+	Scheduler interface {
+		Status() core.SchedulerStatus
+		Trigger() bool
+	}
+
+	// Handler is http.Handler implementation for admin API
+	Handler struct {
+		mux       *http.ServeMux
+		scheduler Scheduler
+		threshold int
+	}
+
+	statusResponse struct {
+		Ready               bool
+		Leader              bool
+		ConsecutiveFailures int
+		LastRunAt           *time.Time   `json:",omitempty"`
+		LastError           string       `json:",omitempty"`
+		Report              *core.Report `json:",omitempty"`
+	}
+
+	messageResponse struct {
+		Message string
+	}
+)
+
+// NewHandler provides Handler for Scheduler.
+// Readiness probe fails, when count of consecutive failed runs reaches threshold. Values less than 1 mean single run
+func NewHandler(scheduler Scheduler, threshold int) *Handler {
+	if threshold < 1 {
+		threshold = 1
+	}
+	h := &Handler{
+		mux:       http.NewServeMux(),
+		scheduler: scheduler,
+		threshold: threshold,
+	}
+	h.mux.HandleFunc(PathHealth, h.health)
+	h.mux.HandleFunc(PathReady, h.ready)
+	h.mux.HandleFunc(PathStatus, h.status)
+	h.mux.HandleFunc(PathSync, h.sync)
+	return h
+}
+
+// ServeHTTP is implementation of http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, messageResponse{Message: `ok`})
+}
+
+func (h *Handler) ready(w http.ResponseWriter, _ *http.Request) {
+	status := h.scheduler.Status()
+	if !h.isReady(status) {
+		writeJSON(w, http.StatusServiceUnavailable, messageResponse{Message: `last runs failed`})
+		return
+	}
+	writeJSON(w, http.StatusOK, messageResponse{Message: `ok`})
+}
+
+func (h *Handler) status(w http.ResponseWriter, _ *http.Request) {
+	status := h.scheduler.Status()
+	response := statusResponse{
+		Ready:               h.isReady(status),
+		Leader:              status.Leader,
+		ConsecutiveFailures: status.ConsecutiveFailures,
+		Report:              status.LastReport,
+	}
+	if !status.LastRunAt.IsZero() {
+		response.LastRunAt = &status.LastRunAt
+	}
+	if status.LastError != nil {
+		response.LastError = status.LastError.Error()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set(`Allow`, http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, messageResponse{Message: `method not allowed`})
+		return
+	}
+	if !h.scheduler.Status().Leader {
+		writeJSON(w, http.StatusServiceUnavailable, messageResponse{Message: `not leader`})
+		return
+	}
+	if !h.scheduler.Trigger() {
+		writeJSON(w, http.StatusConflict, messageResponse{Message: `sync has been already triggered`})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, messageResponse{Message: `sync triggered`})
+}
+
+func (h *Handler) isReady(status core.SchedulerStatus) bool {
+	return status.ConsecutiveFailures < h.threshold
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,187 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewHandler(t *testing.T) {
+	suite.Run(t, new(newHandlerTestSuite))
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	suite.Run(t, new(handlerServeHTTPTestSuite))
+}
+
+// --- Suites ---
+
+type newHandlerTestSuite struct {
+	suite.Suite
+}
+
+func (s *newHandlerTestSuite) TestNewHandler() {
+	h := NewHandler(nil, 3)
+	s.Implements((*http.Handler)(nil), h)
+	s.Equal(3, h.threshold)
+}
+
+func (s *newHandlerTestSuite) TestDefaultThreshold() {
+	s.Equal(1, NewHandler(nil, 0).threshold)
+}
+
+type handlerServeHTTPTestSuite struct {
+	suite.Suite
+	scheduler *MockScheduler
+	handler   *Handler
+}
+
+func (s *handlerServeHTTPTestSuite) SetupTest() {
+	s.scheduler = new(MockScheduler)
+	s.handler = NewHandler(s.scheduler, 2)
+}
+
+func (s *handlerServeHTTPTestSuite) serve(method string, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func (s *handlerServeHTTPTestSuite) TestHealth() {
+	recorder := s.serve(http.MethodGet, PathHealth)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.JSONEq(`{"Message":"ok"}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestReady() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{ConsecutiveFailures: 1})
+	recorder := s.serve(http.MethodGet, PathReady)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.JSONEq(`{"Message":"ok"}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestNotReady() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{ConsecutiveFailures: 2})
+	recorder := s.serve(http.MethodGet, PathReady)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.JSONEq(`{"Message":"last runs failed"}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestStatusEmpty() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{})
+	recorder := s.serve(http.MethodGet, PathStatus)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal(`application/json`, recorder.Header().Get(`Content-Type`))
+	s.JSONEq(`{"Ready":true,"Leader":false,"ConsecutiveFailures":0}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestStatus() {
+	startedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := &core.Report{
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(time.Second),
+		Items: []*core.ReportItem{
+			{ID: `service`, Action: core.ActionFailed, Error: errors.New(`expected error`), Duration: time.Millisecond},
+		},
+	}
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{
+		Leader:              true,
+		LastRunAt:           startedAt,
+		LastReport:          report,
+		LastError:           errors.New(`run error`),
+		ConsecutiveFailures: 3,
+	})
+	recorder := s.serve(http.MethodGet, PathStatus)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.JSONEq(`{
+		"Ready": false,
+		"Leader": true,
+		"ConsecutiveFailures": 3,
+		"LastRunAt": "2021-01-01T00:00:00Z",
+		"LastError": "run error",
+		"Report": {
+			"StartedAt": "2021-01-01T00:00:00Z",
+			"FinishedAt": "2021-01-01T00:00:01Z",
+			"Items": [{"ID": "service", "Action": "failed", "Error": "expected error", "Duration": "1ms"}]
+		}
+	}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestSyncMethodNotAllowed() {
+	recorder := s.serve(http.MethodGet, PathSync)
+	s.Equal(http.StatusMethodNotAllowed, recorder.Code)
+	s.Equal(http.MethodPost, recorder.Header().Get(`Allow`))
+	s.scheduler.AssertNotCalled(s.T(), `Trigger`)
+}
+
+func (s *handlerServeHTTPTestSuite) TestSyncTriggered() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{Leader: true})
+	s.scheduler.On(`Trigger`).Return(true)
+	recorder := s.serve(http.MethodPost, PathSync)
+	s.Equal(http.StatusAccepted, recorder.Code)
+	s.JSONEq(`{"Message":"sync triggered"}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestSyncPending() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{Leader: true})
+	s.scheduler.On(`Trigger`).Return(false)
+	recorder := s.serve(http.MethodPost, PathSync)
+	s.Equal(http.StatusConflict, recorder.Code)
+	s.JSONEq(`{"Message":"sync has been already triggered"}`, recorder.Body.String())
+}
+
+func (s *handlerServeHTTPTestSuite) TestSyncNotLeader() {
+	s.scheduler.On(`Status`).Return(core.SchedulerStatus{Leader: false})
+	recorder := s.serve(http.MethodPost, PathSync)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.JSONEq(`{"Message":"not leader"}`, recorder.Body.String())
+	s.scheduler.AssertNotCalled(s.T(), `Trigger`)
+}
+
+func (s *handlerServeHTTPTestSuite) TestNotFound() {
+	recorder := s.serve(http.MethodGet, `/unknown`)
+	s.Equal(http.StatusNotFound, recorder.Code)
+}
+
+// --- Mocks ---
+
+// MockScheduler is an autogenerated mock type for the Scheduler type
+type MockScheduler struct {
+	mock.Mock
+}
+
+// Status provides a mock function with given fields:
+func (_m *MockScheduler) Status() core.SchedulerStatus {
+	ret := _m.Called()
+
+	var r0 core.SchedulerStatus
+	if rf, ok := ret.Get(0).(func() core.SchedulerStatus); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(core.SchedulerStatus)
+	}
+
+	return r0
+}
+
+// Trigger provides a mock function with given fields:
+func (_m *MockScheduler) Trigger() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CheckTypeHTTP is type of Check with Check.HTTP target
+	CheckTypeHTTP = `http`
+	// CheckTypeTCP is type of Check with Check.TCP target
+	CheckTypeTCP = `tcp`
+	// CheckTypeGRPC is type of Check with Check.GRPC target
+	CheckTypeGRPC = `grpc`
+	// CheckTypeTTL is type of Check with Check.TTL, Check status must be updated by service itself
+	CheckTypeTTL = `ttl`
+)
+
+type (
+	// Check contains health check definition of Service. Check must contain exactly one of HTTP, TCP, GRPC or TTL.
+	// HTTP, TCP and GRPC checks require Interval. Durations are strings in time.ParseDuration format.
+	Check struct {
+		ID                             *string `json:",omitempty"`
+		Name                           string  `json:","`
+		HTTP                           *string `json:",omitempty"`
+		Method                         *string `json:",omitempty"`
+		TCP                            *string `json:",omitempty"`
+		GRPC                           *string `json:",omitempty"`
+		GRPCUseTLS                     bool    `json:",omitempty"`
+		TLSSkipVerify                  bool    `json:",omitempty"`
+		TTL                            *string `json:",omitempty"`
+		Interval                       *string `json:",omitempty"`
+		Timeout                        *string `json:",omitempty"`
+		DeregisterCriticalServiceAfter *string `json:",omitempty"`
+	}
+
+	// Checks is simple helper for hold slice of Check's
+	Checks []*Check
+)
+
+// Type return Check type by defined target: CheckTypeHTTP, CheckTypeTCP, CheckTypeGRPC, CheckTypeTTL or empty string
+func (c *Check) Type() string {
+	switch {
+	case c.HTTP != nil:
+		return CheckTypeHTTP
+	case c.TCP != nil:
+		return CheckTypeTCP
+	case c.GRPC != nil:
+		return CheckTypeGRPC
+	case c.TTL != nil:
+		return CheckTypeTTL
+	}
+	return ``
+}
+
+// Validate checks required fields of Check
+func (c *Check) Validate() error {
+	if c.Name == `` {
+		return errors.New(`check field "name" is required and cannot be empty`)
+	}
+	targets := 0
+	for _, target := range []*string{c.HTTP, c.TCP, c.GRPC, c.TTL} {
+		if target != nil {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return errors.Errorf(`check "%s" must contain exactly one of fields "http", "tcp", "grpc" or "ttl"`, c.Name)
+	}
+	if c.Type() != CheckTypeTTL && c.Interval == nil {
+		return errors.Errorf(`check "%s" field "interval" is required for %s check`, c.Name, c.Type())
+	}
+	durations := map[string]*string{
+		`ttl`:                            c.TTL,
+		`interval`:                       c.Interval,
+		`timeout`:                        c.Timeout,
+		`deregistercriticalserviceafter`: c.DeregisterCriticalServiceAfter,
+	}
+	for name, value := range durations {
+		if value == nil {
+			continue
+		}
+		if _, err := time.ParseDuration(*value); err != nil {
+			return errors.Wrapf(err, `check "%s" field "%s" is invalid`, c.Name, name)
+		}
+	}
+	return nil
+}
+
+// Equal compares Check with other Check in canonical form:
+// - Check.ID is compared only when both checks define it, because Registry generates ID for checks without ID
+// - Manager sets default ID of CheckIDGenerator to incoming checks without ID, so check ID is compared in such Registry
+// - durations are compared by value, so "1m" is equal to "1m0s"
+func (c *Check) Equal(other *Check) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	if c.ID != nil && other.ID != nil && *c.ID != *other.ID {
+		return false
+	}
+	left, right := *c, *other
+	left.ID, right.ID = nil, nil
+	for _, pair := range [][2]**string{
+		{&left.TTL, &right.TTL},
+		{&left.Interval, &right.Interval},
+		{&left.Timeout, &right.Timeout},
+		{&left.DeregisterCriticalServiceAfter, &right.DeregisterCriticalServiceAfter},
+	} {
+		if !equalDurations(*pair[0], *pair[1]) {
+			return false
+		}
+		*pair[0], *pair[1] = nil, nil
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// Clone return deep copy of Check
+func (c *Check) Clone() *Check {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	for _, field := range []**string{
+		&clone.ID,
+		&clone.HTTP,
+		&clone.Method,
+		&clone.TCP,
+		&clone.GRPC,
+		&clone.TTL,
+		&clone.Interval,
+		&clone.Timeout,
+		&clone.DeregisterCriticalServiceAfter,
+	} {
+		if *field != nil {
+			value := **field
+			*field = &value
+		}
+	}
+	return &clone
+}
+
+// Equal compares Checks as unordered lists, nil Checks is equal to empty list
+func (c Checks) Equal(other Checks) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	left, right := c.sorted(), other.sorted()
+	for index := range left {
+		if !left[index].Equal(right[index]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone return deep copy of Checks
+func (c Checks) Clone() Checks {
+	if c == nil {
+		return nil
+	}
+	clone := make(Checks, 0, len(c))
+	for _, check := range c {
+		clone = append(clone, check.Clone())
+	}
+	return clone
+}
+
+func (c Checks) sorted() Checks {
+	sorted := append(Checks{}, c...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func equalDurations(left *string, right *string) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	l, lErr := time.ParseDuration(*left)
+	r, rErr := time.ParseDuration(*right)
+	if lErr != nil || rErr != nil {
+		return *left == *right
+	}
+	return l == r
+}
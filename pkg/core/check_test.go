@@ -0,0 +1,137 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestCheck_Type(t *testing.T) {
+	suite.Run(t, new(checkTypeTestSuite))
+}
+
+func TestCheck_Validate(t *testing.T) {
+	suite.Run(t, new(checkValidateTestSuite))
+}
+
+func TestCheck_Equal(t *testing.T) {
+	suite.Run(t, new(checkEqualTestSuite))
+}
+
+func TestCheck_Clone(t *testing.T) {
+	suite.Run(t, new(checkCloneTestSuite))
+}
+
+func TestChecks_Equal(t *testing.T) {
+	suite.Run(t, new(checksEqualTestSuite))
+}
+
+// --- Suites ---
+
+type checkTypeTestSuite struct {
+	suite.Suite
+}
+
+func (s *checkTypeTestSuite) TestType() {
+	s.Equal(CheckTypeHTTP, (&Check{HTTP: ptr.String(`http://127.0.0.1/health`)}).Type())
+	s.Equal(CheckTypeTCP, (&Check{TCP: ptr.String(`127.0.0.1:80`)}).Type())
+	s.Equal(CheckTypeGRPC, (&Check{GRPC: ptr.String(`127.0.0.1:9090`)}).Type())
+	s.Equal(CheckTypeTTL, (&Check{TTL: ptr.String(`30s`)}).Type())
+	s.Equal(``, (&Check{}).Type())
+}
+
+type checkValidateTestSuite struct {
+	suite.Suite
+}
+
+func (s *checkValidateTestSuite) TestEmptyName() {
+	s.EqualError((&Check{TTL: ptr.String(`30s`)}).Validate(), `check field "name" is required and cannot be empty`)
+}
+
+func (s *checkValidateTestSuite) TestTargets() {
+	message := `check "check" must contain exactly one of fields "http", "tcp", "grpc" or "ttl"`
+	s.EqualError((&Check{Name: `check`}).Validate(), message)
+	s.EqualError((&Check{Name: `check`, TTL: ptr.String(`30s`), TCP: ptr.String(`127.0.0.1:80`)}).Validate(), message)
+}
+
+func (s *checkValidateTestSuite) TestEmptyInterval() {
+	s.EqualError(
+		(&Check{Name: `check`, TCP: ptr.String(`127.0.0.1:80`)}).Validate(),
+		`check "check" field "interval" is required for tcp check`,
+	)
+}
+
+func (s *checkValidateTestSuite) TestInvalidDuration() {
+	s.EqualError(
+		(&Check{Name: `check`, TCP: ptr.String(`127.0.0.1:80`), Interval: ptr.String(`10`)}).Validate(),
+		`check "check" field "interval" is invalid: time: missing unit in duration "10"`,
+	)
+}
+
+func (s *checkValidateTestSuite) TestSuccess() {
+	s.NoError((&Check{Name: `check`, TTL: ptr.String(`30s`)}).Validate())
+	s.NoError((&Check{
+		Name:     `check`,
+		HTTP:     ptr.String(`http://127.0.0.1/health`),
+		Interval: ptr.String(`10s`),
+		Timeout:  ptr.String(`1s`),
+	}).Validate())
+}
+
+type checkEqualTestSuite struct {
+	suite.Suite
+}
+
+func (s *checkEqualTestSuite) TestNil() {
+	var check *Check
+	s.True(check.Equal(nil))
+	s.False(check.Equal(&Check{}))
+}
+
+func (s *checkEqualTestSuite) TestID() {
+	s.True((&Check{Name: `check`, ID: ptr.String(`id`)}).Equal(&Check{Name: `check`}))
+	s.False((&Check{Name: `check`, ID: ptr.String(`id`)}).Equal(&Check{Name: `check`, ID: ptr.String(`other`)}))
+}
+
+func (s *checkEqualTestSuite) TestDurations() {
+	s.True((&Check{Name: `check`, Interval: ptr.String(`1m`)}).Equal(&Check{Name: `check`, Interval: ptr.String(`1m0s`)}))
+	s.False((&Check{Name: `check`, Interval: ptr.String(`1m`)}).Equal(&Check{Name: `check`, Interval: ptr.String(`10s`)}))
+	s.False((&Check{Name: `check`, Interval: ptr.String(`1m`)}).Equal(&Check{Name: `check`}))
+}
+
+func (s *checkEqualTestSuite) TestFields() {
+	s.False((&Check{Name: `check`, HTTP: ptr.String(`a`)}).Equal(&Check{Name: `check`, HTTP: ptr.String(`b`)}))
+	s.False((&Check{Name: `check`, GRPCUseTLS: true}).Equal(&Check{Name: `check`}))
+}
+
+type checkCloneTestSuite struct {
+	suite.Suite
+}
+
+func (s *checkCloneTestSuite) TestClone() {
+	var empty *Check
+	s.Nil(empty.Clone())
+
+	check := &Check{Name: `check`, HTTP: ptr.String(`http://127.0.0.1/health`), Interval: ptr.String(`10s`)}
+	clone := check.Clone()
+	s.Equal(check, clone)
+	*clone.HTTP = `other`
+	*clone.Interval = `1s`
+	s.Equal(`http://127.0.0.1/health`, *check.HTTP)
+	s.Equal(`10s`, *check.Interval)
+}
+
+type checksEqualTestSuite struct {
+	suite.Suite
+}
+
+func (s *checksEqualTestSuite) TestEqual() {
+	var empty Checks
+	s.True(empty.Equal(Checks{}))
+	s.True(Checks{{Name: `a`}, {Name: `b`}}.Equal(Checks{{Name: `b`}, {Name: `a`}}))
+	s.False(Checks{{Name: `a`}}.Equal(Checks{{Name: `b`}}))
+	s.False(Checks{{Name: `a`}}.Equal(Checks{{Name: `a`}, {Name: `b`}}))
+}
@@ -0,0 +1,37 @@
+package consul
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/api"
+)
+
+type (
+	// Scope is a common datacenter, namespace and admin partition for query and write requests to Consul HTTP API
+	// Empty values mean defaults of Consul agent, which handle requests
+	Scope struct {
+		Datacenter string
+		Namespace  string
+		Partition  string
+	}
+)
+
+// QueryOptions return new api.QueryOptions bound to Scope and context
+func (s Scope) QueryOptions(ctx context.Context) *api.QueryOptions {
+	opts := &api.QueryOptions{
+		Datacenter: s.Datacenter,
+		Namespace:  s.Namespace,
+		Partition:  s.Partition,
+	}
+	return opts.WithContext(ctx)
+}
+
+// WriteOptions return new api.WriteOptions bound to Scope and context
+func (s Scope) WriteOptions(ctx context.Context) *api.WriteOptions {
+	opts := &api.WriteOptions{
+		Datacenter: s.Datacenter,
+		Namespace:  s.Namespace,
+		Partition:  s.Partition,
+	}
+	return opts.WithContext(ctx)
+}
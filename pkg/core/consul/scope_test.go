@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestScope_QueryOptions(t *testing.T) {
+	suite.Run(t, new(scopeQueryOptionsTestSuite))
+}
+
+func TestScope_WriteOptions(t *testing.T) {
+	suite.Run(t, new(scopeWriteOptionsTestSuite))
+}
+
+// --- Suites ---
+
+type scopeQueryOptionsTestSuite struct {
+	suite.Suite
+}
+
+func (s *scopeQueryOptionsTestSuite) TestEmpty() {
+	ctx := context.Background()
+	opts := Scope{}.QueryOptions(ctx)
+	s.Empty(opts.Datacenter)
+	s.Empty(opts.Namespace)
+	s.Empty(opts.Partition)
+	s.Equal(ctx, opts.Context())
+}
+
+func (s *scopeQueryOptionsTestSuite) TestScope() {
+	opts := Scope{Datacenter: `dc-1`, Namespace: `team`, Partition: `part`}.QueryOptions(context.Background())
+	s.Equal(`dc-1`, opts.Datacenter)
+	s.Equal(`team`, opts.Namespace)
+	s.Equal(`part`, opts.Partition)
+}
+
+type scopeWriteOptionsTestSuite struct {
+	suite.Suite
+}
+
+func (s *scopeWriteOptionsTestSuite) TestEmpty() {
+	ctx := context.Background()
+	opts := Scope{}.WriteOptions(ctx)
+	s.Empty(opts.Datacenter)
+	s.Empty(opts.Namespace)
+	s.Empty(opts.Partition)
+	s.Equal(ctx, opts.Context())
+}
+
+func (s *scopeWriteOptionsTestSuite) TestScope() {
+	opts := Scope{Datacenter: `dc-1`, Namespace: `team`, Partition: `part`}.WriteOptions(context.Background())
+	s.Equal(`dc-1`, opts.Datacenter)
+	s.Equal(`team`, opts.Namespace)
+	s.Equal(`part`, opts.Partition)
+}
@@ -0,0 +1,87 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Locker interface provide common function for work with Consul distributed lock. Implemented by api.Lock
+	Locker interface {
+		Lock(stopCh <-chan struct{}) (<-chan struct{}, error)
+		Unlock() error
+	}
+
+	// RetryDelay is a delay before next Locker.Lock attempt after failure
+	RetryDelay time.Duration
+
+	// Elector is implementation of core.LeaderElector interface, based on Consul session and KV lock
+	Elector struct {
+		locker Locker
+		delay  RetryDelay
+		logger core.LoggerInterface
+	}
+)
+
+// NewElector provide Elector as core.LeaderElector implementation
+func NewElector(locker Locker, delay RetryDelay) *Elector {
+	return &Elector{
+		locker: locker,
+		delay:  delay,
+	}
+}
+
+// Acquire blocks until Locker.Lock succeeds or context.Context canceled. Lock failures are logged and retried after RetryDelay.
+// Acquire never returns nil channel without error
+func (e *Elector) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stop)
+		case <-done:
+		}
+	}()
+
+	for {
+		lost, err := e.locker.Lock(stop)
+		if err == nil && lost != nil {
+			return lost, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// lock can be not acquired without error, e.g. when lock wait time is exceeded, then it is retried too
+		if err == nil {
+			err = errors.New(`lock was not acquired`)
+		}
+		e.logger.Warningln(errors.Wrap(err, `failed to acquire consul lock`).Error())
+
+		timer := time.NewTimer(time.Duration(e.delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Release unlocks Locker. Lock, which is not held anymore, is not an error
+func (e *Elector) Release() error {
+	if err := e.locker.Unlock(); err != nil && err != api.ErrLockNotHeld {
+		return errors.Wrap(err, `failed to release consul lock`)
+	}
+	return nil
+}
+
+// WithLogger is implementation of core.Loggable interface
+func (e *Elector) WithLogger(logger core.LoggerInterface) {
+	e.logger = logger
+}
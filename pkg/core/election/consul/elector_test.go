@@ -0,0 +1,208 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewElector(t *testing.T) {
+	suite.Run(t, new(newElectorTestSuite))
+}
+
+func TestElector_Acquire(t *testing.T) {
+	suite.Run(t, new(electorAcquireTestSuite))
+}
+
+func TestElector_Release(t *testing.T) {
+	suite.Run(t, new(electorReleaseTestSuite))
+}
+
+func TestElector_WithLogger(t *testing.T) {
+	suite.Run(t, new(electorWithLoggerTestSuite))
+}
+
+// --- Suites ---
+
+type newElectorTestSuite struct {
+	suite.Suite
+}
+
+func (s *newElectorTestSuite) TestNewElector() {
+	locker := new(MockLocker)
+	elector := NewElector(locker, RetryDelay(time.Second))
+	s.Implements((*core.LeaderElector)(nil), elector)
+	s.Implements((*core.Loggable)(nil), elector)
+	s.Equal(locker, elector.locker)
+	s.Equal(RetryDelay(time.Second), elector.delay)
+}
+
+type electorAcquireTestSuite struct {
+	suite.Suite
+	locker  *MockLocker
+	elector *Elector
+}
+
+func (s *electorAcquireTestSuite) SetupTest() {
+	s.locker = new(MockLocker)
+	s.elector = NewElector(s.locker, RetryDelay(time.Millisecond))
+	s.elector.logger, _ = test.NewNullLogger()
+}
+
+func (s *electorAcquireTestSuite) TearDownTest() {
+	s.locker.AssertExpectations(s.T())
+}
+
+func (s *electorAcquireTestSuite) TestSuccess() {
+	lost := make(chan struct{})
+	s.locker.On(`Lock`, mock.Anything).Return((<-chan struct{})(lost), nil).Once()
+
+	result, err := s.elector.Acquire(context.Background())
+	s.NoError(err)
+	s.Equal((<-chan struct{})(lost), result)
+}
+
+func (s *electorAcquireTestSuite) TestRetryAfterError() {
+	lost := make(chan struct{})
+	s.locker.On(`Lock`, mock.Anything).Return(nil, errors.New(`expected error`)).Once()
+	s.locker.On(`Lock`, mock.Anything).Return((<-chan struct{})(lost), nil).Once()
+
+	logger, hook := test.NewNullLogger()
+	s.elector.logger = logger
+	result, err := s.elector.Acquire(context.Background())
+	s.NoError(err)
+	s.Equal((<-chan struct{})(lost), result)
+	s.Len(hook.Entries, 1)
+	s.Equal(`failed to acquire consul lock: expected error`, hook.LastEntry().Message)
+}
+
+func (s *electorAcquireTestSuite) TestRetryNotAcquired() {
+	lost := make(chan struct{})
+	s.locker.On(`Lock`, mock.Anything).Return(nil, nil).Once()
+	s.locker.On(`Lock`, mock.Anything).Return((<-chan struct{})(lost), nil).Once()
+
+	logger, hook := test.NewNullLogger()
+	s.elector.logger = logger
+	result, err := s.elector.Acquire(context.Background())
+	s.NoError(err)
+	s.Equal((<-chan struct{})(lost), result)
+	s.Len(hook.Entries, 1)
+	s.Equal(`failed to acquire consul lock: lock was not acquired`, hook.LastEntry().Message)
+}
+
+func (s *electorAcquireTestSuite) TestCanceledWhileLocking() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.locker.On(`Lock`, mock.Anything).Return(nil, nil).Run(func(args mock.Arguments) {
+		cancel()
+		<-args.Get(0).(<-chan struct{})
+	}).Once()
+
+	result, err := s.elector.Acquire(ctx)
+	s.Nil(result)
+	s.Equal(context.Canceled, err)
+}
+
+func (s *electorAcquireTestSuite) TestCanceledWhileWaitingRetry() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.elector.delay = RetryDelay(time.Hour)
+	s.locker.On(`Lock`, mock.Anything).Return(nil, errors.New(`expected error`)).Run(func(args mock.Arguments) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+	}).Once()
+
+	result, err := s.elector.Acquire(ctx)
+	s.Nil(result)
+	s.Equal(context.Canceled, err)
+}
+
+type electorReleaseTestSuite struct {
+	suite.Suite
+}
+
+func (s *electorReleaseTestSuite) TestSuccess() {
+	locker := new(MockLocker)
+	locker.On(`Unlock`).Return(nil).Once()
+	s.NoError(NewElector(locker, 0).Release())
+	locker.AssertExpectations(s.T())
+}
+
+func (s *electorReleaseTestSuite) TestLockNotHeld() {
+	locker := new(MockLocker)
+	locker.On(`Unlock`).Return(api.ErrLockNotHeld).Once()
+	s.NoError(NewElector(locker, 0).Release())
+	locker.AssertExpectations(s.T())
+}
+
+func (s *electorReleaseTestSuite) TestError() {
+	locker := new(MockLocker)
+	locker.On(`Unlock`).Return(errors.New(`expected error`)).Once()
+	err := NewElector(locker, 0).Release()
+	s.EqualError(err, `failed to release consul lock: expected error`)
+	locker.AssertExpectations(s.T())
+}
+
+type electorWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *electorWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	elector := NewElector(new(MockLocker), 0)
+	elector.WithLogger(logger)
+	s.Equal(logger, elector.logger)
+}
+
+// --- Mocks ---
+
+// MockLocker is an autogenerated mock type for the Locker type
+type MockLocker struct {
+	mock.Mock
+}
+
+// Lock provides a mock function with given fields: stopCh
+func (_m *MockLocker) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	ret := _m.Called(stopCh)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(<-chan struct{}) <-chan struct{}); ok {
+		r0 = rf(stopCh)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(<-chan struct{}) error); ok {
+		r1 = rf(stopCh)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unlock provides a mock function with given fields:
+func (_m *MockLocker) Unlock() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
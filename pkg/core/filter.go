@@ -0,0 +1,29 @@
+package core
+
+type (
+	// Filter decides, which Services are owned by Manager.
+	// Filter is applied to Services fetched from Source and to Services fetched from Registry,
+	// so Services, which are not matched by Filter, are never registered and never deregistered.
+	Filter interface {
+		Match(service *Service) bool
+	}
+
+	// FilterFunc is an adapter to allow the use of ordinary functions as Filter
+	FilterFunc func(service *Service) bool
+)
+
+// Match is implementation of Filter interface
+func (f FilterFunc) Match(service *Service) bool {
+	return f(service)
+}
+
+// Filter return Services matched by Filter
+func (s Services) Filter(filter Filter) Services {
+	result := make(Services, 0, len(s))
+	for _, service := range s {
+		if filter.Match(service) {
+			result = append(result, service)
+		}
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package filter
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FieldName matches core.Service Name
+	FieldName = `name`
+	// FieldID matches core.Service RegistrationID
+	FieldID = `id`
+	// FieldTag matches any of core.Service Tags
+	FieldTag = `tag`
+	// FieldMetaPrefix matches core.Service Meta value by key, e.g. "meta.team"
+	FieldMetaPrefix = `meta.`
+)
+
+type (
+	// Expression is core.Filter implementation for single expression in format "field=glob" or "field~regexp".
+	// Available fields are "name", "id", "tag" and "meta.<key>". Operator can be negated with "!", e.g. "meta.team!=payments".
+	// Glob syntax is described in path.Match. Expression with "tag" field matches, if any tag matches.
+	// Expression with "meta.<key>" field does not match services without such meta key.
+	Expression struct {
+		source  string
+		field   string
+		key     string
+		negated bool
+		match   func(value string) bool
+	}
+)
+
+// ParseExpression parse Expression from string
+func ParseExpression(value string) (*Expression, error) {
+	index := strings.IndexAny(value, `=~`)
+	if index <= 0 {
+		return nil, errors.Errorf(`expression "%s" must be in format "field=glob" or "field~regexp"`, value)
+	}
+	e := &Expression{
+		source: value,
+		field:  strings.TrimSpace(value[:index]),
+	}
+	if strings.HasSuffix(e.field, `!`) {
+		e.negated = true
+		e.field = strings.TrimSpace(strings.TrimSuffix(e.field, `!`))
+	}
+	switch {
+	case e.field == FieldName, e.field == FieldID, e.field == FieldTag:
+	case strings.HasPrefix(e.field, FieldMetaPrefix) && len(e.field) > len(FieldMetaPrefix):
+		e.key = strings.TrimPrefix(e.field, FieldMetaPrefix)
+		e.field = FieldMetaPrefix
+	default:
+		return nil, errors.Errorf(`expression "%s" has unknown field "%s", available fields: %s, %s, %s, %s<key>`, value, e.field, FieldName, FieldID, FieldTag, FieldMetaPrefix)
+	}
+
+	pattern := value[index+1:]
+	if value[index] == '~' {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, `expression "%s" has invalid regexp`, value)
+		}
+		e.match = re.MatchString
+		return e, nil
+	}
+	if _, err := path.Match(pattern, ``); err != nil {
+		return nil, errors.Wrapf(err, `expression "%s" has invalid glob`, value)
+	}
+	e.match = func(value string) bool {
+		matched, _ := path.Match(pattern, value)
+		return matched
+	}
+	return e, nil
+}
+
+// Match is implementation of core.Filter interface
+func (e *Expression) Match(service *core.Service) bool {
+	return e.matchValues(service) != e.negated
+}
+
+// String return source of Expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+func (e *Expression) matchValues(service *core.Service) bool {
+	switch e.field {
+	case FieldName:
+		return e.match(service.Name)
+	case FieldID:
+		return e.match(service.RegistrationID())
+	case FieldTag:
+		if service.Tags == nil {
+			return false
+		}
+		for _, tag := range *service.Tags {
+			if e.match(tag) {
+				return true
+			}
+		}
+		return false
+	}
+	if service.Meta == nil {
+		return false
+	}
+	value, ok := (*service.Meta)[e.key]
+	return ok && e.match(value)
+}
@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestParseExpression(t *testing.T) {
+	suite.Run(t, new(parseExpressionTestSuite))
+}
+
+func TestExpression_Match(t *testing.T) {
+	suite.Run(t, new(expressionMatchTestSuite))
+}
+
+func TestExpression_String(t *testing.T) {
+	suite.Run(t, new(expressionStringTestSuite))
+}
+
+// --- Suites ---
+
+type parseExpressionTestSuite struct {
+	suite.Suite
+}
+
+func (s *parseExpressionTestSuite) TestInvalidFormat() {
+	for _, value := range []string{``, `name`, `=value`} {
+		got, err := ParseExpression(value)
+		s.Nil(got)
+		s.EqualError(err, `expression "`+value+`" must be in format "field=glob" or "field~regexp"`)
+	}
+}
+
+func (s *parseExpressionTestSuite) TestUnknownField() {
+	for _, value := range []string{`address=value`, `meta.=value`} {
+		got, err := ParseExpression(value)
+		s.Nil(got)
+		s.Error(err)
+		s.Contains(err.Error(), `has unknown field`)
+	}
+}
+
+func (s *parseExpressionTestSuite) TestInvalidGlob() {
+	got, err := ParseExpression(`name=[`)
+	s.Nil(got)
+	s.EqualError(err, `expression "name=[" has invalid glob: syntax error in pattern`)
+}
+
+func (s *parseExpressionTestSuite) TestInvalidRegexp() {
+	got, err := ParseExpression(`name~(`)
+	s.Nil(got)
+	s.Error(err)
+	s.Contains(err.Error(), `expression "name~(" has invalid regexp`)
+}
+
+func (s *parseExpressionTestSuite) TestSuccess() {
+	got, err := ParseExpression(`meta.team != payments`)
+	s.NoError(err)
+	s.Implements((*core.Filter)(nil), got)
+	s.Equal(FieldMetaPrefix, got.field)
+	s.Equal(`team`, got.key)
+	s.True(got.negated)
+}
+
+type expressionMatchTestSuite struct {
+	suite.Suite
+	service *core.Service
+}
+
+func (s *expressionMatchTestSuite) SetupTest() {
+	s.service = &core.Service{
+		Name: `payments-api`,
+		ID:   ptr.String(`payments-api-1`),
+		Tags: &[]string{`env:prod`, `http`},
+		Meta: &map[string]string{`team`: `payments`},
+	}
+}
+
+func (s *expressionMatchTestSuite) TestMatch() {
+	cases := map[string]bool{
+		`name=payments-*`:      true,
+		`name=orders-*`:        false,
+		`name!=orders-*`:       true,
+		`id=*-1`:               true,
+		`id~^payments-api$`:    false,
+		`name~^payments-api$`:  true,
+		`tag=env:*`:            true,
+		`tag=grpc`:             false,
+		`tag!=grpc`:            true,
+		`meta.team=payments`:   true,
+		`meta.team~^pay`:       true,
+		`meta.team!~^pay`:      false,
+		`meta.owner=*`:         false,
+		`meta.owner!=payments`: true,
+	}
+	for value, expected := range cases {
+		e, err := ParseExpression(value)
+		s.NoError(err)
+		s.Equal(expected, e.Match(s.service), value)
+	}
+}
+
+func (s *expressionMatchTestSuite) TestEmptyTagsAndMeta() {
+	for _, value := range []string{`tag=*`, `meta.team=*`} {
+		e, _ := ParseExpression(value)
+		s.False(e.Match(&core.Service{Name: `service`}), value)
+	}
+}
+
+type expressionStringTestSuite struct {
+	suite.Suite
+}
+
+func (s *expressionStringTestSuite) TestString() {
+	e, _ := ParseExpression(`meta.team=payments`)
+	s.Equal(`meta.team=payments`, e.String())
+}
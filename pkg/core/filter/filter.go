@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Filter is core.Filter implementation with include and exclude Expression lists.
+	// Service is matched, if it is matched by all include expressions and is not matched by any exclude expression.
+	Filter struct {
+		include []*Expression
+		exclude []*Expression
+	}
+)
+
+// NewFilter provide Filter as core.Filter implementation for include and exclude expressions
+func NewFilter(include []string, exclude []string) (*Filter, error) {
+	f := new(Filter)
+	for _, value := range include {
+		e, err := ParseExpression(value)
+		if err != nil {
+			return nil, errors.Wrap(err, `invalid include expression`)
+		}
+		f.include = append(f.include, e)
+	}
+	for _, value := range exclude {
+		e, err := ParseExpression(value)
+		if err != nil {
+			return nil, errors.Wrap(err, `invalid exclude expression`)
+		}
+		f.exclude = append(f.exclude, e)
+	}
+	return f, nil
+}
+
+// Match is implementation of core.Filter interface
+func (f *Filter) Match(service *core.Service) bool {
+	for _, e := range f.include {
+		if !e.Match(service) {
+			return false
+		}
+	}
+	for _, e := range f.exclude {
+		if e.Match(service) {
+			return false
+		}
+	}
+	return true
+}
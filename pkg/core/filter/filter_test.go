@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewFilter(t *testing.T) {
+	suite.Run(t, new(newFilterTestSuite))
+}
+
+func TestFilter_Match(t *testing.T) {
+	suite.Run(t, new(filterMatchTestSuite))
+}
+
+// --- Suites ---
+
+type newFilterTestSuite struct {
+	suite.Suite
+}
+
+func (s *newFilterTestSuite) TestNewFilter() {
+	got, err := NewFilter([]string{`name=*`}, []string{`tag=legacy`})
+	s.NoError(err)
+	s.Implements((*core.Filter)(nil), got)
+	s.Len(got.include, 1)
+	s.Len(got.exclude, 1)
+}
+
+func (s *newFilterTestSuite) TestInvalidInclude() {
+	got, err := NewFilter([]string{`name`}, nil)
+	s.Nil(got)
+	s.EqualError(err, `invalid include expression: expression "name" must be in format "field=glob" or "field~regexp"`)
+}
+
+func (s *newFilterTestSuite) TestInvalidExclude() {
+	got, err := NewFilter(nil, []string{`name`})
+	s.Nil(got)
+	s.EqualError(err, `invalid exclude expression: expression "name" must be in format "field=glob" or "field~regexp"`)
+}
+
+type filterMatchTestSuite struct {
+	suite.Suite
+}
+
+func (s *filterMatchTestSuite) TestEmpty() {
+	f, _ := NewFilter(nil, nil)
+	s.True(f.Match(&core.Service{Name: `service`}))
+}
+
+func (s *filterMatchTestSuite) TestMatch() {
+	f, _ := NewFilter([]string{`meta.team=payments`, `name=payments-*`}, []string{`tag=legacy`})
+	s.True(f.Match(&core.Service{Name: `payments-api`, Meta: &map[string]string{`team`: `payments`}}))
+	s.False(f.Match(&core.Service{Name: `payments-api`, Meta: &map[string]string{`team`: `orders`}}))
+	s.False(f.Match(&core.Service{Name: `api`, Meta: &map[string]string{`team`: `payments`}}))
+	s.False(f.Match(&core.Service{
+		Name: `payments-api`,
+		Tags: &[]string{`legacy`},
+		Meta: &map[string]string{`team`: `payments`},
+	}))
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestFilterFunc_Match(t *testing.T) {
+	suite.Run(t, new(filterFuncMatchTestSuite))
+}
+
+func TestServices_Filter(t *testing.T) {
+	suite.Run(t, new(servicesFilterTestSuite))
+}
+
+// --- Suites ---
+
+type filterFuncMatchTestSuite struct {
+	suite.Suite
+}
+
+func (s *filterFuncMatchTestSuite) TestMatch() {
+	f := FilterFunc(func(service *Service) bool {
+		return service.Name == `service-1`
+	})
+	s.True(f.Match(&Service{Name: `service-1`}))
+	s.False(f.Match(&Service{Name: `service-2`}))
+}
+
+type servicesFilterTestSuite struct {
+	suite.Suite
+}
+
+func (s *servicesFilterTestSuite) TestFilter() {
+	services := Services{{Name: `service-1`}, {Name: `service-2`}}
+	filtered := services.Filter(FilterFunc(func(service *Service) bool {
+		return service.Name == `service-2`
+	}))
+	s.Equal(Services{{Name: `service-2`}}, filtered)
+}
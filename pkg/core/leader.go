@@ -0,0 +1,16 @@
+package core
+
+import (
+	"context"
+)
+
+type (
+	// LeaderElector provides leadership among several Scheduler instances, so only one of them calls Manager.Run.
+	// Acquire must block until leadership is acquired or context.Context canceled, in last case ctx.Err() is returned.
+	// Channel returned by Acquire must be closed, when leadership is lost.
+	// Release gives leadership up and must be called after every successful Acquire, even if leadership was lost.
+	LeaderElector interface {
+		Acquire(ctx context.Context) (<-chan struct{}, error)
+		Release() error
+	}
+)
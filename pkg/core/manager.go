@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cast"
 	"github.com/thoas/go-funk"
 )
@@ -12,8 +16,12 @@ import (
 type (
 	// ManagerInterface is main unit between Source and Registry.
 	// Implementation must provide full cycle for fetch services from Source and register them into Registry.
+	// Run must return Report with result of processing for every Service.
+	// Plan must return changes, which Run is going to apply, without any changes in Registry.
+	// Plan contains separate changes for every RegistryMember, if Registry implements RegistryGroup.
 	ManagerInterface interface {
-		Run(ctx context.Context) error
+		Run(ctx context.Context) (*Report, error)
+		Plan(ctx context.Context) ([]*Plan, error)
 	}
 
 	// Manager is built-in ManagerInterface implementation.
@@ -22,59 +30,231 @@ type (
 		registry    Registry
 		logger      LoggerInterface
 		exitOnError ManagerExitOnError
+		fullResync  bool
+		limit       DeregistrationLimit
+		force       bool
+		concurrency int
+		transformer TransformerChain
+		filter      Filter
+		metrics     Metrics
 	}
 
 	// ManagerExitOnError provide information how to handle errors and panics during manager.Run process.
 	ManagerExitOnError bool
 
+	// DeregistrationLimit contains max count and max percent of registered Services, which can be deregistered by
+	// single Manager.Run call. Zero values mean no limit.
+	DeregistrationLimit struct {
+		MaxCount   int
+		MaxPercent float64
+	}
+
+	// DeregistrationLimitError is returned by Manager.Run, when count of orphan Services exceeds DeregistrationLimit.
+	DeregistrationLimitError struct {
+		Limit      DeregistrationLimit
+		Orphan     int
+		Registered int
+	}
+
+	// ManagerOption is a func for additional Manager configuration, passed to NewManager.
+	ManagerOption func(*Manager)
+
+	// Plan contains Services, grouped by action, which Manager is going to apply to Registry.
+	// Registered contains all Services fetched from Registry and can be used to look up current state of updated Services.
+	// Registry contains RegistryMember name, if Registry implements RegistryGroup.
+	// LimitExceeded is set, if Deregister exceeds DeregistrationLimit, so Manager.Run is going to skip all changes.
+	Plan struct {
+		Registry      string
+		Create        Services
+		Update        Services
+		Unchanged     Services
+		Deregister    Services
+		Registered    Services
+		LimitExceeded *DeregistrationLimitError
+	}
+
 	managerError []error
+
+	serviceHandler func(context.Context, *Service) (Action, error)
 )
 
 // NewManager provider built-in ManagerInterface implementation
-func NewManager(source Source, registry Registry, logger LoggerInterface, exitOnError ManagerExitOnError) ManagerInterface {
-	return &Manager{
+func NewManager(source Source, registry Registry, logger LoggerInterface, exitOnError ManagerExitOnError, options ...ManagerOption) ManagerInterface {
+	m := &Manager{
 		source:      source,
 		registry:    registry,
 		logger:      logger,
 		exitOnError: exitOnError,
 	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// WithFullResync disables comparison of incoming Services with registered ones.
+// Every incoming Service will be registered in Registry on every Manager.Run call.
+func WithFullResync(fullResync bool) ManagerOption {
+	return func(m *Manager) {
+		m.fullResync = fullResync
+	}
+}
+
+// WithDeregistrationLimit protects Registry from mass deregistration, e.g. when Source returns truncated list.
+func WithDeregistrationLimit(limit DeregistrationLimit) ManagerOption {
+	return func(m *Manager) {
+		m.limit = limit
+	}
+}
+
+// WithForce disables DeregistrationLimit check, so intentional large cleanups are possible.
+func WithForce(force bool) ManagerOption {
+	return func(m *Manager) {
+		m.force = force
+	}
+}
+
+// WithConcurrency sets count of workers, which call Registry.Register and Registry.Deregister concurrently.
+// Values less than 1 mean single worker.
+func WithConcurrency(concurrency int) ManagerOption {
+	return func(m *Manager) {
+		m.concurrency = concurrency
+	}
+}
+
+// WithTransformers adds Transformer list, which is applied in passed order to Services fetched from Source.
+func WithTransformers(transformers ...Transformer) ManagerOption {
+	return func(m *Manager) {
+		m.transformer = append(m.transformer, transformers...)
+	}
+}
+
+// WithFilter sets Filter, which is applied to Services fetched from Source after Transformer list
+// and to Services fetched from Registry. Nil Filter means all Services are owned by Manager.
+func WithFilter(filter Filter) ManagerOption {
+	return func(m *Manager) {
+		m.filter = filter
+	}
+}
+
+// WithMetrics sets Metrics sink for count of Services fetched from Source and result of every processed Service.
+// Nil Metrics means metrics are not collected.
+func WithMetrics(metrics Metrics) ManagerOption {
+	return func(m *Manager) {
+		m.metrics = metrics
+	}
 }
 
 // Run contains next steps
-// - Call Source.Fetch
-// - Call Registry.Fetch
-// - Check orphan Services fetched from Registry
+// - Call Source.Fetch, apply Transformer list and Filter
+// - Call Registry.Fetch, apply Filter and compare Services for every RegistryMember
+// - Check DeregistrationLimit, nothing is changed in Registry if limit is exceeded
 // - Remove orphan Services
-// - Register Services fetched from Source
-func (m *Manager) Run(ctx context.Context) error {
-	m.logger.Infoln(`Fetching services from source`)
-	incoming, err := m.source.Fetch(ctx)
+// - Register new and changed Services fetched from Source, unchanged Services are skipped
+// Run always returns Report with result for every processed Service, even if error occurred
+func (m *Manager) Run(ctx context.Context) (*Report, error) {
+	report := NewReport()
+	err := m.run(ctx, report)
+	report.Finish()
+	if m.metrics != nil {
+		for _, item := range report.Items {
+			m.metrics.ServiceProcessed(item.Registry, item.Action)
+		}
+	}
+	return report, err
+}
+
+// Plan contains next steps
+// - Call Source.Fetch, apply Transformer list and Filter
+// - Call Registry.Fetch and apply Filter for every RegistryMember
+// - Compare Services fetched from Source with Services fetched from Registry
+// - Check DeregistrationLimit, Plan.LimitExceeded is set if limit is exceeded
+func (m *Manager) Plan(ctx context.Context) ([]*Plan, error) {
+	incoming, err := m.fetchSource(ctx)
 	if err != nil {
-		return errors.Wrap(err, `failed to fetch services from source`)
+		return nil, err
 	}
 
-	m.logger.Infoln(`Fetching services from registry`)
-	registered, err := m.registry.Fetch(ctx)
+	plans := make([]*Plan, 0)
+	for _, member := range m.members() {
+		plan, err := m.planMember(ctx, member, incoming)
+		if err != nil {
+			return nil, wrapMemberError(member, err)
+		}
+		plan.LimitExceeded = m.checkDeregistrationLimit(plan)
+		if plan.LimitExceeded != nil {
+			m.logger.WithFields(logrus.Fields{
+				`registry`:   member.Name,
+				`orphan`:     plan.LimitExceeded.Orphan,
+				`registered`: plan.LimitExceeded.Registered,
+			}).Warningln(plan.LimitExceeded.Error())
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func (m *Manager) run(ctx context.Context, report *Report) error {
+	incoming, err := m.fetchSource(ctx)
 	if err != nil {
-		return errors.Wrap(err, `failed to fetch services from registry`)
+		return err
 	}
 
-	m.logger.Infoln(`Checking difference between registered services and incoming list`)
-	orphan := m.findOrphan(incoming, registered)
-	if len(orphan) > 0 {
-		m.logger.Infof(`Deleting %d orphan services`, len(orphan))
-		if err := m.deregisterServices(ctx, orphan); err != nil {
+	me := new(managerError)
+	for _, member := range m.members() {
+		plan, err := m.planMember(ctx, member, incoming)
+		if err == nil {
+			err = m.apply(ctx, member, plan, report)
+		}
+		if err != nil {
+			me.Add(wrapMemberError(member, err))
+		}
+	}
+	switch len(*me) {
+	case 0:
+		return nil
+	case 1:
+		return (*me)[0]
+	}
+	return me
+}
+
+func (m *Manager) apply(ctx context.Context, member RegistryMember, plan *Plan, report *Report) error {
+	if err := m.checkDeregistrationLimit(plan); err != nil {
+		m.logger.WithFields(logrus.Fields{
+			`registry`:   member.Name,
+			`orphan`:     err.Orphan,
+			`registered`: err.Registered,
+		}).Errorln(err.Error())
+		report.AddServices(member.Name, plan.Deregister, ActionSkipped)
+		report.AddServices(member.Name, plan.Unchanged, ActionUnchanged)
+		report.AddServices(member.Name, plan.Create, ActionSkipped)
+		report.AddServices(member.Name, plan.Update, ActionSkipped)
+		return err
+	}
+
+	if len(plan.Deregister) > 0 {
+		m.logger.Infof(`Deleting %d orphan services`, len(plan.Deregister))
+		if err := m.deregisterServices(ctx, member, plan.Deregister, report); err != nil {
 			err := errors.Wrap(err, `failed to deregister services`)
 			m.logger.Error(err.Error())
 			if m.exitOnError {
+				report.AddServices(member.Name, plan.Unchanged, ActionUnchanged)
+				report.AddServices(member.Name, plan.Create, ActionSkipped)
+				report.AddServices(member.Name, plan.Update, ActionSkipped)
 				return err
 			}
 		}
 	}
 
-	if len(incoming) > 0 {
-		m.logger.Infoln(`Registering services in registry`)
-		if err := m.registerServices(ctx, incoming); err != nil {
+	if len(plan.Unchanged) > 0 {
+		m.logger.Infof(`Skipping %d unchanged services`, len(plan.Unchanged))
+		report.AddServices(member.Name, plan.Unchanged, ActionUnchanged)
+	}
+
+	if len(plan.Create) > 0 || len(plan.Update) > 0 {
+		m.logger.Infof(`Registering %d new and %d changed services in registry`, len(plan.Create), len(plan.Update))
+		if err := m.registerServices(ctx, member, plan, report); err != nil {
 			err := errors.Wrap(err, `failed to register services`)
 			m.logger.Error(err.Error())
 			if m.exitOnError {
@@ -85,6 +265,127 @@ func (m *Manager) Run(ctx context.Context) error {
 	return nil
 }
 
+func (m *Manager) members() []RegistryMember {
+	if group, ok := m.registry.(RegistryGroup); ok {
+		return group.Members()
+	}
+	return []RegistryMember{{Registry: m.registry}}
+}
+
+func (m *Manager) fetchSource(ctx context.Context) (Services, error) {
+	m.logger.Infoln(`Fetching services from source`)
+	incoming, err := m.source.Fetch(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to fetch services from source`)
+	}
+	if len(m.transformer) > 0 {
+		m.logger.Infof(`Transforming %d services fetched from source`, len(incoming))
+		incoming, err = m.transformer.Transform(ctx, incoming)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to transform services`)
+		}
+	}
+	if m.filter != nil {
+		filtered := incoming.Filter(m.filter)
+		m.logger.Infof(`Filter matched %d of %d services fetched from source`, len(filtered), len(incoming))
+		incoming = filtered
+	}
+	for _, id := range incoming.Duplicates() {
+		m.logger.Warningf(`Service "%s" is defined more than once in source, first definition is used`, id)
+	}
+	if m.metrics != nil {
+		m.metrics.SourceFetched(len(incoming))
+	}
+	return incoming, nil
+}
+
+func (m *Manager) planMember(ctx context.Context, member RegistryMember, incoming Services) (*Plan, error) {
+	if member.Name != `` {
+		m.logger.Infof(`Fetching services from registry "%s"`, member.Name)
+	} else {
+		m.logger.Infoln(`Fetching services from registry`)
+	}
+	registered, err := member.Registry.Fetch(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to fetch services from registry`)
+	}
+	if m.filter != nil {
+		filtered := registered.Filter(m.filter)
+		m.logger.Infof(`Filter matched %d of %d services fetched from registry`, len(filtered), len(registered))
+		registered = filtered
+	}
+
+	m.logger.Infoln(`Checking difference between registered services and incoming list`)
+	plan := m.plan(withDefaultCheckIDs(member.Registry, incoming), registered)
+	plan.Registry = member.Name
+	return plan, nil
+}
+
+func (m *Manager) plan(incoming Services, registered Services) *Plan {
+	plan := &Plan{
+		Create:     make(Services, 0),
+		Update:     make(Services, 0),
+		Unchanged:  make(Services, 0),
+		Deregister: m.findOrphan(incoming, registered),
+		Registered: registered,
+	}
+	seen := make(map[string]bool, len(incoming))
+	for _, service := range incoming {
+		if seen[service.RegistrationID()] {
+			continue
+		}
+		seen[service.RegistrationID()] = true
+		current := registered.Lookup(service.RegistrationID())
+		switch {
+		case current == nil:
+			plan.Create = append(plan.Create, service)
+		case m.fullResync || !service.Equal(current):
+			plan.Update = append(plan.Update, service)
+		default:
+			plan.Unchanged = append(plan.Unchanged, service)
+		}
+	}
+	return plan
+}
+
+// withDefaultCheckIDs return copy of incoming Services, where checks without ID get default ID of Registry.
+// Services are returned as is, if Registry does not implement CheckIDGenerator
+func withDefaultCheckIDs(registry Registry, incoming Services) Services {
+	generator, ok := registry.(CheckIDGenerator)
+	if !ok {
+		return incoming
+	}
+	result := make(Services, 0, len(incoming))
+	for _, service := range incoming {
+		if service.Checks == nil {
+			result = append(result, service)
+			continue
+		}
+		clone := service.Clone()
+		for _, check := range *clone.Checks {
+			if check == nil || check.ID != nil {
+				continue
+			}
+			if id := generator.DefaultCheckID(clone, check); id != `` {
+				check.ID = &id
+			}
+		}
+		result = append(result, clone)
+	}
+	return result
+}
+
+func (m *Manager) checkDeregistrationLimit(plan *Plan) *DeregistrationLimitError {
+	if m.force || len(plan.Deregister) == 0 || !m.limit.Exceeded(len(plan.Deregister), len(plan.Registered)) {
+		return nil
+	}
+	return &DeregistrationLimitError{
+		Limit:      m.limit,
+		Orphan:     len(plan.Deregister),
+		Registered: len(plan.Registered),
+	}
+}
+
 func (m *Manager) findOrphan(incoming Services, registered Services) Services {
 	_, right := funk.Difference(incoming.IDs(), registered.IDs())
 	orphan := make(Services, 0)
@@ -96,34 +397,113 @@ func (m *Manager) findOrphan(incoming Services, registered Services) Services {
 	return orphan
 }
 
-func (m *Manager) deregisterServices(ctx context.Context, services Services) error {
-	me := new(managerError)
-	for _, service := range services {
-		if err := m.registry.Deregister(ctx, service); err != nil {
-			me.Add(errors.Wrapf(err, `failed to deregister service "%s" from registry`, service.RegistrationID()))
-			continue
+func (m *Manager) deregisterServices(ctx context.Context, member RegistryMember, services Services, report *Report) error {
+	return m.process(ctx, member, services, report, func(ctx context.Context, service *Service) (Action, error) {
+		if err := member.Registry.Deregister(ctx, service); err != nil {
+			return ActionFailed, errors.Wrapf(err, `failed to deregister service "%s" from registry`, service.RegistrationID())
 		}
+		return ActionDeregistered, nil
+	})
+}
+
+func (m *Manager) registerServices(ctx context.Context, member RegistryMember, plan *Plan, report *Report) error {
+	created := make(map[*Service]bool, len(plan.Create))
+	for _, service := range plan.Create {
+		created[service] = true
 	}
-	if me.HasErrors() {
-		return me
-	}
-	return nil
+	services := append(append(Services{}, plan.Create...), plan.Update...)
+	return m.process(ctx, member, services, report, func(ctx context.Context, service *Service) (Action, error) {
+		if err := member.Registry.Register(ctx, service); err != nil {
+			return ActionFailed, errors.Wrapf(err, `failed to register service "%s" in registry`, service.RegistrationID())
+		}
+		if created[service] {
+			return ActionRegistered, nil
+		}
+		return ActionUpdated, nil
+	})
 }
 
-func (m *Manager) registerServices(ctx context.Context, services Services) error {
+// process calls serviceHandler for every Service with bounded count of workers and collects results in Report.
+// Services, which were not processed before context.Context cancellation, are marked as skipped.
+func (m *Manager) process(ctx context.Context, member RegistryMember, services Services, report *Report, handler serviceHandler) error {
+	workers := m.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(services) {
+		workers = len(services)
+	}
+
 	me := new(managerError)
+	mu := new(sync.Mutex)
+	queue := make(chan *Service)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for service := range queue {
+				if err := ctx.Err(); err != nil {
+					err = errors.Wrapf(err, `service "%s" was skipped`, service.RegistrationID())
+					mu.Lock()
+					me.Add(err)
+					mu.Unlock()
+					report.Add(member.Name, service.RegistrationID(), ActionSkipped, err, 0)
+					continue
+				}
+				started := time.Now()
+				action, err := handler(ctx, service)
+				if err != nil {
+					mu.Lock()
+					me.Add(err)
+					mu.Unlock()
+				}
+				report.Add(member.Name, service.RegistrationID(), action, err, time.Since(started))
+			}
+		}()
+	}
 	for _, service := range services {
-		if err := m.registry.Register(ctx, service); err != nil {
-			me.Add(errors.Wrapf(err, `failed to register service "%s" in registry`, service.RegistrationID()))
-			continue
-		}
+		queue <- service
 	}
+	close(queue)
+	wg.Wait()
+
 	if me.HasErrors() {
 		return me
 	}
 	return nil
 }
 
+// wrapMemberError adds RegistryMember name to error, if Registry implements RegistryGroup
+func wrapMemberError(member RegistryMember, err error) error {
+	if member.Name == `` {
+		return err
+	}
+	return errors.Wrapf(err, `registry "%s"`, member.Name)
+}
+
+// Exceeded checks if deregistration of orphan Services from registered Services violates DeregistrationLimit
+func (l DeregistrationLimit) Exceeded(orphan int, registered int) bool {
+	if l.MaxCount > 0 && orphan > l.MaxCount {
+		return true
+	}
+	if l.MaxPercent > 0 && registered > 0 && float64(orphan)*100/float64(registered) > l.MaxPercent {
+		return true
+	}
+	return false
+}
+
+// Error is implementation of error interface
+func (e *DeregistrationLimitError) Error() string {
+	return fmt.Sprintf(
+		`refusing to deregister %d of %d registered services: deregistration limit is exceeded (max count %d, max percent %.2f), use force to override`,
+		e.Orphan,
+		e.Registered,
+		e.Limit.MaxCount,
+		e.Limit.MaxPercent,
+	)
+}
+
 func (e *managerError) Add(err error) {
 	*e = append(*e, err)
 }
@@ -143,3 +523,28 @@ func (e *managerError) String() string {
 func (e *managerError) Error() string {
 	return e.String()
 }
+
+// Unwrap return all collected errors, so errors.Is and errors.As check every error on Go 1.20+
+func (e *managerError) Unwrap() []error {
+	return *e
+}
+
+// Is checks if any collected error matches target, it is used by errors.Is
+func (e *managerError) Is(target error) bool {
+	for _, err := range *e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds first collected error, which matches target, it is used by errors.As
+func (e *managerError) As(target interface{}) bool {
+	for _, err := range *e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
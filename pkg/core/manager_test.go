@@ -2,8 +2,11 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/agrea/ptr"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/mock"
@@ -16,10 +19,54 @@ func TestNewManager(t *testing.T) {
 	suite.Run(t, new(newManagerTestSuite))
 }
 
+func TestWithFullResync(t *testing.T) {
+	suite.Run(t, new(withFullResyncTestSuite))
+}
+
+func TestWithDeregistrationLimit(t *testing.T) {
+	suite.Run(t, new(withDeregistrationLimitTestSuite))
+}
+
+func TestWithForce(t *testing.T) {
+	suite.Run(t, new(withForceTestSuite))
+}
+
+func TestWithConcurrency(t *testing.T) {
+	suite.Run(t, new(withConcurrencyTestSuite))
+}
+
+func TestWithTransformers(t *testing.T) {
+	suite.Run(t, new(withTransformersTestSuite))
+}
+
+func TestWithFilter(t *testing.T) {
+	suite.Run(t, new(withFilterTestSuite))
+}
+
+func TestWithMetrics(t *testing.T) {
+	suite.Run(t, new(withMetricsTestSuite))
+}
+
+func TestDeregistrationLimit_Exceeded(t *testing.T) {
+	suite.Run(t, new(deregistrationLimitExceededTestSuite))
+}
+
+func TestDeregistrationLimitError_Error(t *testing.T) {
+	suite.Run(t, new(deregistrationLimitErrorErrorTestSuite))
+}
+
 func TestManager_Run(t *testing.T) {
 	suite.Run(t, new(managerRunTestSuite))
 }
 
+func TestManager_Plan(t *testing.T) {
+	suite.Run(t, new(managerPlanMethodTestSuite))
+}
+
+func TestManager_plan(t *testing.T) {
+	suite.Run(t, new(managerPlanTestSuite))
+}
+
 func TestManagerError_Add(t *testing.T) {
 	suite.Run(t, new(managerErrorAddTestSuite))
 }
@@ -36,6 +83,18 @@ func TestManagerError_HasErrors(t *testing.T) {
 	suite.Run(t, new(managerErrorHasErrorsTestSuite))
 }
 
+func TestManagerError_Unwrap(t *testing.T) {
+	suite.Run(t, new(managerErrorUnwrapTestSuite))
+}
+
+func TestManagerError_Is(t *testing.T) {
+	suite.Run(t, new(managerErrorIsTestSuite))
+}
+
+func TestManagerError_As(t *testing.T) {
+	suite.Run(t, new(managerErrorAsTestSuite))
+}
+
 // --- Suites ---
 
 type newManagerTestSuite struct {
@@ -44,11 +103,129 @@ type newManagerTestSuite struct {
 
 func (s *newManagerTestSuite) TestNewManager() {
 	s.Equal(
-		&Manager{nil, nil, nil, true},
+		&Manager{exitOnError: true},
 		NewManager(nil, nil, nil, true),
 	)
 }
 
+func (s *newManagerTestSuite) TestNewManagerWithOptions() {
+	s.Equal(
+		&Manager{exitOnError: true, fullResync: true},
+		NewManager(nil, nil, nil, true, WithFullResync(true)),
+	)
+}
+
+type withFullResyncTestSuite struct {
+	suite.Suite
+}
+
+func (s *withFullResyncTestSuite) TestWithFullResync() {
+	m := new(Manager)
+	WithFullResync(true)(m)
+	s.True(m.fullResync)
+}
+
+type withDeregistrationLimitTestSuite struct {
+	suite.Suite
+}
+
+func (s *withDeregistrationLimitTestSuite) TestWithDeregistrationLimit() {
+	m := new(Manager)
+	WithDeregistrationLimit(DeregistrationLimit{MaxCount: 10, MaxPercent: 50})(m)
+	s.Equal(DeregistrationLimit{MaxCount: 10, MaxPercent: 50}, m.limit)
+}
+
+type withForceTestSuite struct {
+	suite.Suite
+}
+
+func (s *withForceTestSuite) TestWithForce() {
+	m := new(Manager)
+	WithForce(true)(m)
+	s.True(m.force)
+}
+
+type withConcurrencyTestSuite struct {
+	suite.Suite
+}
+
+func (s *withConcurrencyTestSuite) TestWithConcurrency() {
+	m := new(Manager)
+	WithConcurrency(4)(m)
+	s.Equal(4, m.concurrency)
+}
+
+type withTransformersTestSuite struct {
+	suite.Suite
+}
+
+func (s *withTransformersTestSuite) TestWithTransformers() {
+	first := TransformerFunc(nil)
+	second := TransformerFunc(nil)
+	m := new(Manager)
+	WithTransformers(first)(m)
+	WithTransformers(second)(m)
+	s.Len(m.transformer, 2)
+}
+
+type withFilterTestSuite struct {
+	suite.Suite
+}
+
+func (s *withFilterTestSuite) TestWithFilter() {
+	filter := FilterFunc(func(service *Service) bool {
+		return true
+	})
+	m := new(Manager)
+	WithFilter(filter)(m)
+	s.NotNil(m.filter)
+}
+
+type withMetricsTestSuite struct {
+	suite.Suite
+}
+
+func (s *withMetricsTestSuite) TestWithMetrics() {
+	metrics := new(MockMetrics)
+	m := new(Manager)
+	WithMetrics(metrics)(m)
+	s.Equal(metrics, m.metrics)
+}
+
+type deregistrationLimitExceededTestSuite struct {
+	suite.Suite
+}
+
+func (s *deregistrationLimitExceededTestSuite) TestNoLimit() {
+	s.False(DeregistrationLimit{}.Exceeded(100, 100))
+}
+
+func (s *deregistrationLimitExceededTestSuite) TestMaxCount() {
+	limit := DeregistrationLimit{MaxCount: 2}
+	s.False(limit.Exceeded(2, 100))
+	s.True(limit.Exceeded(3, 100))
+}
+
+func (s *deregistrationLimitExceededTestSuite) TestMaxPercent() {
+	limit := DeregistrationLimit{MaxPercent: 50}
+	s.False(limit.Exceeded(5, 10))
+	s.True(limit.Exceeded(6, 10))
+	s.False(limit.Exceeded(0, 0))
+}
+
+type deregistrationLimitErrorErrorTestSuite struct {
+	suite.Suite
+}
+
+func (s *deregistrationLimitErrorErrorTestSuite) TestError() {
+	err := &DeregistrationLimitError{
+		Limit:      DeregistrationLimit{MaxCount: 1, MaxPercent: 10},
+		Orphan:     2,
+		Registered: 3,
+	}
+	s.EqualError(err, `refusing to deregister 2 of 3 registered services: deregistration limit is exceeded (max count 1, max percent 10.00), use force to override`)
+}
+
 type managerRunTestSuite struct {
 	suite.Suite
 	manager *Manager
@@ -65,7 +242,7 @@ func (s *managerRunTestSuite) TestErrorFetchFromSource() {
 	sourceMock.On(`Fetch`, ctx).Return(nil, errors.New(`expected error`))
 
 	s.manager.source = sourceMock
-	err := s.manager.Run(ctx)
+	_, err := s.manager.Run(ctx)
 	s.Error(err)
 	s.EqualError(err, `failed to fetch services from source: expected error`)
 }
@@ -80,7 +257,7 @@ func (s *managerRunTestSuite) TestErrorFetchFromRegistry() {
 	s.manager.source = sourceMock
 	s.manager.registry = registryMock
 
-	err := s.manager.Run(ctx)
+	_, err := s.manager.Run(ctx)
 	s.Error(err)
 	s.EqualError(err, `failed to fetch services from registry: expected error`)
 }
@@ -97,9 +274,16 @@ func (s *managerRunTestSuite) TestErrorDeregisterOrphan() {
 	s.manager.registry = registryMock
 	s.manager.exitOnError = true
 
-	err := s.manager.Run(ctx)
+	report, err := s.manager.Run(ctx)
 	s.Error(err)
 	s.EqualError(err, `failed to deregister services: failed to deregister service "service-2" from registry: expected error`)
+	s.Len(report.Items, 2)
+	s.Equal(`service-2`, report.Items[0].ID)
+	s.Equal(ActionFailed, report.Items[0].Action)
+	s.EqualError(report.Items[0].Error, `failed to deregister service "service-2" from registry: expected error`)
+	s.Equal(`service-1`, report.Items[1].ID)
+	s.Equal(ActionSkipped, report.Items[1].Action)
+	s.False(report.FinishedAt.IsZero())
 }
 
 func (s *managerRunTestSuite) TestErrorRegister() {
@@ -115,7 +299,7 @@ func (s *managerRunTestSuite) TestErrorRegister() {
 	s.manager.registry = registryMock
 	s.manager.exitOnError = true
 
-	err := s.manager.Run(ctx)
+	_, err := s.manager.Run(ctx)
 	s.Error(err)
 	s.EqualError(err, `failed to register services: failed to register service "service-1" in registry: expected error`)
 }
@@ -131,7 +315,524 @@ func (s *managerRunTestSuite) TestSuccess() {
 
 	s.manager.source = sourceMock
 	s.manager.registry = registryMock
-	s.NoError(s.manager.Run(ctx))
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	s.Equal(1, report.Count(ActionDeregistered))
+	s.Equal(1, report.Count(ActionRegistered))
+	s.False(report.HasFailures())
+}
+
+func (s *managerRunTestSuite) TestMetrics() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-3`}}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-2`}, {Name: `service-3`}}, nil)
+	registryMock.On(`Deregister`, ctx, &Service{Name: `service-2`}).Return(nil)
+	registryMock.On(`Register`, ctx, mock.Anything).Return(errors.New(`expected error`))
+	metricsMock := new(MockMetrics)
+	metricsMock.On(`SourceFetched`, 2).Once()
+	metricsMock.On(`ServiceProcessed`, ``, ActionDeregistered).Once()
+	metricsMock.On(`ServiceProcessed`, ``, ActionUnchanged).Once()
+	metricsMock.On(`ServiceProcessed`, ``, ActionFailed).Once()
+
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.metrics = metricsMock
+	_, err := s.manager.Run(ctx)
+	s.NoError(err)
+	metricsMock.AssertExpectations(s.T())
+}
+
+func (s *managerRunTestSuite) TestSkipUnchanged() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`, Address: `127.0.0.1`}, {Name: `service-2`, Address: `127.0.0.2`}}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`, Address: `127.0.0.1`}, {Name: `service-2`, Address: `127.0.0.1`}}, nil)
+	registryMock.On(`Register`, ctx, &Service{Name: `service-2`, Address: `127.0.0.2`}).Return(nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	registryMock.AssertNumberOfCalls(s.T(), `Register`, 1)
+	s.Equal(1, report.Count(ActionUnchanged))
+	s.Equal(1, report.Count(ActionUpdated))
+}
+
+func (s *managerRunTestSuite) TestRegistryWithoutNode() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{
+		{Name: `service-1`, Address: `127.0.0.1`, Node: &Node{Node: `node-1`, Address: `10.0.0.1`}},
+	}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`, Address: `127.0.0.1`}}, nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	s.Equal(1, report.Count(ActionUnchanged))
+	registryMock.AssertNotCalled(s.T(), `Register`, mock.Anything, mock.Anything)
+	registryMock.AssertNotCalled(s.T(), `Deregister`, mock.Anything, mock.Anything)
+}
+
+func (s *managerRunTestSuite) TestErrorDeregistrationLimit() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-2`}, {Name: `service-3`}}, nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.limit = DeregistrationLimit{MaxCount: 1}
+	report, err := s.manager.Run(ctx)
+	s.Equal(3, report.Count(ActionSkipped))
+	s.IsType(&DeregistrationLimitError{}, err)
+	s.Equal(&DeregistrationLimitError{Limit: s.manager.limit, Orphan: 2, Registered: 2}, err)
+	registryMock.AssertNotCalled(s.T(), `Deregister`, mock.Anything, mock.Anything)
+	registryMock.AssertNotCalled(s.T(), `Register`, mock.Anything, mock.Anything)
+}
+
+func (s *managerRunTestSuite) TestForceDeregistrationLimit() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-2`}}, nil)
+	registryMock.On(`Deregister`, ctx, mock.Anything).Return(nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.limit = DeregistrationLimit{MaxPercent: 50}
+	s.manager.force = true
+	_, err := s.manager.Run(ctx)
+	s.NoError(err)
+	registryMock.AssertNumberOfCalls(s.T(), `Deregister`, 2)
+}
+
+func (s *managerRunTestSuite) TestConcurrency() {
+	ctx := context.Background()
+	incoming := make(Services, 0)
+	registered := make(Services, 0)
+	for i := 0; i < 20; i++ {
+		incoming = append(incoming, &Service{Name: fmt.Sprintf(`incoming-%d`, i)})
+		registered = append(registered, &Service{Name: fmt.Sprintf(`registered-%d`, i)})
+	}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(incoming, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(registered, nil)
+	registryMock.On(`Deregister`, ctx, mock.Anything).Return(nil)
+	registryMock.On(`Register`, ctx, &Service{Name: `incoming-0`}).Return(errors.New(`expected error`))
+	registryMock.On(`Register`, ctx, mock.Anything).Return(nil)
+
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.concurrency = 4
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	s.Len(report.Items, 40)
+	s.Equal(20, report.Count(ActionDeregistered))
+	s.Equal(19, report.Count(ActionRegistered))
+	s.Equal(1, report.Count(ActionFailed))
+}
+
+func (s *managerRunTestSuite) TestContextCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-2`}}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock.On(`Register`, ctx, &Service{Name: `service-1`}).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(nil)
+
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.exitOnError = true
+	report, err := s.manager.Run(ctx)
+	s.EqualError(err, `failed to register services: service "service-2" was skipped: context canceled`)
+	s.Equal(1, report.Count(ActionRegistered))
+	s.Equal(1, report.Count(ActionSkipped))
+	registryMock.AssertNumberOfCalls(s.T(), `Register`, 1)
+}
+
+func (s *managerRunTestSuite) TestRegistryGroup() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}}, nil)
+	firstMock := new(MockRegistry)
+	firstMock.On(`Fetch`, ctx).Return(Services{{Name: `service-2`}}, nil)
+	firstMock.On(`Deregister`, ctx, &Service{Name: `service-2`}).Return(nil)
+	firstMock.On(`Register`, ctx, mock.Anything).Return(nil)
+	secondMock := new(MockRegistry)
+	secondMock.On(`Fetch`, ctx).Return(nil, errors.New(`expected error`))
+	groupMock := new(MockRegistryGroup)
+	groupMock.On(`Members`).Return([]RegistryMember{
+		{Name: `first`, Registry: firstMock},
+		{Name: `second`, Registry: secondMock},
+	})
+
+	s.manager.source = sourceMock
+	s.manager.registry = groupMock
+	report, err := s.manager.Run(ctx)
+	s.EqualError(err, `registry "second": failed to fetch services from registry: expected error`)
+	s.Len(report.Items, 2)
+	for _, item := range report.Items {
+		s.Equal(`first`, item.Registry)
+	}
+	s.Equal(1, report.Count(ActionDeregistered))
+	s.Equal(1, report.Count(ActionRegistered))
+	sourceMock.AssertNumberOfCalls(s.T(), `Fetch`, 1)
+	groupMock.AssertNotCalled(s.T(), `Fetch`, mock.Anything)
+}
+
+func (s *managerRunTestSuite) TestRegistryGroupErrors() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	firstMock := new(MockRegistry)
+	firstMock.On(`Fetch`, ctx).Return(nil, errors.New(`first error`))
+	secondMock := new(MockRegistry)
+	secondMock.On(`Fetch`, ctx).Return(nil, errors.New(`second error`))
+	groupMock := new(MockRegistryGroup)
+	groupMock.On(`Members`).Return([]RegistryMember{
+		{Name: `first`, Registry: firstMock},
+		{Name: `second`, Registry: secondMock},
+	})
+
+	s.manager.source = sourceMock
+	s.manager.registry = groupMock
+	_, err := s.manager.Run(ctx)
+	s.IsType(new(managerError), err)
+	s.Len(*err.(*managerError), 2)
+}
+
+func (s *managerRunTestSuite) TestRegistryGroupDeregistrationLimit() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	firstMock := new(MockRegistry)
+	firstMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-2`}}, nil)
+	secondMock := new(MockRegistry)
+	secondMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-2`}, {Name: `service-3`}}, nil)
+	groupMock := new(MockRegistryGroup)
+	groupMock.On(`Members`).Return([]RegistryMember{
+		{Name: `first`, Registry: firstMock},
+		{Name: `second`, Registry: secondMock},
+	})
+
+	s.manager.source = sourceMock
+	s.manager.registry = groupMock
+	s.manager.limit = DeregistrationLimit{MaxCount: 1}
+	report, err := s.manager.Run(ctx)
+	s.IsType(new(managerError), err)
+	s.Equal(5, report.Count(ActionSkipped))
+	var limitErr *DeregistrationLimitError
+	s.True(errors.As(err, &limitErr))
+	s.Equal(&DeregistrationLimitError{Limit: s.manager.limit, Orphan: 2, Registered: 2}, limitErr)
+	firstMock.AssertNotCalled(s.T(), `Deregister`, mock.Anything, mock.Anything)
+	secondMock.AssertNotCalled(s.T(), `Deregister`, mock.Anything, mock.Anything)
+}
+
+func (s *managerRunTestSuite) TestTransform() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`, Address: `127.0.0.1`}}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock.On(`Register`, ctx, &Service{Name: `prefix-service-1`, Address: `127.0.0.1`}).Return(nil)
+
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.transformer = TransformerChain{
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return Services{{Name: `prefix-` + services[0].Name, Address: services[0].Address}}, nil
+		}),
+	}
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	s.Equal(`prefix-service-1`, report.Items[0].ID)
+	registryMock.AssertNumberOfCalls(s.T(), `Register`, 1)
+}
+
+func (s *managerRunTestSuite) TestErrorTransform() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+
+	s.manager.source = sourceMock
+	s.manager.transformer = TransformerChain{
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return nil, errors.New(`expected error`)
+		}),
+	}
+	_, err := s.manager.Run(ctx)
+	s.EqualError(err, `failed to transform services: transformer #1 failed: expected error`)
+}
+
+func (s *managerRunTestSuite) TestFilter() {
+	ctx := context.Background()
+	owned := func(name string) *Service {
+		return &Service{Name: name, Address: `127.0.0.1`, Meta: &map[string]string{`team`: `payments`}}
+	}
+	foreign := func(name string) *Service {
+		return &Service{Name: name, Address: `127.0.0.1`, Meta: &map[string]string{`team`: `orders`}}
+	}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{owned(`service-1`), foreign(`service-2`)}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{owned(`service-3`), foreign(`service-4`)}, nil)
+	registryMock.On(`Register`, ctx, owned(`service-1`)).Return(nil)
+	registryMock.On(`Deregister`, ctx, owned(`service-3`)).Return(nil)
+
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.filter = FilterFunc(func(service *Service) bool {
+		return (*service.Meta)[`team`] == `payments`
+	})
+	report, err := s.manager.Run(ctx)
+	s.NoError(err)
+	s.Len(report.Items, 2)
+	s.Equal(1, report.Count(ActionRegistered))
+	s.Equal(1, report.Count(ActionDeregistered))
+	registryMock.AssertNotCalled(s.T(), `Register`, ctx, foreign(`service-2`))
+	registryMock.AssertNotCalled(s.T(), `Deregister`, ctx, foreign(`service-4`))
+}
+
+type managerPlanMethodTestSuite struct {
+	suite.Suite
+	manager *Manager
+}
+
+func (s *managerPlanMethodTestSuite) SetupTest() {
+	s.manager = new(Manager)
+	s.manager.logger, _ = test.NewNullLogger()
+}
+
+func (s *managerPlanMethodTestSuite) TestErrorFetchFromSource() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(nil, errors.New(`expected error`))
+	s.manager.source = sourceMock
+	plan, err := s.manager.Plan(ctx)
+	s.Nil(plan)
+	s.EqualError(err, `failed to fetch services from source: expected error`)
+}
+
+func (s *managerPlanMethodTestSuite) TestErrorFetchFromRegistry() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(nil, errors.New(`expected error`))
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	plan, err := s.manager.Plan(ctx)
+	s.Nil(plan)
+	s.EqualError(err, `failed to fetch services from registry: expected error`)
+}
+
+func (s *managerPlanMethodTestSuite) TestSuccess() {
+	ctx := context.Background()
+	incoming := Services{{Name: `service-1`, Address: `127.0.0.1`}}
+	registered := Services{{Name: `service-2`, Address: `127.0.0.1`}}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(incoming, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(registered, nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	plan, err := s.manager.Plan(ctx)
+	s.NoError(err)
+	s.Equal([]*Plan{{
+		Create:     incoming,
+		Update:     Services{},
+		Unchanged:  Services{},
+		Deregister: registered,
+		Registered: registered,
+	}}, plan)
+	registryMock.AssertNotCalled(s.T(), `Register`, mock.Anything, mock.Anything)
+	registryMock.AssertNotCalled(s.T(), `Deregister`, mock.Anything, mock.Anything)
+}
+
+func (s *managerPlanMethodTestSuite) TestDeregistrationLimit() {
+	ctx := context.Background()
+	registered := Services{{Name: `service-1`}, {Name: `service-2`}}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(registered, nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.limit = DeregistrationLimit{MaxPercent: 50}
+	plans, err := s.manager.Plan(ctx)
+	s.NoError(err)
+	s.Len(plans, 1)
+	s.Equal(registered, plans[0].Deregister)
+	s.Equal(&DeregistrationLimitError{Limit: s.manager.limit, Orphan: 2, Registered: 2}, plans[0].LimitExceeded)
+}
+
+func (s *managerPlanMethodTestSuite) TestForceDeregistrationLimit() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(Services{{Name: `service-1`}, {Name: `service-2`}}, nil)
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	s.manager.limit = DeregistrationLimit{MaxPercent: 50}
+	s.manager.force = true
+	plans, err := s.manager.Plan(ctx)
+	s.NoError(err)
+	s.Len(plans, 1)
+	s.Nil(plans[0].LimitExceeded)
+}
+
+func (s *managerPlanMethodTestSuite) TestRegistryGroup() {
+	ctx := context.Background()
+	incoming := Services{{Name: `service-1`}}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(incoming, nil)
+	firstMock := new(MockRegistry)
+	firstMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	secondMock := new(MockRegistry)
+	secondMock.On(`Fetch`, ctx).Return(incoming, nil)
+	groupMock := new(MockRegistryGroup)
+	groupMock.On(`Members`).Return([]RegistryMember{
+		{Name: `first`, Registry: firstMock},
+		{Name: `second`, Registry: secondMock},
+	})
+
+	s.manager.source = sourceMock
+	s.manager.registry = groupMock
+	plans, err := s.manager.Plan(ctx)
+	s.NoError(err)
+	s.Len(plans, 2)
+	s.Equal(`first`, plans[0].Registry)
+	s.Equal(incoming, plans[0].Create)
+	s.Equal(`second`, plans[1].Registry)
+	s.Equal(incoming, plans[1].Unchanged)
+}
+
+func (s *managerPlanMethodTestSuite) TestRegistryGroupError() {
+	ctx := context.Background()
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(Services{}, nil)
+	registryMock := new(MockRegistry)
+	registryMock.On(`Fetch`, ctx).Return(nil, errors.New(`expected error`))
+	groupMock := new(MockRegistryGroup)
+	groupMock.On(`Members`).Return([]RegistryMember{{Name: `first`, Registry: registryMock}})
+
+	s.manager.source = sourceMock
+	s.manager.registry = groupMock
+	plans, err := s.manager.Plan(ctx)
+	s.Nil(plans)
+	s.EqualError(err, `registry "first": failed to fetch services from registry: expected error`)
+}
+
+func (s *managerPlanMethodTestSuite) TestCheckIDGenerator() {
+	ctx := context.Background()
+	incoming := Services{
+		{Name: `legacy`, Checks: &Checks{{Name: `http`}}},
+		{Name: `default`, Checks: &Checks{{Name: `http`}}},
+		{Name: `explicit`, Checks: &Checks{{ID: ptr.String(`custom`), Name: `http`}}},
+	}
+	registered := Services{
+		{Name: `legacy`, Checks: &Checks{{ID: ptr.String(`http`), Name: `http`}}},
+		{Name: `default`, Checks: &Checks{{ID: ptr.String(`service:default:http`), Name: `http`}}},
+		{Name: `explicit`, Checks: &Checks{{ID: ptr.String(`custom`), Name: `http`}}},
+	}
+	sourceMock := new(MockSource)
+	sourceMock.On(`Fetch`, ctx).Return(incoming, nil)
+	registryMock := new(MockCheckIDGeneratorRegistry)
+	registryMock.On(`Fetch`, ctx).Return(registered, nil)
+	registryMock.On(`DefaultCheckID`, mock.Anything, mock.Anything).Return(func(service *Service, check *Check) string {
+		return `service:` + service.RegistrationID() + `:` + check.Name
+	})
+	s.manager.source = sourceMock
+	s.manager.registry = registryMock
+	plans, err := s.manager.Plan(ctx)
+	s.NoError(err)
+	s.Len(plans, 1)
+	s.Equal(Services{{Name: `legacy`, Checks: &Checks{{ID: ptr.String(`service:legacy:http`), Name: `http`}}}}, plans[0].Update)
+	s.Equal(Services{registered[1], registered[2]}, plans[0].Unchanged)
+	s.Nil((*incoming[0].Checks)[0].ID)
+	registryMock.AssertNumberOfCalls(s.T(), `DefaultCheckID`, 2)
+}
+
+type managerPlanTestSuite struct {
+	suite.Suite
+	manager *Manager
+}
+
+func (s *managerPlanTestSuite) SetupTest() {
+	s.manager = new(Manager)
+}
+
+func (s *managerPlanTestSuite) TestPlan() {
+	incoming := Services{
+		{Name: `create`, Address: `127.0.0.1`},
+		{Name: `update`, Address: `127.0.0.2`},
+		{Name: `unchanged`, Address: `127.0.0.1`},
+	}
+	registered := Services{
+		{Name: `update`, Address: `127.0.0.1`},
+		{Name: `unchanged`, Address: `127.0.0.1`},
+		{Name: `orphan`, Address: `127.0.0.1`},
+	}
+	s.Equal(&Plan{
+		Create:     Services{incoming[0]},
+		Update:     Services{incoming[1]},
+		Unchanged:  Services{incoming[2]},
+		Deregister: Services{registered[2]},
+		Registered: registered,
+	}, s.manager.plan(incoming, registered))
+}
+
+func (s *managerPlanTestSuite) TestPlanRegistryWithoutNode() {
+	node := &Node{Node: `node-1`, Address: `10.0.0.1`}
+	incoming := Services{
+		{Name: `service-1`, Address: `127.0.0.1`, Node: node},
+		{Name: `service-2`, Address: `127.0.0.2`, Port: ptr.Int(80), Node: node},
+	}
+	registered := Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+		{Name: `service-2`, Address: `127.0.0.2`, Port: ptr.Int(80)},
+	}
+	s.Equal(&Plan{
+		Create:     Services{},
+		Update:     Services{},
+		Unchanged:  incoming,
+		Deregister: Services{},
+		Registered: registered,
+	}, s.manager.plan(incoming, registered))
+}
+
+func (s *managerPlanTestSuite) TestPlanDuplicates() {
+	incoming := Services{
+		{Name: `service`, Address: `127.0.0.1`},
+		{Name: `service`, Address: `127.0.0.2`},
+	}
+	plan := s.manager.plan(incoming, Services{})
+	s.Equal(Services{incoming[0]}, plan.Create)
+}
+
+func (s *managerPlanTestSuite) TestPlanFullResync() {
+	s.manager.fullResync = true
+	incoming := Services{
+		{Name: `unchanged`, Address: `127.0.0.1`},
+	}
+	registered := Services{
+		{Name: `unchanged`, Address: `127.0.0.1`},
+	}
+	s.Equal(&Plan{
+		Create:     Services{},
+		Update:     Services{incoming[0]},
+		Unchanged:  Services{},
+		Deregister: Services{},
+		Registered: registered,
+	}, s.manager.plan(incoming, registered))
 }
 
 type managerErrorAddTestSuite struct {
@@ -192,9 +893,87 @@ func (s *managerErrorHasErrorsTestSuite) TestNonEmptyError() {
 	s.True(s.err.HasErrors())
 }
 
+type managerErrorUnwrapTestSuite struct {
+	suite.Suite
+	err managerError
+}
+
+func (s *managerErrorUnwrapTestSuite) TestUnwrap() {
+	first, second := errors.New(`expected error 1`), errors.New(`expected error 2`)
+	s.err = append(s.err, first, second)
+	s.Equal([]error{first, second}, s.err.Unwrap())
+}
+
+type managerErrorIsTestSuite struct {
+	suite.Suite
+	err managerError
+}
+
+func (s *managerErrorIsTestSuite) SetupTest() {
+	s.err = nil
+}
+
+func (s *managerErrorIsTestSuite) TestIs() {
+	target := errors.New(`expected error 2`)
+	s.err = append(s.err, errors.New(`expected error 1`), errors.Wrap(target, `registry "second"`))
+	s.True(errors.Is(&s.err, target))
+	s.True(errors.Is(errors.Wrap(&s.err, `sync failed`), target))
+}
+
+func (s *managerErrorIsTestSuite) TestIsNot() {
+	s.err = append(s.err, errors.New(`expected error 1`), errors.New(`expected error 2`))
+	s.False(errors.Is(&s.err, errors.New(`expected error 1`)))
+	s.False(errors.Is(&s.err, context.Canceled))
+}
+
+type managerErrorAsTestSuite struct {
+	suite.Suite
+	err managerError
+}
+
+func (s *managerErrorAsTestSuite) SetupTest() {
+	s.err = nil
+}
+
+func (s *managerErrorAsTestSuite) TestAs() {
+	first := &DeregistrationLimitError{Orphan: 2, Registered: 3}
+	second := &DeregistrationLimitError{Orphan: 3, Registered: 4}
+	s.err = append(s.err, errors.New(`expected error`), errors.Wrap(first, `registry "first"`), errors.Wrap(second, `registry "second"`))
+	var target *DeregistrationLimitError
+	s.True(errors.As(&s.err, &target))
+	s.Same(first, target)
+}
+
+func (s *managerErrorAsTestSuite) TestAsNot() {
+	s.err = append(s.err, errors.New(`expected error 1`), errors.New(`expected error 2`))
+	var target *DeregistrationLimitError
+	s.False(errors.As(&s.err, &target))
+	s.Nil(target)
+}
+
 // --- Mocks ---
 
-/// MockRegistry is an autogenerated mock type for the Registry type
+// MockMetrics is an autogenerated mock type for the Metrics type
+type MockMetrics struct {
+	mock.Mock
+}
+
+// RunFinished provides a mock function with given fields: duration, success
+func (_m *MockMetrics) RunFinished(duration time.Duration, success bool) {
+	_m.Called(duration, success)
+}
+
+// ServiceProcessed provides a mock function with given fields: registry, action
+func (_m *MockMetrics) ServiceProcessed(registry string, action Action) {
+	_m.Called(registry, action)
+}
+
+// SourceFetched provides a mock function with given fields: count
+func (_m *MockMetrics) SourceFetched(count int) {
+	_m.Called(count)
+}
+
+// / MockRegistry is an autogenerated mock type for the Registry type
 type MockRegistry struct {
 	mock.Mock
 }
@@ -250,6 +1029,97 @@ func (_m *MockRegistry) Register(ctx context.Context, service *Service) error {
 	return r0
 }
 
+// MockCheckIDGeneratorRegistry is a mock type for the Registry type, which implements CheckIDGenerator
+type MockCheckIDGeneratorRegistry struct {
+	MockRegistry
+}
+
+// DefaultCheckID provides a mock function with given fields: service, check
+func (_m *MockCheckIDGeneratorRegistry) DefaultCheckID(service *Service, check *Check) string {
+	ret := _m.Called(service, check)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(*Service, *Check) string); ok {
+		r0 = rf(service, check)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockRegistryGroup is an autogenerated mock type for the RegistryGroup type
+type MockRegistryGroup struct {
+	mock.Mock
+}
+
+// Deregister provides a mock function with given fields: ctx, service
+func (_m *MockRegistryGroup) Deregister(ctx context.Context, service *Service) error {
+	ret := _m.Called(ctx, service)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Service) error); ok {
+		r0 = rf(ctx, service)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Fetch provides a mock function with given fields: ctx
+func (_m *MockRegistryGroup) Fetch(ctx context.Context) (Services, error) {
+	ret := _m.Called(ctx)
+
+	var r0 Services
+	if rf, ok := ret.Get(0).(func(context.Context) Services); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Services)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, service
+func (_m *MockRegistryGroup) Register(ctx context.Context, service *Service) error {
+	ret := _m.Called(ctx, service)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *Service) error); ok {
+		r0 = rf(ctx, service)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Members provides a mock function with given fields:
+func (_m *MockRegistryGroup) Members() []RegistryMember {
+	ret := _m.Called()
+
+	var r0 []RegistryMember
+	if rf, ok := ret.Get(0).(func() []RegistryMember); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]RegistryMember)
+		}
+	}
+
+	return r0
+}
+
 // MockSource is an autogenerated mock type for the Source type
 type MockSource struct {
 	mock.Mock
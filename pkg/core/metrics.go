@@ -0,0 +1,18 @@
+package core
+
+import (
+	"time"
+)
+
+type (
+	// Metrics is a sink for Manager and Scheduler metrics. Implementation must be safe for concurrent use.
+	// SourceFetched is called by Manager with count of Services fetched from Source after Transformer list and Filter.
+	// ServiceProcessed is called by Manager for every ReportItem after Manager.Run.
+	// RunFinished is called by Scheduler after every Manager.Run with run duration and result.
+	// Run is successful, if Manager.Run returned no error and Report has no failures.
+	Metrics interface {
+		SourceFetched(count int)
+		ServiceProcessed(registry string, action Action)
+		RunFinished(duration time.Duration, success bool)
+	}
+)
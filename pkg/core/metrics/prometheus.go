@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = `pinchy`
+
+	resultSuccess = `success`
+	resultFailure = `failure`
+)
+
+type (
+	// DefaultRegistry is registry label value for services processed by single registry, which is not core.RegistryGroup
+	DefaultRegistry string
+
+	// Prometheus is implementation of core.Metrics interface, which exposes metrics with prometheus client
+	Prometheus struct {
+		runDuration     prometheus.Histogram
+		runs            *prometheus.CounterVec
+		lastSuccess     prometheus.Gauge
+		source          prometheus.Gauge
+		services        *prometheus.CounterVec
+		defaultRegistry DefaultRegistry
+		timeProvider    func() time.Time
+	}
+)
+
+// NewPrometheus provides Prometheus with all collectors registered in prometheus.Registerer.
+// DefaultRegistry is used as registry label value, when core.Manager reports services without registry member name
+func NewPrometheus(registerer prometheus.Registerer, defaultRegistry DefaultRegistry) (*Prometheus, error) {
+	p := &Prometheus{
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      `sync_duration_seconds`,
+			Help:      `Duration of sync runs`,
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+		}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      `sync_runs_total`,
+			Help:      `Count of sync runs by result`,
+		}, []string{`result`}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      `sync_last_success_timestamp_seconds`,
+			Help:      `Unix timestamp of last successful sync run`,
+		}),
+		source: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      `source_services`,
+			Help:      `Count of services fetched from source by last sync run`,
+		}),
+		services: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      `registry_services_total`,
+			Help:      `Count of services processed by registry and action`,
+		}, []string{`registry`, `action`}),
+		defaultRegistry: defaultRegistry,
+		timeProvider:    time.Now,
+	}
+	// pre-create labels, so counters are exported with zero values before first run
+	p.runs.WithLabelValues(resultSuccess)
+	p.runs.WithLabelValues(resultFailure)
+
+	for _, collector := range []prometheus.Collector{p.runDuration, p.runs, p.lastSuccess, p.source, p.services} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, errors.Wrap(err, `failed to register prometheus collector`)
+		}
+	}
+	return p, nil
+}
+
+// SourceFetched is implementation of core.Metrics interface
+func (p *Prometheus) SourceFetched(count int) {
+	p.source.Set(float64(count))
+}
+
+// ServiceProcessed is implementation of core.Metrics interface. Empty registry is replaced with DefaultRegistry
+func (p *Prometheus) ServiceProcessed(registry string, action core.Action) {
+	if registry == `` {
+		registry = string(p.defaultRegistry)
+	}
+	p.services.WithLabelValues(registry, string(action)).Inc()
+}
+
+// RunFinished is implementation of core.Metrics interface
+func (p *Prometheus) RunFinished(duration time.Duration, success bool) {
+	p.runDuration.Observe(duration.Seconds())
+	if !success {
+		p.runs.WithLabelValues(resultFailure).Inc()
+		return
+	}
+	p.runs.WithLabelValues(resultSuccess).Inc()
+	p.lastSuccess.Set(float64(p.timeProvider().Unix()))
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewPrometheus(t *testing.T) {
+	suite.Run(t, new(newPrometheusTestSuite))
+}
+
+func TestPrometheus_SourceFetched(t *testing.T) {
+	suite.Run(t, new(prometheusSourceFetchedTestSuite))
+}
+
+func TestPrometheus_ServiceProcessed(t *testing.T) {
+	suite.Run(t, new(prometheusServiceProcessedTestSuite))
+}
+
+func TestPrometheus_RunFinished(t *testing.T) {
+	suite.Run(t, new(prometheusRunFinishedTestSuite))
+}
+
+// --- Suites ---
+
+type newPrometheusTestSuite struct {
+	suite.Suite
+}
+
+func (s *newPrometheusTestSuite) TestNewPrometheus() {
+	registry := prometheus.NewPedanticRegistry()
+	p, err := NewPrometheus(registry, ``)
+	s.NoError(err)
+	s.Implements((*core.Metrics)(nil), p)
+
+	count, err := testutil.GatherAndCount(registry)
+	s.NoError(err)
+	s.Equal(5, count)
+}
+
+func (s *newPrometheusTestSuite) TestErrorRegister() {
+	registry := prometheus.NewPedanticRegistry()
+	_, err := NewPrometheus(registry, ``)
+	s.NoError(err)
+
+	p, err := NewPrometheus(registry, ``)
+	s.Nil(p)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to register prometheus collector`)
+}
+
+type prometheusSourceFetchedTestSuite struct {
+	suite.Suite
+}
+
+func (s *prometheusSourceFetchedTestSuite) TestSourceFetched() {
+	p, err := NewPrometheus(prometheus.NewPedanticRegistry(), ``)
+	s.NoError(err)
+	p.SourceFetched(10)
+	p.SourceFetched(5)
+	s.Equal(float64(5), testutil.ToFloat64(p.source))
+}
+
+type prometheusServiceProcessedTestSuite struct {
+	suite.Suite
+}
+
+func (s *prometheusServiceProcessedTestSuite) TestServiceProcessed() {
+	registry := prometheus.NewPedanticRegistry()
+	p, err := NewPrometheus(registry, ``)
+	s.NoError(err)
+	p.ServiceProcessed(`primary`, core.ActionRegistered)
+	p.ServiceProcessed(`primary`, core.ActionRegistered)
+	p.ServiceProcessed(``, core.ActionFailed)
+
+	expected := `
+# HELP pinchy_registry_services_total Count of services processed by registry and action
+# TYPE pinchy_registry_services_total counter
+pinchy_registry_services_total{action="failed",registry=""} 1
+pinchy_registry_services_total{action="registered",registry="primary"} 2
+`
+	s.NoError(testutil.GatherAndCompare(registry, strings.NewReader(expected), `pinchy_registry_services_total`))
+}
+
+func (s *prometheusServiceProcessedTestSuite) TestDefaultRegistry() {
+	registry := prometheus.NewPedanticRegistry()
+	p, err := NewPrometheus(registry, `consul-agent`)
+	s.NoError(err)
+	p.ServiceProcessed(``, core.ActionRegistered)
+	p.ServiceProcessed(`primary`, core.ActionRegistered)
+
+	expected := `
+# HELP pinchy_registry_services_total Count of services processed by registry and action
+# TYPE pinchy_registry_services_total counter
+pinchy_registry_services_total{action="registered",registry="consul-agent"} 1
+pinchy_registry_services_total{action="registered",registry="primary"} 1
+`
+	s.NoError(testutil.GatherAndCompare(registry, strings.NewReader(expected), `pinchy_registry_services_total`))
+}
+
+type prometheusRunFinishedTestSuite struct {
+	suite.Suite
+	registry *prometheus.Registry
+	metrics  *Prometheus
+}
+
+func (s *prometheusRunFinishedTestSuite) SetupTest() {
+	var err error
+	s.registry = prometheus.NewPedanticRegistry()
+	s.metrics, err = NewPrometheus(s.registry, ``)
+	s.NoError(err)
+	s.metrics.timeProvider = func() time.Time {
+		return time.Unix(1600000000, 0)
+	}
+}
+
+func (s *prometheusRunFinishedTestSuite) TestSuccess() {
+	s.metrics.RunFinished(time.Second, true)
+	s.Equal(float64(1), testutil.ToFloat64(s.metrics.runs.WithLabelValues(resultSuccess)))
+	s.Equal(float64(0), testutil.ToFloat64(s.metrics.runs.WithLabelValues(resultFailure)))
+	s.Equal(float64(1600000000), testutil.ToFloat64(s.metrics.lastSuccess))
+	s.Equal(1, testutil.CollectAndCount(s.metrics.runDuration))
+}
+
+func (s *prometheusRunFinishedTestSuite) TestFailure() {
+	s.metrics.RunFinished(time.Second, false)
+	s.Equal(float64(0), testutil.ToFloat64(s.metrics.runs.WithLabelValues(resultSuccess)))
+	s.Equal(float64(1), testutil.ToFloat64(s.metrics.runs.WithLabelValues(resultFailure)))
+	s.Equal(float64(0), testutil.ToFloat64(s.metrics.lastSuccess))
+}
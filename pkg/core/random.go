@@ -0,0 +1,43 @@
+package core
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type (
+	// Random is a source of random numbers for jitter. Implementation must be safe for concurrent use
+	Random interface {
+		Int63n(n int64) int64
+		Float64() float64
+	}
+
+	// lockedRandom is Random implementation, which guards own rand.Rand with mutex
+	lockedRandom struct {
+		rand *rand.Rand
+		mu   sync.Mutex
+	}
+)
+
+// NewRandom provides Random seeded with current time, so every process draws own sequence of random numbers.
+// Global math/rand source is not used, because it is seeded with the same value in every process until rand.Seed call
+func NewRandom() Random {
+	return &lockedRandom{
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Int63n is implementation of Random interface
+func (r *lockedRandom) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Int63n(n)
+}
+
+// Float64 is implementation of Random interface
+func (r *lockedRandom) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64()
+}
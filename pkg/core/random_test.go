@@ -0,0 +1,93 @@
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewRandom(t *testing.T) {
+	suite.Run(t, new(newRandomTestSuite))
+}
+
+// --- Suites ---
+
+type newRandomTestSuite struct {
+	suite.Suite
+}
+
+func (s *newRandomTestSuite) TestRange() {
+	random := NewRandom()
+	for i := 0; i < 100; i++ {
+		value := random.Int63n(10)
+		s.GreaterOrEqual(value, int64(0))
+		s.Less(value, int64(10))
+		fraction := random.Float64()
+		s.GreaterOrEqual(fraction, float64(0))
+		s.Less(fraction, float64(1))
+	}
+}
+
+func (s *newRandomTestSuite) TestOwnSequence() {
+	first, second := NewRandom(), NewRandom()
+	same := true
+	for i := 0; i < 10; i++ {
+		same = same && first.Int63n(1<<62) == second.Int63n(1<<62)
+	}
+	s.False(same)
+}
+
+func (s *newRandomTestSuite) TestConcurrent() {
+	random := NewRandom()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				random.Int63n(100)
+				random.Float64()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// --- Mocks ---
+
+// MockRandom is an autogenerated mock type for the Random type
+type MockRandom struct {
+	mock.Mock
+}
+
+// Float64 provides a mock function with given fields:
+func (_m *MockRandom) Float64() float64 {
+	ret := _m.Called()
+
+	var r0 float64
+	if rf, ok := ret.Get(0).(func() float64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	return r0
+}
+
+// Int63n provides a mock function with given fields: n
+func (_m *MockRandom) Int63n(n int64) int64 {
+	ret := _m.Called(n)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(int64) int64); ok {
+		r0 = rf(n)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	return r0
+}
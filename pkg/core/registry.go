@@ -12,4 +12,24 @@ type (
 		Register(ctx context.Context, service *Service) error
 		Deregister(ctx context.Context, service *Service) error
 	}
+
+	// RegistryGroup is optional interface for Registry implementations, which write same Services to several registries.
+	// Manager computes orphans, applies changes and reports errors for every RegistryMember separately.
+	RegistryGroup interface {
+		Registry
+		Members() []RegistryMember
+	}
+
+	// CheckIDGenerator is optional interface for Registry implementations, which generate ID for checks without ID.
+	// Manager compares incoming checks without ID with registered checks by DefaultCheckID, so check registered
+	// under another ID is updated. DefaultCheckID must return empty string, if Registry has no default ID for check.
+	CheckIDGenerator interface {
+		DefaultCheckID(service *Service, check *Check) string
+	}
+
+	// RegistryMember is named Registry, which is a part of RegistryGroup
+	RegistryMember struct {
+		Name     string
+		Registry Registry
+	}
 )
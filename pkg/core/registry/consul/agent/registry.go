@@ -7,6 +7,7 @@ import (
 	"github.com/agrea/ptr"
 	"github.com/hashicorp/consul/api"
 	"github.com/insidieux/pinchy/pkg/core"
+	coreConsul "github.com/insidieux/pinchy/pkg/core/consul"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul"
 	"github.com/pkg/errors"
 	"github.com/thoas/go-funk"
@@ -15,35 +16,78 @@ import (
 type (
 	// Agent interface provide common function for work with Consul HTTP API
 	Agent interface {
-		ServicesWithFilter(filter string) (map[string]*api.AgentService, error)
-		ServiceRegister(service *api.AgentServiceRegistration) error
-		ServiceDeregister(serviceID string) error
+		ServicesWithFilterOpts(filter string, q *api.QueryOptions) (map[string]*api.AgentService, error)
+		ServiceRegisterOpts(service *api.AgentServiceRegistration, opts api.ServiceRegisterOpts) error
+		ServiceDeregisterOpts(serviceID string, q *api.QueryOptions) error
+	}
+
+	// Raw interface provide raw access to Consul HTTP API.
+	// Used for agent checks, because api.HealthCheckDefinition does not contain gRPC and TTL check fields
+	Raw interface {
+		Query(endpoint string, out interface{}, q *api.QueryOptions) (*api.QueryMeta, error)
 	}
 
 	// Registry is implementation of core.Registry interface
 	Registry struct {
 		agent  Agent
+		raw    Raw
 		logger core.LoggerInterface
 		tag    consul.Tag
+		scope  coreConsul.Scope
+	}
+
+	// agentCheck is a part of /v1/agent/checks response item with full check definition
+	agentCheck struct {
+		CheckID    string
+		Name       string
+		ServiceID  string
+		Definition struct {
+			HTTP                           string
+			Method                         string
+			TCP                            string
+			GRPC                           string
+			GRPCUseTLS                     bool
+			TLSSkipVerify                  bool
+			TTL                            string
+			Interval                       string
+			Timeout                        string
+			DeregisterCriticalServiceAfter string
+		}
 	}
 )
 
 // NewRegistry provide Registry as core.Registry implementation
-func NewRegistry(agent Agent, tag consul.Tag) *Registry {
+func NewRegistry(agent Agent, raw Raw, tag consul.Tag, scope coreConsul.Scope) *Registry {
 	return &Registry{
 		agent: agent,
+		raw:   raw,
 		tag:   tag,
+		scope: scope,
 	}
 }
 
 // Fetch make request for Agent.Services and try to cast result to core.Services
-func (r *Registry) Fetch(_ context.Context) (core.Services, error) {
+// Common consul.Tag is excluded from fetched service tags, service checks are fetched with Raw.Query.
+// Requests are bound to coreConsul.Scope
+func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
 	r.logger.Infoln(`Send services filter consul agent request`)
-	registered, err := r.agent.ServicesWithFilter(fmt.Sprintf(`("%s" in Tags)`, r.tag))
+	registered, err := r.agent.ServicesWithFilterOpts(fmt.Sprintf(`("%s" in Tags)`, r.tag), r.scope.QueryOptions(ctx))
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to fetch registered services info`)
 	}
 
+	r.logger.Infoln(`Send checks consul agent request`)
+	checks := make(map[string]*agentCheck)
+	if _, err := r.raw.Query(`/v1/agent/checks`, &checks, r.scope.QueryOptions(ctx)); err != nil {
+		return nil, errors.Wrap(err, `failed to fetch registered checks info`)
+	}
+	serviceChecks := make(map[string]core.Checks)
+	for _, check := range checks {
+		if check.ServiceID != `` {
+			serviceChecks[check.ServiceID] = append(serviceChecks[check.ServiceID], check.toCore())
+		}
+	}
+
 	r.logger.Infoln(`Prepare registered services list`)
 	result := make([]*core.Service, 0)
 	for _, item := range registered {
@@ -55,18 +99,21 @@ func (r *Registry) Fetch(_ context.Context) (core.Services, error) {
 		if item.Port != 0 {
 			service.Port = ptr.Int(item.Port)
 		}
-		if len(item.Tags) > 0 {
-			service.Tags = &item.Tags
+		if tags := r.tag.Exclude(item.Tags); len(tags) > 0 {
+			service.Tags = &tags
 		}
 		if len(item.Meta) > 0 {
 			service.Meta = &item.Meta
 		}
+		if checks, ok := serviceChecks[item.ID]; ok {
+			service.Checks = &checks
+		}
 		result = append(result, service)
 	}
 	return result, nil
 }
 
-// Deregister make request for Agent.ServiceDeregister by core.Service RegistrationID
+// Deregister make request for Agent.ServiceDeregisterOpts by core.Service RegistrationID bound to coreConsul.Scope
 func (r *Registry) Deregister(ctx context.Context, service *core.Service) error {
 	r.logger.Infof(`Validate service "%s"`, service.RegistrationID())
 	if err := service.Validate(ctx); err != nil {
@@ -74,13 +121,13 @@ func (r *Registry) Deregister(ctx context.Context, service *core.Service) error
 	}
 
 	r.logger.Infof(`Send service deregister consul agent request for service "%s"`, service.RegistrationID())
-	if err := r.agent.ServiceDeregister(service.RegistrationID()); err != nil {
+	if err := r.agent.ServiceDeregisterOpts(service.RegistrationID(), r.scope.QueryOptions(ctx)); err != nil {
 		return errors.Wrapf(err, `failed deregister service by service id "%s"`, service.RegistrationID())
 	}
 	return nil
 }
 
-// Register make request for Agent.ServiceRegister for core.Service
+// Register make request for Agent.ServiceRegisterOpts for core.Service in namespace and partition of coreConsul.Scope
 func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 	r.logger.Infof(`Validate service "%s"`, service.RegistrationID())
 	if err := service.Validate(ctx); err != nil {
@@ -88,9 +135,11 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 	}
 
 	asr := &api.AgentServiceRegistration{
-		Kind:    api.ServiceKindTypical,
-		Name:    service.Name,
-		Address: service.Address,
+		Kind:      api.ServiceKindTypical,
+		Name:      service.Name,
+		Address:   service.Address,
+		Namespace: r.scope.Namespace,
+		Partition: r.scope.Partition,
 	}
 	if service.ID != nil {
 		asr.ID = *service.ID
@@ -106,9 +155,14 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 	if service.Meta != nil {
 		asr.Meta = *service.Meta
 	}
+	if service.Checks != nil {
+		for _, check := range *service.Checks {
+			asr.Checks = append(asr.Checks, toAgentServiceCheck(check))
+		}
+	}
 
 	r.logger.Infof(`Send service register consul agent request for service "%s"`, service.RegistrationID())
-	if err := r.agent.ServiceRegister(asr); err != nil {
+	if err := r.agent.ServiceRegisterOpts(asr, api.ServiceRegisterOpts{}.WithContext(ctx)); err != nil {
 		return errors.Wrapf(err, `failed register service by service id "%s"`, service.RegistrationID())
 	}
 	return nil
@@ -118,3 +172,64 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 func (r *Registry) WithLogger(logger core.LoggerInterface) {
 	r.logger = logger
 }
+
+// IsRetryable is implementation of core.RetryClassifier interface
+func (r *Registry) IsRetryable(err error) bool {
+	return consul.IsRetryable(err)
+}
+
+// toCore convert agentCheck to core.Check
+func (c *agentCheck) toCore() *core.Check {
+	check := &core.Check{
+		ID:            ptr.String(c.CheckID),
+		Name:          c.Name,
+		GRPCUseTLS:    c.Definition.GRPCUseTLS,
+		TLSSkipVerify: c.Definition.TLSSkipVerify,
+	}
+	for _, field := range []struct {
+		target **string
+		value  string
+	}{
+		{&check.HTTP, c.Definition.HTTP},
+		{&check.Method, c.Definition.Method},
+		{&check.TCP, c.Definition.TCP},
+		{&check.GRPC, c.Definition.GRPC},
+		{&check.TTL, c.Definition.TTL},
+		{&check.Interval, c.Definition.Interval},
+		{&check.Timeout, c.Definition.Timeout},
+		{&check.DeregisterCriticalServiceAfter, c.Definition.DeregisterCriticalServiceAfter},
+	} {
+		if field.value != `` && field.value != `0s` {
+			*field.target = ptr.String(field.value)
+		}
+	}
+	return check
+}
+
+// toAgentServiceCheck convert core.Check to api.AgentServiceCheck
+func toAgentServiceCheck(check *core.Check) *api.AgentServiceCheck {
+	asc := &api.AgentServiceCheck{
+		Name:          check.Name,
+		GRPCUseTLS:    check.GRPCUseTLS,
+		TLSSkipVerify: check.TLSSkipVerify,
+	}
+	for _, field := range []struct {
+		target *string
+		value  *string
+	}{
+		{&asc.CheckID, check.ID},
+		{&asc.HTTP, check.HTTP},
+		{&asc.Method, check.Method},
+		{&asc.TCP, check.TCP},
+		{&asc.GRPC, check.GRPC},
+		{&asc.TTL, check.TTL},
+		{&asc.Interval, check.Interval},
+		{&asc.Timeout, check.Timeout},
+		{&asc.DeregisterCriticalServiceAfter, check.DeregisterCriticalServiceAfter},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	return asc
+}
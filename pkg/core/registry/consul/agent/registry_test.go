@@ -2,11 +2,13 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/agrea/ptr"
 	"github.com/hashicorp/consul/api"
 	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/consul"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/mock"
@@ -31,6 +33,10 @@ func TestRegistry_Register(t *testing.T) {
 	suite.Run(t, new(registryRegisterTestSuite))
 }
 
+func TestRegistry_IsRetryable(t *testing.T) {
+	suite.Run(t, new(registryIsRetryableTestSuite))
+}
+
 func TestRegistry_WithLogger(t *testing.T) {
 	suite.Run(t, new(registryWithLoggerTestSuite))
 }
@@ -42,25 +48,27 @@ type newRegistryTestSuite struct {
 }
 
 func (s *newRegistryTestSuite) TestNewRegistry() {
-	got := NewRegistry(nil, ``)
+	got := NewRegistry(nil, nil, ``, consul.Scope{})
 	s.Implements((*core.Registry)(nil), got)
-	s.Equal(&Registry{nil, nil, ``}, got)
+	s.Equal(&Registry{nil, nil, nil, ``, consul.Scope{}}, got)
 }
 
 type registryFetchTestSuite struct {
 	suite.Suite
 	agent    *MockAgent
+	raw      *MockRaw
 	registry *Registry
 }
 
 func (s *registryFetchTestSuite) SetupTest() {
 	s.agent = new(MockAgent)
-	s.registry = NewRegistry(s.agent, `test`)
+	s.raw = new(MockRaw)
+	s.registry = NewRegistry(s.agent, s.raw, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 }
 
 func (s *registryFetchTestSuite) TestErrorAgentFetch() {
-	s.agent.On(`ServicesWithFilter`, mock.Anything).Return(nil, errors.New(`expected error`))
+	s.agent.On(`ServicesWithFilterOpts`, mock.Anything, mock.Anything).Return(nil, errors.New(`expected error`))
 
 	s.registry.agent = s.agent
 	services, err := s.registry.Fetch(context.Background())
@@ -68,12 +76,35 @@ func (s *registryFetchTestSuite) TestErrorAgentFetch() {
 	s.EqualError(err, `failed to fetch registered services info: expected error`)
 }
 
+func (s *registryFetchTestSuite) TestErrorRawQuery() {
+	s.agent.On(`ServicesWithFilterOpts`, mock.Anything, mock.Anything).Return(map[string]*api.AgentService{}, nil)
+	s.raw.On(`Query`, `/v1/agent/checks`, mock.Anything, mock.Anything).Return(nil, errors.New(`expected error`))
+
+	services, err := s.registry.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch registered checks info: expected error`)
+}
+
 func (s *registryFetchTestSuite) TestSuccess() {
-	s.agent.On(`ServicesWithFilter`, mock.Anything).Return(map[string]*api.AgentService{
+	s.raw.On(`Query`, `/v1/agent/checks`, mock.Anything, mock.Anything).Return(&api.QueryMeta{}, nil).Run(func(args mock.Arguments) {
+		response := `{
+			"service:id": {
+				"CheckID": "service:id",
+				"Name": "http",
+				"ServiceID": "id",
+				"Definition": {"HTTP": "http://127.0.0.1/health", "Interval": "10s", "Timeout": "0s"}
+			},
+			"node": {"CheckID": "serfHealth", "Name": "Serf Health Status"}
+		}`
+		if err := json.Unmarshal([]byte(response), args.Get(1)); err != nil {
+			panic(err)
+		}
+	})
+	s.agent.On(`ServicesWithFilterOpts`, mock.Anything, mock.Anything).Return(map[string]*api.AgentService{
 		`name`: {
 			ID:      `id`,
 			Service: `name`,
-			Tags:    []string{`tags`},
+			Tags:    []string{`test`, `tags`},
 			Meta:    map[string]string{`key`: `value`},
 			Port:    80,
 			Address: `127.0.0.1`,
@@ -93,11 +124,32 @@ func (s *registryFetchTestSuite) TestSuccess() {
 			Tags:    &expectedTags,
 			Meta:    &expectedMeta,
 			Port:    ptr.Int(80),
+			Checks: &core.Checks{{
+				ID:       ptr.String(`service:id`),
+				Name:     `http`,
+				HTTP:     ptr.String(`http://127.0.0.1/health`),
+				Interval: ptr.String(`10s`),
+			}},
 		},
 	}, fetchedServices)
 
 }
 
+func (s *registryFetchTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`, Partition: `part`}
+	inScope := mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Datacenter == `dc-2` && opts.Namespace == `team` && opts.Partition == `part`
+	})
+	s.agent.On(`ServicesWithFilterOpts`, `("test" in Tags)`, inScope).Return(map[string]*api.AgentService{}, nil)
+	s.raw.On(`Query`, `/v1/agent/checks`, mock.Anything, inScope).Return(&api.QueryMeta{}, nil)
+
+	fetchedServices, err := s.registry.Fetch(context.Background())
+	s.NoError(err)
+	s.Empty(fetchedServices)
+	s.agent.AssertExpectations(s.T())
+	s.raw.AssertExpectations(s.T())
+}
+
 type registryDeregisterTestSuite struct {
 	suite.Suite
 	agent    *MockAgent
@@ -107,7 +159,7 @@ type registryDeregisterTestSuite struct {
 
 func (s *registryDeregisterTestSuite) SetupTest() {
 	s.agent = new(MockAgent)
-	s.registry = NewRegistry(s.agent, `test`)
+	s.registry = NewRegistry(s.agent, nil, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 	s.service = &core.Service{
 		Name:    `service`,
@@ -124,19 +176,30 @@ func (s *registryDeregisterTestSuite) TestErrorServiceValidation() {
 }
 
 func (s *registryDeregisterTestSuite) TestErrorAgentDeregister() {
-	s.agent.On(`ServiceDeregister`, `service`).Return(errors.New(`expected error`))
+	s.agent.On(`ServiceDeregisterOpts`, `service`, mock.Anything).Return(errors.New(`expected error`))
 
 	err := s.registry.Deregister(context.Background(), s.service)
 	s.EqualError(err, `failed deregister service by service id "service": expected error`)
 }
 
 func (s *registryDeregisterTestSuite) TestSuccess() {
-	s.agent.On(`ServiceDeregister`, `service`).Return(nil)
+	s.agent.On(`ServiceDeregisterOpts`, `service`, mock.Anything).Return(nil)
 
 	err := s.registry.Deregister(context.Background(), s.service)
 	s.NoError(err)
 }
 
+func (s *registryDeregisterTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`, Partition: `part`}
+	s.agent.On(`ServiceDeregisterOpts`, `service`, mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Datacenter == `dc-2` && opts.Namespace == `team` && opts.Partition == `part`
+	})).Return(nil)
+
+	err := s.registry.Deregister(context.Background(), s.service)
+	s.NoError(err)
+	s.agent.AssertExpectations(s.T())
+}
+
 type registryRegisterTestSuite struct {
 	suite.Suite
 	agent    *MockAgent
@@ -145,7 +208,7 @@ type registryRegisterTestSuite struct {
 
 func (s *registryRegisterTestSuite) SetupTest() {
 	s.agent = new(MockAgent)
-	s.registry = NewRegistry(s.agent, `test`)
+	s.registry = NewRegistry(s.agent, nil, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 }
 
@@ -158,7 +221,7 @@ func (s *registryRegisterTestSuite) TestErrorServiceValidation() {
 }
 
 func (s *registryRegisterTestSuite) TestErrorAgentRegister() {
-	s.agent.On(`ServiceRegister`, mock.Anything).Return(errors.New(`expected error`))
+	s.agent.On(`ServiceRegisterOpts`, mock.Anything, mock.Anything).Return(errors.New(`expected error`))
 
 	err := s.registry.Register(context.Background(), &core.Service{
 		Name:    `name`,
@@ -168,7 +231,7 @@ func (s *registryRegisterTestSuite) TestErrorAgentRegister() {
 }
 
 func (s *registryRegisterTestSuite) TestSuccess() {
-	s.agent.On(`ServiceRegister`, mock.Anything).Return(nil)
+	s.agent.On(`ServiceRegisterOpts`, mock.Anything, mock.Anything).Return(nil)
 
 	expectedTags := []string{`tags`}
 	expectedMeta := map[string]string{`key`: `value`}
@@ -183,13 +246,58 @@ func (s *registryRegisterTestSuite) TestSuccess() {
 	s.NoError(err)
 }
 
+func (s *registryRegisterTestSuite) TestChecks() {
+	s.agent.On(`ServiceRegisterOpts`, mock.Anything, mock.Anything).Return(nil)
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Checks: &core.Checks{
+			{Name: `ttl`, ID: ptr.String(`ttl-id`), TTL: ptr.String(`30s`)},
+			{Name: `grpc`, GRPC: ptr.String(`127.0.0.1:9090`), GRPCUseTLS: true, Interval: ptr.String(`10s`)},
+		},
+	})
+	s.NoError(err)
+	s.agent.AssertCalled(s.T(), `ServiceRegisterOpts`, mock.MatchedBy(func(asr *api.AgentServiceRegistration) bool {
+		return s.Equal(api.AgentServiceChecks{
+			{CheckID: `ttl-id`, Name: `ttl`, TTL: `30s`},
+			{Name: `grpc`, GRPC: `127.0.0.1:9090`, GRPCUseTLS: true, Interval: `10s`},
+		}, asr.Checks)
+	}), mock.Anything)
+}
+
+func (s *registryRegisterTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`, Partition: `part`}
+	s.agent.On(`ServiceRegisterOpts`, mock.MatchedBy(func(asr *api.AgentServiceRegistration) bool {
+		return asr.Namespace == `team` && asr.Partition == `part`
+	}), mock.Anything).Return(nil)
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+	})
+	s.NoError(err)
+	s.agent.AssertExpectations(s.T())
+}
+
+type registryIsRetryableTestSuite struct {
+	suite.Suite
+}
+
+func (s *registryIsRetryableTestSuite) TestIsRetryable() {
+	r := NewRegistry(nil, nil, ``, consul.Scope{})
+	s.Implements((*core.RetryClassifier)(nil), r)
+	s.True(r.IsRetryable(errors.New(`Unexpected response code: 500 ()`)))
+	s.False(r.IsRetryable(errors.New(`Unexpected response code: 400 ()`)))
+}
+
 type registryWithLoggerTestSuite struct {
 	suite.Suite
 }
 
 func (s *registryWithLoggerTestSuite) TestWithLogger() {
 	logger, _ := test.NewNullLogger()
-	src := NewRegistry(nil, ``)
+	src := NewRegistry(nil, nil, ``, consul.Scope{})
 	src.WithLogger(logger)
 }
 
@@ -200,13 +308,13 @@ type MockAgent struct {
 	mock.Mock
 }
 
-// ServiceDeregister provides a mock function with given fields: serviceID
-func (_m *MockAgent) ServiceDeregister(serviceID string) error {
-	ret := _m.Called(serviceID)
+// ServiceDeregisterOpts provides a mock function with given fields: serviceID, q
+func (_m *MockAgent) ServiceDeregisterOpts(serviceID string, q *api.QueryOptions) error {
+	ret := _m.Called(serviceID, q)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(serviceID)
+	if rf, ok := ret.Get(0).(func(string, *api.QueryOptions) error); ok {
+		r0 = rf(serviceID, q)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -214,13 +322,13 @@ func (_m *MockAgent) ServiceDeregister(serviceID string) error {
 	return r0
 }
 
-// ServiceRegister provides a mock function with given fields: service
-func (_m *MockAgent) ServiceRegister(service *api.AgentServiceRegistration) error {
-	ret := _m.Called(service)
+// ServiceRegisterOpts provides a mock function with given fields: service, opts
+func (_m *MockAgent) ServiceRegisterOpts(service *api.AgentServiceRegistration, opts api.ServiceRegisterOpts) error {
+	ret := _m.Called(service, opts)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*api.AgentServiceRegistration) error); ok {
-		r0 = rf(service)
+	if rf, ok := ret.Get(0).(func(*api.AgentServiceRegistration, api.ServiceRegisterOpts) error); ok {
+		r0 = rf(service, opts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -228,13 +336,13 @@ func (_m *MockAgent) ServiceRegister(service *api.AgentServiceRegistration) erro
 	return r0
 }
 
-// ServicesWithFilter provides a mock function with given fields: filter
-func (_m *MockAgent) ServicesWithFilter(filter string) (map[string]*api.AgentService, error) {
-	ret := _m.Called()
+// ServicesWithFilterOpts provides a mock function with given fields: filter, q
+func (_m *MockAgent) ServicesWithFilterOpts(filter string, q *api.QueryOptions) (map[string]*api.AgentService, error) {
+	ret := _m.Called(filter, q)
 
 	var r0 map[string]*api.AgentService
-	if rf, ok := ret.Get(0).(func(string) map[string]*api.AgentService); ok {
-		r0 = rf(filter)
+	if rf, ok := ret.Get(0).(func(string, *api.QueryOptions) map[string]*api.AgentService); ok {
+		r0 = rf(filter, q)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string]*api.AgentService)
@@ -242,8 +350,36 @@ func (_m *MockAgent) ServicesWithFilter(filter string) (map[string]*api.AgentSer
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(filter)
+	if rf, ok := ret.Get(1).(func(string, *api.QueryOptions) error); ok {
+		r1 = rf(filter, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRaw is an autogenerated mock type for the Raw type
+type MockRaw struct {
+	mock.Mock
+}
+
+// Query provides a mock function with given fields: endpoint, out, q
+func (_m *MockRaw) Query(endpoint string, out interface{}, q *api.QueryOptions) (*api.QueryMeta, error) {
+	ret := _m.Called(endpoint, out, q)
+
+	var r0 *api.QueryMeta
+	if rf, ok := ret.Get(0).(func(string, interface{}, *api.QueryOptions) *api.QueryMeta); ok {
+		r0 = rf(endpoint, out, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.QueryMeta)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, interface{}, *api.QueryOptions) error); ok {
+		r1 = rf(endpoint, out, q)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -3,10 +3,12 @@ package catalog
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/agrea/ptr"
 	"github.com/hashicorp/consul/api"
 	"github.com/insidieux/pinchy/pkg/core"
+	coreConsul "github.com/insidieux/pinchy/pkg/core/consul"
 	"github.com/insidieux/pinchy/pkg/core/registry/consul"
 	"github.com/pkg/errors"
 	"github.com/thoas/go-funk"
@@ -21,29 +23,37 @@ type (
 		Register(*api.CatalogRegistration, *api.WriteOptions) (*api.WriteMeta, error)
 	}
 
+	// Health interface provide common function for work with Consul HTTP API /v1/health
+	Health interface {
+		Checks(string, *api.QueryOptions) (api.HealthChecks, *api.QueryMeta, error)
+	}
+
 	// Registry is implementation of core.Registry interface
 	Registry struct {
 		catalog Catalog
+		health  Health
 		logger  core.LoggerInterface
 		tag     consul.Tag
+		scope   coreConsul.Scope
 	}
 )
 
 // NewRegistry provide Registry as core.Registry implementation
-func NewRegistry(catalog Catalog, tag consul.Tag) *Registry {
+func NewRegistry(catalog Catalog, health Health, tag consul.Tag, scope coreConsul.Scope) *Registry {
 	return &Registry{
 		catalog: catalog,
+		health:  health,
 		tag:     tag,
+		scope:   scope,
 	}
 }
 
-// Fetch make request for Catalog.Services plus Catalog.Service and try to cast result to core.Services
+// Fetch make request for Catalog.Services plus Catalog.Service and Health.Checks and try to cast result to core.Services
+// Common consul.Tag is excluded from fetched service tags. All requests are bound to coreConsul.Scope
 func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
 	r.logger.Infoln(`Fetch registered services from catalog`)
-	query := &api.QueryOptions{
-		Filter: fmt.Sprintf(`("%s" in Tags)`, r.tag),
-	}
-	query = query.WithContext(ctx)
+	query := r.scope.QueryOptions(ctx)
+	query.Filter = fmt.Sprintf(`("%s" in Tags)`, r.tag)
 	names, _, err := r.catalog.Services(query)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to fetch registered services info`)
@@ -52,12 +62,25 @@ func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
 	r.logger.Infoln(`Prepare registered services list`)
 	result := make([]*core.Service, 0)
 	for name := range names {
-		opts := &api.QueryOptions{}
-		opts = opts.WithContext(ctx)
+		opts := r.scope.QueryOptions(ctx)
 		items, _, err := r.catalog.Service(name, string(r.tag), opts)
 		if err != nil {
 			return nil, errors.Wrap(err, `failed to fetch registered service info`)
 		}
+		checks, _, err := r.health.Checks(name, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to fetch registered service checks info`)
+		}
+		serviceChecks := make(map[[2]string]core.Checks)
+		for _, check := range checks {
+			key := [2]string{check.Node, check.ServiceID}
+			coreCheck := toCoreCheck(check)
+			// default check id is not returned, so check is equal to incoming check without id
+			if check.CheckID == defaultCheckID(check.ServiceID, check.Name) {
+				coreCheck.ID = nil
+			}
+			serviceChecks[key] = append(serviceChecks[key], coreCheck)
+		}
 
 		for _, item := range items {
 			service := &core.Service{
@@ -68,8 +91,8 @@ func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
 			if item.ServicePort != 0 {
 				service.Port = ptr.Int(item.ServicePort)
 			}
-			if len(item.ServiceTags) > 0 {
-				service.Tags = &item.ServiceTags
+			if tags := r.tag.Exclude(item.ServiceTags); len(tags) > 0 {
+				service.Tags = &tags
 			}
 			if len(item.ServiceMeta) > 0 {
 				service.Meta = &item.ServiceMeta
@@ -80,6 +103,9 @@ func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
 				Datacenter: ptr.String(item.Datacenter),
 				NodeMeta:   &item.NodeMeta,
 			}
+			if checks, ok := serviceChecks[[2]string{item.Node, item.ServiceID}]; ok {
+				service.Checks = &checks
+			}
 			result = append(result, service)
 		}
 
@@ -95,17 +121,16 @@ func (r *Registry) Deregister(ctx context.Context, service *core.Service) error
 	}
 
 	r.logger.Infof(`Send service deregister catalog request for service "%s"`, service.RegistrationID())
-	opts := &api.WriteOptions{}
-	opts = opts.WithContext(ctx)
-	_, err := r.catalog.Deregister(
-		&api.CatalogDeregistration{
-			ServiceID: service.RegistrationID(),
-			Node:      service.Node.Node,
-		},
-		opts,
-	)
-
-	if err != nil {
+	cd := &api.CatalogDeregistration{
+		ServiceID:  service.RegistrationID(),
+		Node:       service.Node.Node,
+		Datacenter: r.scope.Datacenter,
+	}
+	if service.Node.Datacenter != nil {
+		cd.Datacenter = *service.Node.Datacenter
+	}
+	opts := r.scope.WriteOptions(ctx)
+	if _, err := r.catalog.Deregister(cd, opts); err != nil {
 		return errors.Wrapf(err, `failed deregister service by service id "%s"`, service.RegistrationID())
 	}
 	return nil
@@ -119,8 +144,9 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 	}
 
 	cr := &api.CatalogRegistration{
-		Node:    service.Node.Node,
-		Address: service.Node.Address,
+		Node:       service.Node.Node,
+		Address:    service.Node.Address,
+		Datacenter: r.scope.Datacenter,
 		Service: &api.AgentService{
 			Kind:              api.ServiceKindTypical,
 			Service:           service.Name,
@@ -129,6 +155,12 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 			EnableTagOverride: true,
 		},
 	}
+	if service.Node.Datacenter != nil {
+		cr.Datacenter = *service.Node.Datacenter
+	}
+	if service.Node.NodeMeta != nil {
+		cr.NodeMeta = *service.Node.NodeMeta
+	}
 	if service.ID != nil {
 		cr.Service.ID = *service.ID
 	}
@@ -143,13 +175,45 @@ func (r *Registry) Register(ctx context.Context, service *core.Service) error {
 	if service.Meta != nil {
 		cr.Service.Meta = *service.Meta
 	}
+	if service.Checks != nil {
+		for _, check := range *service.Checks {
+			cr.Checks = append(cr.Checks, toHealthCheck(service, check))
+		}
+	}
 
 	r.logger.Infof(`Send service register catalog request for service "%s"`, service.RegistrationID())
-	opts := &api.WriteOptions{}
-	opts = opts.WithContext(ctx)
+	opts := r.scope.WriteOptions(ctx)
 	if _, err := r.catalog.Register(cr, opts); err != nil {
 		return errors.Wrapf(err, `failed register service by service id "%s"`, service.RegistrationID())
 	}
+	return r.deregisterStaleChecks(ctx, service, cr)
+}
+
+// deregisterStaleChecks removes checks of service on node, which are not in registration anymore, because
+// Catalog.Register adds and updates checks, but never removes them
+func (r *Registry) deregisterStaleChecks(ctx context.Context, service *core.Service, cr *api.CatalogRegistration) error {
+	registered := make(map[string]bool, len(cr.Checks))
+	for _, check := range cr.Checks {
+		registered[check.CheckID] = true
+	}
+	checks, _, err := r.health.Checks(service.Name, r.scope.QueryOptions(ctx))
+	if err != nil {
+		return errors.Wrapf(err, `failed to fetch checks of service by service id "%s"`, service.RegistrationID())
+	}
+	for _, check := range checks {
+		if check.Node != cr.Node || check.ServiceID != service.RegistrationID() || registered[check.CheckID] {
+			continue
+		}
+		r.logger.Infof(`Send check "%s" deregister catalog request for service "%s"`, check.CheckID, service.RegistrationID())
+		cd := &api.CatalogDeregistration{
+			Node:       cr.Node,
+			CheckID:    check.CheckID,
+			Datacenter: cr.Datacenter,
+		}
+		if _, err := r.catalog.Deregister(cd, r.scope.WriteOptions(ctx)); err != nil {
+			return errors.Wrapf(err, `failed deregister check "%s" by service id "%s"`, check.CheckID, service.RegistrationID())
+		}
+	}
 	return nil
 }
 
@@ -169,5 +233,97 @@ func (r *Registry) validateService(_ context.Context, service *core.Service) err
 	if service.Node.Address == `` {
 		return errors.Errorf(`service field "Node.Address" is required and cannot be empty`)
 	}
+	if service.Checks == nil {
+		return nil
+	}
+	for _, check := range *service.Checks {
+		if t := check.Type(); t != core.CheckTypeHTTP && t != core.CheckTypeTCP {
+			return errors.Errorf(
+				`check "%s" has unsupported type "%s", only http and tcp checks are supported by catalog, `+
+					`because catalog checks are executed by external tools, use consul-agent registry for grpc and ttl checks`,
+				check.Name,
+				t,
+			)
+		}
+	}
 	return nil
 }
+
+// IsRetryable is implementation of core.RetryClassifier interface
+func (r *Registry) IsRetryable(err error) bool {
+	return consul.IsRetryable(err)
+}
+
+// toCoreCheck convert api.HealthCheck to core.Check
+func toCoreCheck(check *api.HealthCheck) *core.Check {
+	result := &core.Check{
+		ID:            ptr.String(check.CheckID),
+		Name:          check.Name,
+		TLSSkipVerify: check.Definition.TLSSkipVerify,
+	}
+	if check.Definition.HTTP != `` {
+		result.HTTP = ptr.String(check.Definition.HTTP)
+	}
+	if check.Definition.Method != `` {
+		result.Method = ptr.String(check.Definition.Method)
+	}
+	if check.Definition.TCP != `` {
+		result.TCP = ptr.String(check.Definition.TCP)
+	}
+	if check.Definition.IntervalDuration > 0 {
+		result.Interval = ptr.String(check.Definition.IntervalDuration.String())
+	}
+	if check.Definition.TimeoutDuration > 0 {
+		result.Timeout = ptr.String(check.Definition.TimeoutDuration.String())
+	}
+	if check.Definition.DeregisterCriticalServiceAfterDuration > 0 {
+		result.DeregisterCriticalServiceAfter = ptr.String(check.Definition.DeregisterCriticalServiceAfterDuration.String())
+	}
+	return result
+}
+
+// DefaultCheckID is implementation of core.CheckIDGenerator interface
+func (r *Registry) DefaultCheckID(service *core.Service, check *core.Check) string {
+	return defaultCheckID(service.RegistrationID(), check.Name)
+}
+
+// defaultCheckID return id of check without explicit id. Check ids are unique per node in catalog,
+// so id contains service id to avoid collisions between checks with the same name of different services
+func defaultCheckID(serviceID string, name string) string {
+	return fmt.Sprintf(`service:%s:%s`, serviceID, name)
+}
+
+// toHealthCheck convert core.Check of core.Service to api.HealthCheck. Durations are validated by core.Check Validate
+func toHealthCheck(service *core.Service, check *core.Check) *api.HealthCheck {
+	hc := &api.HealthCheck{
+		Node:      service.Node.Node,
+		CheckID:   defaultCheckID(service.RegistrationID(), check.Name),
+		Name:      check.Name,
+		ServiceID: service.RegistrationID(),
+		Definition: api.HealthCheckDefinition{
+			TLSSkipVerify: check.TLSSkipVerify,
+		},
+	}
+	if check.ID != nil {
+		hc.CheckID = *check.ID
+	}
+	if check.HTTP != nil {
+		hc.Definition.HTTP = *check.HTTP
+	}
+	if check.Method != nil {
+		hc.Definition.Method = *check.Method
+	}
+	if check.TCP != nil {
+		hc.Definition.TCP = *check.TCP
+	}
+	if check.Interval != nil {
+		hc.Definition.IntervalDuration, _ = time.ParseDuration(*check.Interval)
+	}
+	if check.Timeout != nil {
+		hc.Definition.TimeoutDuration, _ = time.ParseDuration(*check.Timeout)
+	}
+	if check.DeregisterCriticalServiceAfter != nil {
+		hc.Definition.DeregisterCriticalServiceAfterDuration, _ = time.ParseDuration(*check.DeregisterCriticalServiceAfter)
+	}
+	return hc
+}
@@ -3,10 +3,12 @@ package catalog
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/agrea/ptr"
 	"github.com/hashicorp/consul/api"
 	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/consul"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/mock"
@@ -31,6 +33,14 @@ func TestRegistry_Register(t *testing.T) {
 	suite.Run(t, new(registryRegisterTestSuite))
 }
 
+func TestRegistry_IsRetryable(t *testing.T) {
+	suite.Run(t, new(registryIsRetryableTestSuite))
+}
+
+func TestRegistry_DefaultCheckID(t *testing.T) {
+	suite.Run(t, new(registryDefaultCheckIDTestSuite))
+}
+
 func TestRegistry_WithLogger(t *testing.T) {
 	suite.Run(t, new(registryWithLoggerTestSuite))
 }
@@ -42,20 +52,22 @@ type newRegistryTestSuite struct {
 }
 
 func (s *newRegistryTestSuite) TestNewRegistry() {
-	got := NewRegistry(nil, ``)
+	got := NewRegistry(nil, nil, ``, consul.Scope{})
 	s.Implements((*core.Registry)(nil), got)
-	s.Equal(&Registry{nil, nil, ``}, got)
+	s.Equal(&Registry{nil, nil, nil, ``, consul.Scope{}}, got)
 }
 
 type registryFetchTestSuite struct {
 	suite.Suite
 	catalog  *MockCatalog
+	health   *MockHealth
 	registry *Registry
 }
 
 func (s *registryFetchTestSuite) SetupTest() {
 	s.catalog = new(MockCatalog)
-	s.registry = NewRegistry(s.catalog, `test`)
+	s.health = new(MockHealth)
+	s.registry = NewRegistry(s.catalog, s.health, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 }
 
@@ -78,6 +90,16 @@ func (s *registryFetchTestSuite) TestErrorCatalogServiceFetch() {
 	s.EqualError(err, `failed to fetch registered service info: expected error`)
 }
 
+func (s *registryFetchTestSuite) TestErrorHealthChecksFetch() {
+	s.catalog.On(`Services`, mock.Anything).Return(map[string][]string{`name`: nil}, nil, nil)
+	s.catalog.On(`Service`, `name`, mock.Anything, mock.Anything).Return([]*api.CatalogService{}, nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(nil, nil, errors.New(`expected error`))
+
+	services, err := s.registry.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch registered service checks info: expected error`)
+}
+
 func (s *registryFetchTestSuite) TestSuccess() {
 	expectedTags := []string{`tags`}
 	expectedMeta := map[string]string{`key`: `value`}
@@ -88,7 +110,7 @@ func (s *registryFetchTestSuite) TestSuccess() {
 			ServiceName:    `name`,
 			ServiceAddress: `127.0.0.1`,
 			ServiceID:      `id`,
-			ServiceTags:    expectedTags,
+			ServiceTags:    []string{`test`, `tags`},
 			ServiceMeta:    expectedMeta,
 			ServicePort:    80,
 			Node:           `node-1`,
@@ -97,6 +119,24 @@ func (s *registryFetchTestSuite) TestSuccess() {
 			NodeMeta:       expectedMeta,
 		},
 	}, nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(api.HealthChecks{
+		{
+			Node:      `node-1`,
+			CheckID:   `check-id`,
+			Name:      `tcp`,
+			ServiceID: `id`,
+			Definition: api.HealthCheckDefinition{
+				TCP:              `127.0.0.1:80`,
+				IntervalDuration: 10 * time.Second,
+			},
+		},
+		{
+			Node:      `node-2`,
+			CheckID:   `other`,
+			Name:      `other`,
+			ServiceID: `id`,
+		},
+	}, nil, nil)
 
 	fetchedServices, err := s.registry.Fetch(context.Background())
 
@@ -115,11 +155,67 @@ func (s *registryFetchTestSuite) TestSuccess() {
 				Datacenter: ptr.String(`dc-1`),
 				NodeMeta:   &expectedMeta,
 			},
+			Checks: &core.Checks{{
+				ID:       ptr.String(`check-id`),
+				Name:     `tcp`,
+				TCP:      ptr.String(`127.0.0.1:80`),
+				Interval: ptr.String(`10s`),
+			}},
 		},
 	}, fetchedServices)
 
 }
 
+func (s *registryFetchTestSuite) TestDefaultCheckID() {
+	s.catalog.On(`Services`, mock.Anything).Return(map[string][]string{`name`: nil}, nil, nil)
+	s.catalog.On(`Service`, `name`, mock.Anything, mock.Anything).Return([]*api.CatalogService{
+		{
+			ServiceName:    `name`,
+			ServiceAddress: `127.0.0.1`,
+			ServiceID:      `id`,
+			Node:           `node-1`,
+			Address:        `127.0.0.1`,
+		},
+	}, nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(api.HealthChecks{
+		{
+			Node:      `node-1`,
+			CheckID:   `service:id:tcp`,
+			Name:      `tcp`,
+			ServiceID: `id`,
+			Definition: api.HealthCheckDefinition{
+				TCP:              `127.0.0.1:80`,
+				IntervalDuration: 10 * time.Second,
+			},
+		},
+	}, nil, nil)
+
+	fetchedServices, err := s.registry.Fetch(context.Background())
+	s.NoError(err)
+	s.Len(fetchedServices, 1)
+	s.Equal(&core.Checks{{
+		Name:     `tcp`,
+		TCP:      ptr.String(`127.0.0.1:80`),
+		Interval: ptr.String(`10s`),
+	}}, fetchedServices[0].Checks)
+}
+
+func (s *registryFetchTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`}
+	inScope := mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Datacenter == `dc-2` && opts.Namespace == `team`
+	})
+	s.catalog.On(`Services`, inScope).Return(map[string][]string{`name`: nil}, nil, nil)
+	s.catalog.On(`Service`, `name`, `test`, inScope).Return([]*api.CatalogService{}, nil, nil)
+	s.health.On(`Checks`, `name`, inScope).Return(api.HealthChecks{}, nil, nil)
+
+	fetchedServices, err := s.registry.Fetch(context.Background())
+	s.NoError(err)
+	s.Empty(fetchedServices)
+	s.catalog.AssertExpectations(s.T())
+	s.health.AssertExpectations(s.T())
+}
+
 type registryDeregisterTestSuite struct {
 	suite.Suite
 	catalog  *MockCatalog
@@ -129,7 +225,7 @@ type registryDeregisterTestSuite struct {
 
 func (s *registryDeregisterTestSuite) SetupTest() {
 	s.catalog = new(MockCatalog)
-	s.registry = NewRegistry(s.catalog, `test`)
+	s.registry = NewRegistry(s.catalog, nil, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 	s.service = &core.Service{
 		Name:    `service`,
@@ -194,15 +290,47 @@ func (s *registryDeregisterTestSuite) TestSuccess() {
 	s.NoError(err)
 }
 
+func (s *registryDeregisterTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`}
+	s.catalog.On(`Deregister`, &api.CatalogDeregistration{
+		Node:       s.service.Node.Node,
+		ServiceID:  s.service.RegistrationID(),
+		Datacenter: `dc-2`,
+	}, mock.MatchedBy(func(opts *api.WriteOptions) bool {
+		return opts.Datacenter == `dc-2` && opts.Namespace == `team`
+	})).Return(nil, nil)
+
+	err := s.registry.Deregister(context.Background(), s.service)
+	s.NoError(err)
+	s.catalog.AssertExpectations(s.T())
+}
+
+func (s *registryDeregisterTestSuite) TestServiceDatacenter() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`}
+	s.service.Node.Datacenter = ptr.String(`dc-1`)
+	s.catalog.On(`Deregister`, &api.CatalogDeregistration{
+		Node:       s.service.Node.Node,
+		ServiceID:  s.service.RegistrationID(),
+		Datacenter: `dc-1`,
+	}, mock.Anything).Return(nil, nil)
+
+	err := s.registry.Deregister(context.Background(), s.service)
+	s.NoError(err)
+	s.catalog.AssertExpectations(s.T())
+}
+
 type registryRegisterTestSuite struct {
 	suite.Suite
 	catalog  *MockCatalog
+	health   *MockHealth
 	registry *Registry
 }
 
 func (s *registryRegisterTestSuite) SetupTest() {
 	s.catalog = new(MockCatalog)
-	s.registry = NewRegistry(s.catalog, `test`)
+	s.health = new(MockHealth)
+	s.health.On(`Checks`, mock.Anything, mock.Anything).Return(api.HealthChecks{}, nil, nil).Maybe()
+	s.registry = NewRegistry(s.catalog, s.health, `test`, consul.Scope{})
 	s.registry.logger, _ = test.NewNullLogger()
 }
 
@@ -248,13 +376,198 @@ func (s *registryRegisterTestSuite) TestSuccess() {
 	s.NoError(err)
 }
 
+func (s *registryRegisterTestSuite) TestScope() {
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`, Namespace: `team`}
+	s.catalog.On(`Register`, mock.MatchedBy(func(cr *api.CatalogRegistration) bool {
+		return cr.Datacenter == `dc-2`
+	}), mock.MatchedBy(func(opts *api.WriteOptions) bool {
+		return opts.Datacenter == `dc-2` && opts.Namespace == `team`
+	})).Return(nil, nil)
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+	})
+	s.NoError(err)
+	s.catalog.AssertExpectations(s.T())
+}
+
+func (s *registryRegisterTestSuite) TestErrorUnsupportedCheck() {
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+		Checks: &core.Checks{{Name: `ttl`, TTL: ptr.String(`30s`)}},
+	})
+	s.Error(err)
+	s.Contains(err.Error(), `check "ttl" has unsupported type "ttl", only http and tcp checks are supported by catalog`)
+	s.Contains(err.Error(), `use consul-agent registry for grpc and ttl checks`)
+}
+
+func (s *registryRegisterTestSuite) TestChecks() {
+	s.catalog.On(`Register`, mock.Anything, mock.Anything).Return(nil, nil)
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+		Checks: &core.Checks{{
+			Name:     `http`,
+			HTTP:     ptr.String(`http://127.0.0.1/health`),
+			Method:   ptr.String(`HEAD`),
+			Interval: ptr.String(`10s`),
+			Timeout:  ptr.String(`1s`),
+		}},
+	})
+	s.NoError(err)
+	s.catalog.AssertCalled(s.T(), `Register`, mock.MatchedBy(func(cr *api.CatalogRegistration) bool {
+		return s.Equal(api.HealthChecks{{
+			Node:      `node-1`,
+			CheckID:   `service:name:http`,
+			Name:      `http`,
+			ServiceID: `name`,
+			Definition: api.HealthCheckDefinition{
+				HTTP:             `http://127.0.0.1/health`,
+				Method:           `HEAD`,
+				IntervalDuration: 10 * time.Second,
+				TimeoutDuration:  time.Second,
+			},
+		}}, cr.Checks)
+	}), mock.Anything)
+}
+
+func (s *registryRegisterTestSuite) TestChecksSameNameOnNode() {
+	s.catalog.On(`Register`, mock.Anything, mock.Anything).Return(nil, nil)
+
+	for _, id := range []string{`first`, `second`} {
+		err := s.registry.Register(context.Background(), &core.Service{
+			Name:    `name`,
+			ID:      ptr.String(id),
+			Address: `127.0.0.1`,
+			Node: &core.Node{
+				Node:    `node-1`,
+				Address: `127.0.0.1`,
+			},
+			Checks: &core.Checks{{Name: `http`, HTTP: ptr.String(`http://127.0.0.1/health`), Interval: ptr.String(`10s`)}},
+		})
+		s.NoError(err)
+	}
+	for _, id := range []string{`service:first:http`, `service:second:http`} {
+		checkID := id
+		s.catalog.AssertCalled(s.T(), `Register`, mock.MatchedBy(func(cr *api.CatalogRegistration) bool {
+			return len(cr.Checks) == 1 && cr.Checks[0].CheckID == checkID
+		}), mock.Anything)
+	}
+}
+
+func (s *registryRegisterTestSuite) TestDeregisterStaleChecks() {
+	s.health = new(MockHealth)
+	s.registry.health = s.health
+	s.registry.scope = consul.Scope{Datacenter: `dc-2`}
+	s.catalog.On(`Register`, mock.Anything, mock.Anything).Return(nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(api.HealthChecks{
+		{Node: `node-1`, ServiceID: `id`, CheckID: `service:id:http`},
+		{Node: `node-1`, ServiceID: `id`, CheckID: `http`},
+		{Node: `node-1`, ServiceID: `other`, CheckID: `service:other:tcp`},
+		{Node: `node-2`, ServiceID: `id`, CheckID: `service:id:tcp`},
+	}, nil, nil)
+	s.catalog.On(`Deregister`, &api.CatalogDeregistration{
+		Node:       `node-1`,
+		CheckID:    `http`,
+		Datacenter: `dc-2`,
+	}, mock.Anything).Return(nil, nil).Once()
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		ID:      ptr.String(`id`),
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+		Checks: &core.Checks{{Name: `http`, HTTP: ptr.String(`http://127.0.0.1/health`), Interval: ptr.String(`10s`)}},
+	})
+	s.NoError(err)
+	s.catalog.AssertExpectations(s.T())
+	s.catalog.AssertNumberOfCalls(s.T(), `Deregister`, 1)
+}
+
+func (s *registryRegisterTestSuite) TestErrorStaleChecksFetch() {
+	s.health = new(MockHealth)
+	s.registry.health = s.health
+	s.catalog.On(`Register`, mock.Anything, mock.Anything).Return(nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(nil, nil, errors.New(`expected error`))
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+	})
+	s.EqualError(err, `failed to fetch checks of service by service id "name": expected error`)
+}
+
+func (s *registryRegisterTestSuite) TestErrorStaleCheckDeregister() {
+	s.health = new(MockHealth)
+	s.registry.health = s.health
+	s.catalog.On(`Register`, mock.Anything, mock.Anything).Return(nil, nil)
+	s.health.On(`Checks`, `name`, mock.Anything).Return(api.HealthChecks{
+		{Node: `node-1`, ServiceID: `name`, CheckID: `http`},
+	}, nil, nil)
+	s.catalog.On(`Deregister`, mock.Anything, mock.Anything).Return(nil, errors.New(`expected error`))
+
+	err := s.registry.Register(context.Background(), &core.Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		Node: &core.Node{
+			Node:    `node-1`,
+			Address: `127.0.0.1`,
+		},
+	})
+	s.EqualError(err, `failed deregister check "http" by service id "name": expected error`)
+}
+
+type registryIsRetryableTestSuite struct {
+	suite.Suite
+}
+
+func (s *registryIsRetryableTestSuite) TestIsRetryable() {
+	r := NewRegistry(nil, nil, ``, consul.Scope{})
+	s.Implements((*core.RetryClassifier)(nil), r)
+	s.True(r.IsRetryable(errors.New(`Unexpected response code: 500 ()`)))
+	s.False(r.IsRetryable(errors.New(`Unexpected response code: 400 ()`)))
+}
+
+type registryDefaultCheckIDTestSuite struct {
+	suite.Suite
+}
+
+func (s *registryDefaultCheckIDTestSuite) TestDefaultCheckID() {
+	r := NewRegistry(nil, nil, ``, consul.Scope{})
+	s.Implements((*core.CheckIDGenerator)(nil), r)
+	s.Equal(`service:name:http`, r.DefaultCheckID(&core.Service{Name: `name`}, &core.Check{Name: `http`}))
+	s.Equal(`service:id:http`, r.DefaultCheckID(&core.Service{ID: ptr.String(`id`), Name: `name`}, &core.Check{Name: `http`}))
+}
+
 type registryWithLoggerTestSuite struct {
 	suite.Suite
 }
 
 func (s *registryWithLoggerTestSuite) TestWithLogger() {
 	logger, _ := test.NewNullLogger()
-	src := NewRegistry(nil, ``)
+	src := NewRegistry(nil, nil, ``, consul.Scope{})
 	src.WithLogger(logger)
 }
 
@@ -374,3 +687,40 @@ func (_m *MockCatalog) Services(_a0 *api.QueryOptions) (map[string][]string, *ap
 
 	return r0, r1, r2
 }
+
+// MockHealth is an autogenerated mock type for the Health type
+type MockHealth struct {
+	mock.Mock
+}
+
+// Checks provides a mock function with given fields: _a0, _a1
+func (_m *MockHealth) Checks(_a0 string, _a1 *api.QueryOptions) (api.HealthChecks, *api.QueryMeta, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 api.HealthChecks
+	if rf, ok := ret.Get(0).(func(string, *api.QueryOptions) api.HealthChecks); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(api.HealthChecks)
+		}
+	}
+
+	var r1 *api.QueryMeta
+	if rf, ok := ret.Get(1).(func(string, *api.QueryOptions) *api.QueryMeta); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*api.QueryMeta)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, *api.QueryOptions) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
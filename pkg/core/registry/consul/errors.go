@@ -0,0 +1,36 @@
+package consul
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	responseCodeRegexp = regexp.MustCompile(`Unexpected response code: (\d+)`)
+)
+
+// IsRetryable classifies errors returned by Consul HTTP API client.
+// Network errors, 429 and 5xx responses are transient, all other errors are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	matches := responseCodeRegexp.FindStringSubmatch(err.Error())
+	if len(matches) < 2 {
+		return false
+	}
+	code, convErr := strconv.Atoi(matches[1])
+	if convErr != nil {
+		return false
+	}
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,47 @@
+package consul
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestIsRetryable(t *testing.T) {
+	suite.Run(t, new(isRetryableTestSuite))
+}
+
+// --- Suites ---
+
+type isRetryableTestSuite struct {
+	suite.Suite
+}
+
+func (s *isRetryableTestSuite) TestNil() {
+	s.False(IsRetryable(nil))
+}
+
+func (s *isRetryableTestSuite) TestContext() {
+	s.False(IsRetryable(errors.Wrap(context.Canceled, `failed`)))
+	s.False(IsRetryable(errors.Wrap(context.DeadlineExceeded, `failed`)))
+}
+
+func (s *isRetryableTestSuite) TestNetworkError() {
+	s.True(IsRetryable(errors.Wrap(&net.OpError{Op: `dial`, Err: errors.New(`connection refused`)}, `failed`)))
+}
+
+func (s *isRetryableTestSuite) TestResponseCode() {
+	s.True(IsRetryable(errors.Wrap(errors.New(`Unexpected response code: 500 (error)`), `failed`)))
+	s.True(IsRetryable(errors.New(`Unexpected response code: 503 ()`)))
+	s.True(IsRetryable(errors.New(`Unexpected response code: 429 ()`)))
+	s.False(IsRetryable(errors.New(`Unexpected response code: 403 (ACL not found)`)))
+	s.False(IsRetryable(errors.New(`Unexpected response code: 400 (bad request)`)))
+}
+
+func (s *isRetryableTestSuite) TestOtherError() {
+	s.False(IsRetryable(errors.New(`service has validation error before registration`)))
+}
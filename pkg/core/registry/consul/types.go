@@ -1,6 +1,17 @@
 package consul
 
+import (
+	"github.com/thoas/go-funk"
+)
+
 type (
 	// Tag is a common tag for query and register services in registry
 	Tag string
 )
+
+// Exclude return copy of tags list without Tag. Used to return services from registry in the same form as in source
+func (t Tag) Exclude(tags []string) []string {
+	return funk.FilterString(tags, func(tag string) bool {
+		return tag != string(t)
+	})
+}
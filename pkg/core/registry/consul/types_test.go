@@ -0,0 +1,27 @@
+package consul
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestTag_Exclude(t *testing.T) {
+	suite.Run(t, new(tagExcludeTestSuite))
+}
+
+// --- Suites ---
+
+type tagExcludeTestSuite struct {
+	suite.Suite
+}
+
+func (s *tagExcludeTestSuite) TestEmpty() {
+	s.Empty(Tag(`pinchy`).Exclude(nil))
+}
+
+func (s *tagExcludeTestSuite) TestExclude() {
+	s.Equal([]string{`tag-1`, `tag-2`}, Tag(`pinchy`).Exclude([]string{`tag-1`, `pinchy`, `tag-2`}))
+}
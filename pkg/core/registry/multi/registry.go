@@ -0,0 +1,111 @@
+package multi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Registry is implementation of core.RegistryGroup interface, which writes same services to several members.
+	// core.Manager detects core.RegistryGroup and processes every member separately,
+	// so Registry methods are only used when Registry is called directly.
+	Registry struct {
+		members []core.RegistryMember
+	}
+
+	// MemberError contains errors returned by several members for single call
+	MemberError []error
+)
+
+// NewRegistry provide Registry as core.RegistryGroup implementation
+func NewRegistry(members []core.RegistryMember) (*Registry, error) {
+	if len(members) == 0 {
+		return nil, errors.New(`at least one registry member is required`)
+	}
+	names := make(map[string]bool, len(members))
+	for _, member := range members {
+		if member.Name == `` {
+			return nil, errors.New(`registry member name must not be empty`)
+		}
+		if names[member.Name] {
+			return nil, errors.Errorf(`registry member "%s" is defined more than once`, member.Name)
+		}
+		names[member.Name] = true
+	}
+	return &Registry{
+		members: members,
+	}, nil
+}
+
+// Members is implementation of core.RegistryGroup interface
+func (r *Registry) Members() []core.RegistryMember {
+	return r.members
+}
+
+// Fetch return union of services registered in all members. Service from member listed earlier wins
+func (r *Registry) Fetch(ctx context.Context) (core.Services, error) {
+	seen := make(map[string]bool)
+	services := make(core.Services, 0)
+	for _, member := range r.members {
+		list, err := member.Registry.Fetch(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to fetch services from registry "%s"`, member.Name)
+		}
+		for _, service := range list {
+			if seen[service.RegistrationID()] {
+				continue
+			}
+			seen[service.RegistrationID()] = true
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// Register registers service in all members
+func (r *Registry) Register(ctx context.Context, service *core.Service) error {
+	return r.each(func(member core.RegistryMember) error {
+		return member.Registry.Register(ctx, service)
+	})
+}
+
+// Deregister deregisters service from all members
+func (r *Registry) Deregister(ctx context.Context, service *core.Service) error {
+	return r.each(func(member core.RegistryMember) error {
+		return member.Registry.Deregister(ctx, service)
+	})
+}
+
+// WithLogger is implementation of core.Loggable interface. Logger is passed to all members
+func (r *Registry) WithLogger(logger core.LoggerInterface) {
+	for _, member := range r.members {
+		if loggable, ok := member.Registry.(core.Loggable); ok {
+			loggable.WithLogger(logger)
+		}
+	}
+}
+
+func (r *Registry) each(call func(member core.RegistryMember) error) error {
+	var me MemberError
+	for _, member := range r.members {
+		if err := call(member); err != nil {
+			me = append(me, errors.Wrapf(err, `registry "%s"`, member.Name))
+		}
+	}
+	if len(me) > 0 {
+		return me
+	}
+	return nil
+}
+
+// Error is implementation of error interface
+func (e MemberError) Error() string {
+	slice := make([]string, 0, len(e))
+	for _, err := range e {
+		slice = append(slice, err.Error())
+	}
+	return strings.Join(slice, `; `)
+}
@@ -0,0 +1,271 @@
+package multi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewRegistry(t *testing.T) {
+	suite.Run(t, new(newRegistryTestSuite))
+}
+
+func TestRegistry_Members(t *testing.T) {
+	suite.Run(t, new(registryMembersTestSuite))
+}
+
+func TestRegistry_Fetch(t *testing.T) {
+	suite.Run(t, new(registryFetchTestSuite))
+}
+
+func TestRegistry_Register(t *testing.T) {
+	suite.Run(t, new(registryRegisterTestSuite))
+}
+
+func TestRegistry_Deregister(t *testing.T) {
+	suite.Run(t, new(registryDeregisterTestSuite))
+}
+
+func TestRegistry_WithLogger(t *testing.T) {
+	suite.Run(t, new(registryWithLoggerTestSuite))
+}
+
+func TestMemberError_Error(t *testing.T) {
+	suite.Run(t, new(memberErrorErrorTestSuite))
+}
+
+// --- Suites ---
+
+type newRegistryTestSuite struct {
+	suite.Suite
+}
+
+func (s *newRegistryTestSuite) TestNewRegistry() {
+	members := []core.RegistryMember{{Name: `agent`, Registry: new(MockRegistry)}}
+	got, err := NewRegistry(members)
+	s.NoError(err)
+	s.Implements((*core.RegistryGroup)(nil), got)
+	s.Equal(&Registry{members}, got)
+}
+
+func (s *newRegistryTestSuite) TestEmptyMembers() {
+	got, err := NewRegistry(nil)
+	s.Nil(got)
+	s.EqualError(err, `at least one registry member is required`)
+}
+
+func (s *newRegistryTestSuite) TestEmptyName() {
+	got, err := NewRegistry([]core.RegistryMember{{Registry: new(MockRegistry)}})
+	s.Nil(got)
+	s.EqualError(err, `registry member name must not be empty`)
+}
+
+func (s *newRegistryTestSuite) TestDuplicatedName() {
+	got, err := NewRegistry([]core.RegistryMember{
+		{Name: `agent`, Registry: new(MockRegistry)},
+		{Name: `agent`, Registry: new(MockRegistry)},
+	})
+	s.Nil(got)
+	s.EqualError(err, `registry member "agent" is defined more than once`)
+}
+
+type registryMembersTestSuite struct {
+	suite.Suite
+}
+
+func (s *registryMembersTestSuite) TestMembers() {
+	members := []core.RegistryMember{{Name: `agent`, Registry: new(MockRegistry)}}
+	registry, _ := NewRegistry(members)
+	s.Equal(members, registry.Members())
+}
+
+type registryTestSuite struct {
+	suite.Suite
+	agent    *MockRegistry
+	catalog  *MockRegistry
+	registry *Registry
+}
+
+func (s *registryTestSuite) SetupTest() {
+	s.agent = new(MockRegistry)
+	s.catalog = new(MockRegistry)
+	registry, err := NewRegistry([]core.RegistryMember{
+		{Name: `agent`, Registry: s.agent},
+		{Name: `catalog`, Registry: s.catalog},
+	})
+	if err != nil {
+		panic(errors.Wrap(err, `failed to create registry`))
+	}
+	s.registry = registry
+}
+
+type registryFetchTestSuite struct {
+	registryTestSuite
+}
+
+func (s *registryFetchTestSuite) TestError() {
+	s.agent.On(`Fetch`, mock.Anything).Return(core.Services{}, nil)
+	s.catalog.On(`Fetch`, mock.Anything).Return(nil, errors.New(`expected error`))
+
+	services, err := s.registry.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch services from registry "catalog": expected error`)
+}
+
+func (s *registryFetchTestSuite) TestSuccess() {
+	s.agent.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+	}, nil)
+	s.catalog.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.2`},
+		{Name: `service-2`, Address: `127.0.0.2`},
+	}, nil)
+
+	services, err := s.registry.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+		{Name: `service-2`, Address: `127.0.0.2`},
+	}, services)
+}
+
+type registryRegisterTestSuite struct {
+	registryTestSuite
+}
+
+func (s *registryRegisterTestSuite) TestError() {
+	service := &core.Service{Name: `service-1`}
+	s.agent.On(`Register`, mock.Anything, service).Return(errors.New(`expected error`))
+	s.catalog.On(`Register`, mock.Anything, service).Return(nil)
+
+	err := s.registry.Register(context.Background(), service)
+	s.EqualError(err, `registry "agent": expected error`)
+	s.catalog.AssertCalled(s.T(), `Register`, mock.Anything, service)
+}
+
+func (s *registryRegisterTestSuite) TestSuccess() {
+	service := &core.Service{Name: `service-1`}
+	s.agent.On(`Register`, mock.Anything, service).Return(nil)
+	s.catalog.On(`Register`, mock.Anything, service).Return(nil)
+
+	s.NoError(s.registry.Register(context.Background(), service))
+	s.agent.AssertCalled(s.T(), `Register`, mock.Anything, service)
+	s.catalog.AssertCalled(s.T(), `Register`, mock.Anything, service)
+}
+
+type registryDeregisterTestSuite struct {
+	registryTestSuite
+}
+
+func (s *registryDeregisterTestSuite) TestError() {
+	service := &core.Service{Name: `service-1`}
+	s.agent.On(`Deregister`, mock.Anything, service).Return(errors.New(`first error`))
+	s.catalog.On(`Deregister`, mock.Anything, service).Return(errors.New(`second error`))
+
+	err := s.registry.Deregister(context.Background(), service)
+	s.EqualError(err, `registry "agent": first error; registry "catalog": second error`)
+}
+
+func (s *registryDeregisterTestSuite) TestSuccess() {
+	service := &core.Service{Name: `service-1`}
+	s.agent.On(`Deregister`, mock.Anything, service).Return(nil)
+	s.catalog.On(`Deregister`, mock.Anything, service).Return(nil)
+
+	s.NoError(s.registry.Deregister(context.Background(), service))
+}
+
+type registryWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *registryWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	member := new(MockLoggableRegistry)
+	member.On(`WithLogger`, logger).Return()
+	registry, _ := NewRegistry([]core.RegistryMember{{Name: `member`, Registry: member}})
+	registry.WithLogger(logger)
+	member.AssertCalled(s.T(), `WithLogger`, logger)
+}
+
+type memberErrorErrorTestSuite struct {
+	suite.Suite
+}
+
+func (s *memberErrorErrorTestSuite) TestError() {
+	s.Equal(`message 1; message 2`, MemberError{errors.New(`message 1`), errors.New(`message 2`)}.Error())
+}
+
+// --- Mocks ---
+
+// MockRegistry is an autogenerated mock type for the Registry type
+type MockRegistry struct {
+	mock.Mock
+}
+
+// Deregister provides a mock function with given fields: ctx, service
+func (_m *MockRegistry) Deregister(ctx context.Context, service *core.Service) error {
+	ret := _m.Called(ctx, service)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.Service) error); ok {
+		r0 = rf(ctx, service)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Fetch provides a mock function with given fields: ctx
+func (_m *MockRegistry) Fetch(ctx context.Context) (core.Services, error) {
+	ret := _m.Called(ctx)
+
+	var r0 core.Services
+	if rf, ok := ret.Get(0).(func(context.Context) core.Services); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(core.Services)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, service
+func (_m *MockRegistry) Register(ctx context.Context, service *core.Service) error {
+	ret := _m.Called(ctx, service)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.Service) error); ok {
+		r0 = rf(ctx, service)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockLoggableRegistry is a mock type for the Registry type, which implements core.Loggable
+type MockLoggableRegistry struct {
+	MockRegistry
+}
+
+// WithLogger provides a mock function with given fields: logger
+func (_m *MockLoggableRegistry) WithLogger(logger core.LoggerInterface) {
+	_m.Called(logger)
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ActionRegistered means new Service was registered in Registry
+	ActionRegistered Action = `registered`
+	// ActionUpdated means changed Service was registered in Registry again
+	ActionUpdated Action = `updated`
+	// ActionUnchanged means Service was not changed and registration was skipped
+	ActionUnchanged Action = `unchanged`
+	// ActionDeregistered means orphan Service was deregistered from Registry
+	ActionDeregistered Action = `deregistered`
+	// ActionSkipped means Service was not processed, e.g. Manager.Run stopped on previous error
+	ActionSkipped Action = `skipped`
+	// ActionFailed means Registry returned error for Service
+	ActionFailed Action = `failed`
+)
+
+type (
+	// Action describes what Manager did with Service during Manager.Run
+	Action string
+
+	// ReportItem contains result of processing single Service during Manager.Run.
+	// Registry contains RegistryMember name, if Registry implements RegistryGroup.
+	ReportItem struct {
+		Registry string
+		ID       string
+		Action   Action
+		Error    error
+		Duration time.Duration
+	}
+
+	// Report contains results of single Manager.Run call. Report.Add is safe for concurrent use
+	Report struct {
+		StartedAt  time.Time
+		FinishedAt time.Time
+		Items      []*ReportItem
+		mu         sync.Mutex
+	}
+)
+
+// Actions return list of all available Action values in order of processing
+func Actions() []Action {
+	return []Action{
+		ActionRegistered,
+		ActionUpdated,
+		ActionUnchanged,
+		ActionDeregistered,
+		ActionSkipped,
+		ActionFailed,
+	}
+}
+
+// NewReport provides empty Report started at current time
+func NewReport() *Report {
+	return &Report{
+		StartedAt: time.Now(),
+		Items:     make([]*ReportItem, 0),
+	}
+}
+
+// Add appends result of Service processing to Report
+func (r *Report) Add(registry string, id string, action Action, err error, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Items = append(r.Items, &ReportItem{
+		Registry: registry,
+		ID:       id,
+		Action:   action,
+		Error:    err,
+		Duration: duration,
+	})
+}
+
+// AddServices appends same result for all Services without error and duration
+func (r *Report) AddServices(registry string, services Services, action Action) {
+	for _, service := range services {
+		r.Add(registry, service.RegistrationID(), action, nil, 0)
+	}
+}
+
+// Finish marks Report as finished at current time
+func (r *Report) Finish() {
+	r.FinishedAt = time.Now()
+}
+
+// Duration return time spent between Report start and finish
+func (r *Report) Duration() time.Duration {
+	if r.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// Count return count of ReportItem with passed Action
+func (r *Report) Count(action Action) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// HasFailures checks if Report contains at least one ReportItem with ActionFailed
+func (r *Report) HasFailures() bool {
+	return r.Count(ActionFailed) > 0
+}
+
+// String return short summary with count of ReportItem per Action
+func (r *Report) String() string {
+	var slice []string
+	for _, action := range Actions() {
+		slice = append(slice, fmt.Sprintf(`%s: %d`, action, r.Count(action)))
+	}
+	return strings.Join(slice, `, `)
+}
+
+// MarshalJSON is implementation of json.Marshaler interface. ReportItem.Error is marshaled as string
+func (i *ReportItem) MarshalJSON() ([]byte, error) {
+	item := struct {
+		Registry string `json:",omitempty"`
+		ID       string `json:","`
+		Action   Action `json:","`
+		Error    string `json:",omitempty"`
+		Duration string `json:","`
+	}{
+		Registry: i.Registry,
+		ID:       i.ID,
+		Action:   i.Action,
+		Duration: i.Duration.String(),
+	}
+	if i.Error != nil {
+		item.Error = i.Error.Error()
+	}
+	return json.Marshal(item)
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestActions(t *testing.T) {
+	suite.Run(t, new(actionsTestSuite))
+}
+
+func TestNewReport(t *testing.T) {
+	suite.Run(t, new(newReportTestSuite))
+}
+
+func TestReport_Add(t *testing.T) {
+	suite.Run(t, new(reportAddTestSuite))
+}
+
+func TestReport_AddServices(t *testing.T) {
+	suite.Run(t, new(reportAddServicesTestSuite))
+}
+
+func TestReport_Duration(t *testing.T) {
+	suite.Run(t, new(reportDurationTestSuite))
+}
+
+func TestReport_HasFailures(t *testing.T) {
+	suite.Run(t, new(reportHasFailuresTestSuite))
+}
+
+func TestReport_String(t *testing.T) {
+	suite.Run(t, new(reportStringTestSuite))
+}
+
+func TestReportItem_MarshalJSON(t *testing.T) {
+	suite.Run(t, new(reportItemMarshalJSONTestSuite))
+}
+
+// --- Suites ---
+
+type actionsTestSuite struct {
+	suite.Suite
+}
+
+func (s *actionsTestSuite) TestActions() {
+	s.Len(Actions(), 6)
+}
+
+type newReportTestSuite struct {
+	suite.Suite
+}
+
+func (s *newReportTestSuite) TestNewReport() {
+	report := NewReport()
+	s.False(report.StartedAt.IsZero())
+	s.True(report.FinishedAt.IsZero())
+	s.Empty(report.Items)
+}
+
+type reportAddTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportAddTestSuite) TestAdd() {
+	report := NewReport()
+	report.Add(`registry`, `id`, ActionFailed, errors.New(`expected error`), time.Second)
+	s.Len(report.Items, 1)
+	s.Equal(`registry`, report.Items[0].Registry)
+	s.Equal(`id`, report.Items[0].ID)
+	s.Equal(ActionFailed, report.Items[0].Action)
+	s.EqualError(report.Items[0].Error, `expected error`)
+	s.Equal(time.Second, report.Items[0].Duration)
+}
+
+type reportAddServicesTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportAddServicesTestSuite) TestAddServices() {
+	report := NewReport()
+	report.AddServices(``, Services{{Name: `service-1`}, {Name: `service-2`}}, ActionSkipped)
+	s.Equal(2, report.Count(ActionSkipped))
+	s.Equal(0, report.Count(ActionFailed))
+}
+
+type reportDurationTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportDurationTestSuite) TestNotFinished() {
+	s.Zero(NewReport().Duration())
+}
+
+func (s *reportDurationTestSuite) TestFinished() {
+	report := NewReport()
+	report.FinishedAt = report.StartedAt.Add(time.Second)
+	s.Equal(time.Second, report.Duration())
+}
+
+type reportHasFailuresTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportHasFailuresTestSuite) TestHasFailures() {
+	report := NewReport()
+	report.Add(``, `id-1`, ActionRegistered, nil, 0)
+	s.False(report.HasFailures())
+	report.Add(``, `id-2`, ActionFailed, errors.New(`expected error`), 0)
+	s.True(report.HasFailures())
+}
+
+type reportStringTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportStringTestSuite) TestString() {
+	report := NewReport()
+	report.Add(``, `id`, ActionRegistered, nil, 0)
+	s.Equal(`registered: 1, updated: 0, unchanged: 0, deregistered: 0, skipped: 0, failed: 0`, report.String())
+}
+
+type reportItemMarshalJSONTestSuite struct {
+	suite.Suite
+}
+
+func (s *reportItemMarshalJSONTestSuite) TestWithoutError() {
+	got, err := json.Marshal(&ReportItem{ID: `id`, Action: ActionRegistered, Duration: time.Second})
+	s.NoError(err)
+	s.JSONEq(`{"ID": "id", "Action": "registered", "Duration": "1s"}`, string(got))
+}
+
+func (s *reportItemMarshalJSONTestSuite) TestWithError() {
+	got, err := json.Marshal(&ReportItem{ID: `id`, Action: ActionFailed, Error: errors.New(`expected error`)})
+	s.NoError(err)
+	s.JSONEq(`{"ID": "id", "Action": "failed", "Error": "expected error", "Duration": "0s"}`, string(got))
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// RetryPolicy describes how many times and with which delay RetryRegistry repeats failed Registry calls.
+	// Delay grows exponentially from BaseDelay up to MaxDelay. Jitter is a fraction of delay (from 0 to 1),
+	// which is randomly subtracted from every delay. Random is used for Jitter, nil Random means no jitter.
+	RetryPolicy struct {
+		MaxAttempts int
+		BaseDelay   time.Duration
+		MaxDelay    time.Duration
+		Jitter      float64
+		Random      Random
+	}
+
+	// RetryClassifier is optional interface for Registry implementations.
+	// IsRetryable must return true for transient errors only, e.g. network errors or 5xx responses.
+	// If Registry does not implement RetryClassifier, every error except context cancellation is retryable.
+	RetryClassifier interface {
+		IsRetryable(err error) bool
+	}
+
+	// RetryRegistry is Registry decorator, which repeats failed Registry calls according to RetryPolicy
+	RetryRegistry struct {
+		registry Registry
+		policy   RetryPolicy
+		logger   LoggerInterface
+	}
+)
+
+// NewRetryRegistry provides RetryRegistry for Registry with RetryPolicy.
+// If RetryPolicy has Jitter without Random, own Random seeded with current time is used, so several processes
+// do not retry on the same schedule
+func NewRetryRegistry(registry Registry, policy RetryPolicy) *RetryRegistry {
+	if policy.Jitter > 0 && policy.Random == nil {
+		policy.Random = NewRandom()
+	}
+	return &RetryRegistry{
+		registry: registry,
+		policy:   policy,
+	}
+}
+
+// Delay return delay before retry with passed number, starting from 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && p.Random != nil {
+		delay -= time.Duration(p.Random.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Fetch calls Registry.Fetch with retries
+func (r *RetryRegistry) Fetch(ctx context.Context) (Services, error) {
+	var services Services
+	err := r.retry(ctx, `fetch services`, func() error {
+		var err error
+		services, err = r.registry.Fetch(ctx)
+		return err
+	})
+	return services, err
+}
+
+// Register calls Registry.Register with retries
+func (r *RetryRegistry) Register(ctx context.Context, service *Service) error {
+	return r.retry(ctx, fmt.Sprintf(`register service "%s"`, service.RegistrationID()), func() error {
+		return r.registry.Register(ctx, service)
+	})
+}
+
+// Deregister calls Registry.Deregister with retries
+func (r *RetryRegistry) Deregister(ctx context.Context, service *Service) error {
+	return r.retry(ctx, fmt.Sprintf(`deregister service "%s"`, service.RegistrationID()), func() error {
+		return r.registry.Deregister(ctx, service)
+	})
+}
+
+// DefaultCheckID is implementation of CheckIDGenerator interface, which is forwarded to Registry
+func (r *RetryRegistry) DefaultCheckID(service *Service, check *Check) string {
+	if generator, ok := r.registry.(CheckIDGenerator); ok {
+		return generator.DefaultCheckID(service, check)
+	}
+	return ``
+}
+
+// WithLogger is implementation of Loggable interface
+func (r *RetryRegistry) WithLogger(logger LoggerInterface) {
+	r.logger = logger
+}
+
+func (r *RetryRegistry) retry(ctx context.Context, operation string, call func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = call(); err == nil || attempt >= r.policy.MaxAttempts || !r.isRetryable(err) {
+			return err
+		}
+
+		delay := r.policy.Delay(attempt)
+		r.logger.Warningf(`Failed to %s, retry #%d in %s: %s`, operation, attempt, delay, err.Error())
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *RetryRegistry) isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if classifier, ok := r.registry.(RetryClassifier); ok {
+		return classifier.IsRetryable(err)
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewRetryRegistry(t *testing.T) {
+	suite.Run(t, new(newRetryRegistryTestSuite))
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	suite.Run(t, new(retryPolicyDelayTestSuite))
+}
+
+func TestRetryRegistry_Fetch(t *testing.T) {
+	suite.Run(t, new(retryRegistryFetchTestSuite))
+}
+
+func TestRetryRegistry_Register(t *testing.T) {
+	suite.Run(t, new(retryRegistryRegisterTestSuite))
+}
+
+func TestRetryRegistry_Deregister(t *testing.T) {
+	suite.Run(t, new(retryRegistryDeregisterTestSuite))
+}
+
+func TestRetryRegistry_DefaultCheckID(t *testing.T) {
+	suite.Run(t, new(retryRegistryDefaultCheckIDTestSuite))
+}
+
+func TestRetryRegistry_WithLogger(t *testing.T) {
+	suite.Run(t, new(retryRegistryWithLoggerTestSuite))
+}
+
+// --- Suites ---
+
+type newRetryRegistryTestSuite struct {
+	suite.Suite
+}
+
+func (s *newRetryRegistryTestSuite) TestNewRetryRegistry() {
+	got := NewRetryRegistry(nil, RetryPolicy{MaxAttempts: 3})
+	s.Implements((*Registry)(nil), got)
+	s.Equal(&RetryRegistry{policy: RetryPolicy{MaxAttempts: 3}}, got)
+}
+
+func (s *newRetryRegistryTestSuite) TestJitterRandom() {
+	got := NewRetryRegistry(nil, RetryPolicy{MaxAttempts: 3, Jitter: 0.5})
+	s.NotNil(got.policy.Random)
+
+	random := new(MockRandom)
+	got = NewRetryRegistry(nil, RetryPolicy{MaxAttempts: 3, Jitter: 0.5, Random: random})
+	s.Equal(random, got.policy.Random)
+}
+
+type retryPolicyDelayTestSuite struct {
+	suite.Suite
+}
+
+func (s *retryPolicyDelayTestSuite) TestExponential() {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	s.Equal(time.Second, policy.Delay(1))
+	s.Equal(2*time.Second, policy.Delay(2))
+	s.Equal(4*time.Second, policy.Delay(3))
+	s.Equal(5*time.Second, policy.Delay(4))
+	s.Equal(5*time.Second, policy.Delay(100))
+}
+
+func (s *retryPolicyDelayTestSuite) TestJitter() {
+	random := new(MockRandom)
+	random.On(`Float64`).Return(0.5)
+	policy := RetryPolicy{BaseDelay: time.Second, Jitter: 0.5, Random: random}
+	s.Equal(750*time.Millisecond, policy.Delay(1))
+	s.Equal(1500*time.Millisecond, policy.Delay(2))
+}
+
+func (s *retryPolicyDelayTestSuite) TestJitterRange() {
+	policy := RetryPolicy{BaseDelay: time.Second, Jitter: 0.5, Random: NewRandom()}
+	for i := 0; i < 10; i++ {
+		delay := policy.Delay(1)
+		s.True(delay > 500*time.Millisecond && delay <= time.Second)
+	}
+}
+
+func (s *retryPolicyDelayTestSuite) TestJitterWithoutRandom() {
+	policy := RetryPolicy{BaseDelay: time.Second, Jitter: 0.5}
+	s.Equal(time.Second, policy.Delay(1))
+}
+
+type retryRegistryFetchTestSuite struct {
+	suite.Suite
+	inner    *MockRegistry
+	registry *RetryRegistry
+}
+
+func (s *retryRegistryFetchTestSuite) SetupTest() {
+	s.inner = new(MockRegistry)
+	s.registry = NewRetryRegistry(s.inner, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Microsecond})
+	s.registry.logger, _ = test.NewNullLogger()
+}
+
+func (s *retryRegistryFetchTestSuite) TestRetrySuccess() {
+	s.inner.On(`Fetch`, mock.Anything).Return(nil, errors.New(`expected error`)).Once()
+	s.inner.On(`Fetch`, mock.Anything).Return(Services{{Name: `service`}}, nil).Once()
+	services, err := s.registry.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(Services{{Name: `service`}}, services)
+	s.inner.AssertNumberOfCalls(s.T(), `Fetch`, 2)
+}
+
+func (s *retryRegistryFetchTestSuite) TestMaxAttempts() {
+	s.inner.On(`Fetch`, mock.Anything).Return(nil, errors.New(`expected error`))
+	services, err := s.registry.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `expected error`)
+	s.inner.AssertNumberOfCalls(s.T(), `Fetch`, 3)
+}
+
+func (s *retryRegistryFetchTestSuite) TestContextCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.registry.policy.BaseDelay = time.Hour
+	s.inner.On(`Fetch`, mock.Anything).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(nil, errors.New(`expected error`))
+	_, err := s.registry.Fetch(ctx)
+	s.EqualError(err, `expected error`)
+	s.inner.AssertNumberOfCalls(s.T(), `Fetch`, 1)
+}
+
+type retryRegistryRegisterTestSuite struct {
+	suite.Suite
+	inner    *MockRetryClassifierRegistry
+	registry *RetryRegistry
+	service  *Service
+}
+
+func (s *retryRegistryRegisterTestSuite) SetupTest() {
+	s.inner = new(MockRetryClassifierRegistry)
+	s.registry = NewRetryRegistry(s.inner, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Microsecond})
+	s.registry.logger, _ = test.NewNullLogger()
+	s.service = &Service{Name: `service`}
+}
+
+func (s *retryRegistryRegisterTestSuite) TestPermanentError() {
+	s.inner.On(`Register`, mock.Anything, s.service).Return(errors.New(`permanent error`))
+	s.inner.On(`IsRetryable`, mock.Anything).Return(false)
+	s.EqualError(s.registry.Register(context.Background(), s.service), `permanent error`)
+	s.inner.AssertNumberOfCalls(s.T(), `Register`, 1)
+}
+
+func (s *retryRegistryRegisterTestSuite) TestRetryableError() {
+	s.inner.On(`Register`, mock.Anything, s.service).Return(errors.New(`transient error`)).Once()
+	s.inner.On(`Register`, mock.Anything, s.service).Return(nil).Once()
+	s.inner.On(`IsRetryable`, mock.Anything).Return(true)
+	s.NoError(s.registry.Register(context.Background(), s.service))
+	s.inner.AssertNumberOfCalls(s.T(), `Register`, 2)
+}
+
+type retryRegistryDeregisterTestSuite struct {
+	suite.Suite
+	inner    *MockRegistry
+	registry *RetryRegistry
+	service  *Service
+}
+
+func (s *retryRegistryDeregisterTestSuite) SetupTest() {
+	s.inner = new(MockRegistry)
+	s.registry = NewRetryRegistry(s.inner, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Microsecond})
+	s.registry.logger, _ = test.NewNullLogger()
+	s.service = &Service{Name: `service`}
+}
+
+func (s *retryRegistryDeregisterTestSuite) TestContextError() {
+	s.inner.On(`Deregister`, mock.Anything, s.service).Return(errors.Wrap(context.Canceled, `failed`))
+	s.Error(s.registry.Deregister(context.Background(), s.service))
+	s.inner.AssertNumberOfCalls(s.T(), `Deregister`, 1)
+}
+
+func (s *retryRegistryDeregisterTestSuite) TestSuccess() {
+	s.inner.On(`Deregister`, mock.Anything, s.service).Return(nil)
+	s.NoError(s.registry.Deregister(context.Background(), s.service))
+	s.inner.AssertNumberOfCalls(s.T(), `Deregister`, 1)
+}
+
+type retryRegistryDefaultCheckIDTestSuite struct {
+	suite.Suite
+}
+
+func (s *retryRegistryDefaultCheckIDTestSuite) TestGenerator() {
+	service, check := &Service{Name: `service`}, &Check{Name: `http`}
+	inner := new(MockCheckIDGeneratorRegistry)
+	inner.On(`DefaultCheckID`, service, check).Return(`service:service:http`)
+	registry := NewRetryRegistry(inner, RetryPolicy{})
+	s.Equal(`service:service:http`, registry.DefaultCheckID(service, check))
+}
+
+func (s *retryRegistryDefaultCheckIDTestSuite) TestWithoutGenerator() {
+	registry := NewRetryRegistry(new(MockRegistry), RetryPolicy{})
+	s.Equal(``, registry.DefaultCheckID(&Service{Name: `service`}, &Check{Name: `http`}))
+}
+
+type retryRegistryWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *retryRegistryWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	registry := NewRetryRegistry(nil, RetryPolicy{})
+	registry.WithLogger(logger)
+	s.Equal(logger, registry.logger)
+}
+
+// --- Mocks ---
+
+// MockRetryClassifierRegistry is a mock type for the Registry type, which implements RetryClassifier
+type MockRetryClassifierRegistry struct {
+	MockRegistry
+}
+
+// IsRetryable provides a mock function with given fields: err
+func (_m *MockRetryClassifierRegistry) IsRetryable(err error) bool {
+	ret := _m.Called(err)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(error) bool); ok {
+		r0 = rf(err)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"time"
+)
+
+type (
+	// Schedule describes, when Scheduler calls Manager.Run. Next return time of next run after passed time.
+	// Zero time means there is no next run. Schedule is compatible with cron.Schedule from github.com/robfig/cron/v3
+	Schedule interface {
+		Next(time.Time) time.Time
+	}
+
+	// IntervalSchedule is implementation of Schedule with constant interval between runs
+	IntervalSchedule time.Duration
+)
+
+// Next return passed time with added interval
+func (i IntervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
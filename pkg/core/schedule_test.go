@@ -0,0 +1,26 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestIntervalSchedule_Next(t *testing.T) {
+	suite.Run(t, new(intervalScheduleNextTestSuite))
+}
+
+// --- Suites ---
+
+type intervalScheduleNextTestSuite struct {
+	suite.Suite
+}
+
+func (s *intervalScheduleNextTestSuite) TestNext() {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Implements((*Schedule)(nil), IntervalSchedule(time.Minute))
+	s.Equal(now.Add(time.Minute), IntervalSchedule(time.Minute).Next(now))
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -9,33 +10,416 @@ import (
 
 type (
 	// Scheduler is simple wrapper for ManagerInterface.
-	// Scheduler provides possibility to call Manager.Run with constant interval passed to time.Ticker.
+	// Scheduler calls Manager.Run immediately on start and then according to Schedule.
+	// Additional Manager.Run can be requested with Scheduler.Trigger outside of Schedule
+	// or by WatchableSource change notifications.
+	// If LeaderElector is set, Manager.Run is called only while Scheduler is leader.
+	// ManagerInterface and WatchableSource can be replaced with Scheduler.Reload without Scheduler restart.
 	Scheduler struct {
-		ticker  *time.Ticker
-		manager ManagerInterface
-		logger  LoggerInterface
+		schedule Schedule
+		jitter   time.Duration
+		random   Random
+		backoff  time.Duration
+		manager  ManagerInterface
+		logger   LoggerInterface
+		metrics  Metrics
+		source   WatchableSource
+		debounce time.Duration
+		elector  LeaderElector
+		grace    time.Duration
+		trigger  chan struct{}
+		reload   chan struct{}
+		usage    *sync.WaitGroup
+		status   SchedulerStatus
+		mu       sync.RWMutex
+	}
+
+	// SchedulerOption is a func for additional Scheduler configuration, passed to NewScheduler.
+	SchedulerOption func(*Scheduler)
+
+	// SchedulerStatus contains result of last Manager.Run called by Scheduler.
+	// ConsecutiveFailures is count of last Manager.Run calls, which returned error or Report with failures.
+	// Leader is true, while Scheduler is running and holds leadership, or leader election is not used.
+	SchedulerStatus struct {
+		Leader              bool
+		LastRunAt           time.Time
+		LastReport          *Report
+		LastError           error
+		ConsecutiveFailures int
 	}
 )
 
-// NewScheduler provides Scheduler with predefined time.Ticker, ManagerInterface and LoggerInterface
-func NewScheduler(ticker *time.Ticker, manager ManagerInterface, logger LoggerInterface) *Scheduler {
-	return &Scheduler{
-		ticker:  ticker,
-		manager: manager,
-		logger:  logger,
+// NewScheduler provides Scheduler with predefined Schedule, ManagerInterface and LoggerInterface
+func NewScheduler(schedule Schedule, manager ManagerInterface, logger LoggerInterface, options ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		schedule: schedule,
+		manager:  manager,
+		logger:   logger,
+		trigger:  make(chan struct{}, 1),
+		reload:   make(chan struct{}, 1),
+		usage:    new(sync.WaitGroup),
+		random:   NewRandom(),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// WithSchedulerMetrics sets Metrics sink for duration and result of every Manager.Run.
+// Nil Metrics means metrics are not collected.
+func WithSchedulerMetrics(metrics Metrics) SchedulerOption {
+	return func(s *Scheduler) {
+		s.metrics = metrics
+	}
+}
+
+// WithJitter sets max random delay, which is added to every run planned by Schedule.
+// Jitter prevents several Scheduler instances with the same Schedule from calling Manager.Run at the same time.
+func WithJitter(jitter time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.jitter = jitter
+	}
+}
+
+// WithRandom sets Random used for jitter. By default every Scheduler uses own Random seeded with current time,
+// so several Scheduler instances draw different jitter.
+func WithRandom(random Random) SchedulerOption {
+	return func(s *Scheduler) {
+		s.random = random
+	}
+}
+
+// WithFailureBackoff sets max delay before next run planned by Schedule after failed runs.
+// Delay until next planned run is doubled for every consecutive failed run, but not more than max.
+// Zero max means backoff is disabled.
+func WithFailureBackoff(max time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.backoff = max
+	}
+}
+
+// WithWatch sets WatchableSource, which change notifications call Manager.Run in addition to Schedule.
+// Notifications received within debounce duration after first one are coalesced into single Manager.Run,
+// zero debounce means Manager.Run is called immediately. Notifications received during Manager.Run cause single next run.
+func WithWatch(source WatchableSource, debounce time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.source = source
+		s.debounce = debounce
+	}
+}
+
+// WithLeaderElection sets LeaderElector, so Manager.Run is called only by leader among several Scheduler instances.
+// Context of Manager.Run in progress is canceled, when leadership is lost.
+func WithLeaderElection(elector LeaderElector) SchedulerOption {
+	return func(s *Scheduler) {
+		s.elector = elector
 	}
 }
 
-// Run start listen time.Ticker ticks until context.Context canceled.
-func (s *Scheduler) Run(ctx context.Context) {
+// WithShutdownGrace sets grace period for Manager.Run in progress, when context.Context passed to Scheduler.Run is canceled.
+// Manager.Run in progress is finished with separate context.Context, which is canceled after grace period.
+// Zero grace means Manager.Run in progress is canceled immediately.
+func WithShutdownGrace(grace time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.grace = grace
+	}
+}
+
+// Run calls Manager.Run immediately and start listen Schedule, Scheduler.Trigger calls and WatchableSource notifications
+// until context.Context canceled. If LeaderElector is set, Run waits for leadership before and after every term.
+// After context.Context canceled, Manager.Run in progress is finished within shutdown grace period and no new run is started.
+// Run returns error, if WatchableSource failed to start watching, LeaderElector failed to acquire leadership
+// or final Manager.Run failed.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.serve(ctx); err != nil {
+		return err
+	}
+	status := s.Status()
+	if status.ConsecutiveFailures == 0 {
+		return nil
+	}
+	if status.LastError != nil {
+		return errors.Wrap(status.LastError, `final manager run failed`)
+	}
+	return errors.New(`final manager run finished with failures`)
+}
+
+// serve contains leader election loop of Scheduler
+func (s *Scheduler) serve(ctx context.Context) error {
+	if s.elector == nil {
+		s.setLeader(true)
+		defer s.setLeader(false)
+		return s.lead(ctx, nil)
+	}
+	for {
+		s.logger.Infoln(`Waiting for leadership`)
+		lost, err := s.elector.Acquire(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, `failed to acquire leadership`)
+		}
+		s.logger.Infoln(`Leadership acquired`)
+		err = s.term(ctx, lost)
+		if rErr := s.elector.Release(); rErr != nil {
+			s.logger.Errorln(errors.Wrap(rErr, `failed to release leadership`).Error())
+		}
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+		s.logger.Warningln(`Leadership lost`)
+	}
+}
+
+// term calls Scheduler.lead until leadership is lost
+func (s *Scheduler) term(ctx context.Context, lost <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.setLeader(true)
+	defer s.setLeader(false)
+	return s.lead(ctx, lost)
+}
+
+// lead contains main loop of Scheduler. Loop is stopped, when context.Context canceled or leadership lost
+func (s *Scheduler) lead(ctx context.Context, lost <-chan struct{}) error {
+	notifications, stopWatch, err := s.watch(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		stopWatch()
+	}()
+
+	select {
+	case <-s.reload:
+	default:
+	}
+	s.logger.Infoln(`Initial manager run`)
+	s.run(ctx, lost)
+
+	timer, planned := s.plan(time.Now())
+	defer func() {
+		timer.Stop()
+	}()
+	var debounce <-chan time.Time
 	for {
 		select {
 		case <-ctx.Done():
+			return nil
+		case <-lost:
+			return nil
+		case <-planned:
+			s.run(ctx, lost)
+			timer, planned = s.plan(time.Now())
+		case <-s.trigger:
+			s.logger.Infoln(`Manager run was triggered`)
+			s.run(ctx, lost)
+		case <-s.reload:
+			stopWatch()
+			if notifications, stopWatch, err = s.watch(ctx); err != nil {
+				s.logger.Errorln(err.Error())
+				notifications, stopWatch = nil, func() {}
+			}
+			s.logger.Infoln(`Manager run was triggered by reload`)
+			s.run(ctx, lost)
+		case _, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				continue
+			}
+			if debounce == nil {
+				debounce = time.After(s.debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			s.logger.Infoln(`Manager run was triggered by source changes`)
+			s.run(ctx, lost)
+		}
+	}
+}
+
+// watch starts listening WatchableSource change notifications until returned context.CancelFunc called.
+// Nil channel is returned, if WatchableSource is not set
+func (s *Scheduler) watch(ctx context.Context) (<-chan struct{}, context.CancelFunc, error) {
+	s.mu.RLock()
+	source := s.source
+	usage := s.usage
+	usage.Add(1)
+	s.mu.RUnlock()
+	if source == nil {
+		usage.Done()
+		return nil, func() {}, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	notifications, err := source.Watch(ctx)
+	if err != nil {
+		cancel()
+		usage.Done()
+		return nil, nil, errors.Wrap(err, `failed to watch source`)
+	}
+	var once sync.Once
+	return notifications, func() {
+		once.Do(func() {
+			cancel()
+			usage.Done()
+		})
+	}, nil
+}
+
+// plan starts time.Timer for next run according to Schedule, jitter and failure backoff.
+// Nil channel is returned, if Schedule has no next run
+func (s *Scheduler) plan(now time.Time) (*time.Timer, <-chan time.Time) {
+	next := s.schedule.Next(now)
+	if next.IsZero() {
+		s.logger.Warningln(`Schedule has no next manager run`)
+		timer := time.NewTimer(0)
+		timer.Stop()
+		return timer, nil
+	}
+	timer := time.NewTimer(s.delay(next.Sub(now), s.Status().ConsecutiveFailures))
+	return timer, timer.C
+}
+
+// delay return delay before next planned run stretched by failure backoff, with added random jitter
+func (s *Scheduler) delay(delay time.Duration, failures int) time.Duration {
+	if s.backoff > 0 && failures > 0 {
+		backoff := delay
+		for i := 0; i < failures && backoff < s.backoff; i++ {
+			backoff *= 2
+		}
+		if backoff > s.backoff {
+			backoff = s.backoff
+		}
+		if backoff > delay {
+			delay = backoff
+		}
+	}
+	if s.jitter > 0 {
+		delay += time.Duration(s.random.Int63n(int64(s.jitter)))
+	}
+	return delay
+}
+
+// Trigger requests Manager.Run as soon as possible without waiting for next run planned by Schedule.
+// Trigger returns false, if previous request has not been processed yet
+func (s *Scheduler) Trigger() bool {
+	select {
+	case s.trigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reload replaces ManagerInterface and WatchableSource of Scheduler and requests Manager.Run as soon as possible.
+// SchedulerStatus, leadership and Schedule are kept, Manager.Run in progress is finished with previous ManagerInterface.
+// Nil WatchableSource means source change notifications are not listened anymore. Reload is safe for concurrent use.
+// Returned channel is closed, when previous ManagerInterface and WatchableSource are not used anymore:
+// Manager.Run in progress is finished and watching of previous WatchableSource is stopped
+func (s *Scheduler) Reload(manager ManagerInterface, source WatchableSource) <-chan struct{} {
+	s.mu.Lock()
+	usage := s.usage
+	s.manager = manager
+	s.source = source
+	s.usage = new(sync.WaitGroup)
+	s.mu.Unlock()
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+	released := make(chan struct{})
+	go func() {
+		usage.Wait()
+		close(released)
+	}()
+	return released
+}
+
+// Status return SchedulerStatus of last Manager.Run. Status is safe for concurrent use
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// run calls Manager.Run, unless context.Context is canceled or leadership is lost
+func (s *Scheduler) run(ctx context.Context, lost <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-lost:
+		return
+	default:
+	}
+
+	s.mu.RLock()
+	manager := s.manager
+	usage := s.usage
+	usage.Add(1)
+	s.mu.RUnlock()
+	defer usage.Done()
+
+	runCtx, cancel := s.runContext(ctx, lost)
+	defer cancel()
+	started := time.Now()
+	report, err := manager.Run(runCtx)
+	success := err == nil && report != nil && !report.HasFailures()
+	if s.metrics != nil {
+		s.metrics.RunFinished(time.Since(started), success)
+	}
+	s.setStatus(started, report, err, success)
+	if err != nil {
+		s.logger.Errorln(errors.Wrap(err, `failed to process manager run`).Error())
+		return
+	}
+	s.logger.Infof(`Manager run finished in %s: %s`, report.Duration(), report)
+}
+
+// runContext return context.Context for Manager.Run, which is canceled immediately when leadership is lost
+// or after shutdown grace period since passed context.Context canceled
+func (s *Scheduler) runContext(ctx context.Context, lost <-chan struct{}) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-runCtx.Done():
 			return
-		case <-s.ticker.C:
-			if err := s.manager.Run(ctx); err != nil {
-				s.logger.Errorln(errors.Wrap(err, `failed to process manager run`).Error())
+		case <-lost:
+		case <-ctx.Done():
+			if s.grace > 0 {
+				s.logger.Infof(`Shutdown requested, waiting up to %s for manager run to finish`, s.grace)
+				timer := time.NewTimer(s.grace)
+				defer timer.Stop()
+				select {
+				case <-runCtx.Done():
+					return
+				case <-lost:
+				case <-timer.C:
+					s.logger.Warningln(`Shutdown grace period expired, manager run is canceled`)
+				}
 			}
 		}
+		cancel()
+	}()
+	return runCtx, cancel
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Leader = leader
+}
+
+func (s *Scheduler) setStatus(started time.Time, report *Report, err error, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastRunAt = started
+	s.status.LastReport = report
+	s.status.LastError = err
+	if success {
+		s.status.ConsecutiveFailures = 0
+		return
 	}
+	s.status.ConsecutiveFailures++
 }
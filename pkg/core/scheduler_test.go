@@ -2,10 +2,12 @@ package core
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -17,10 +19,62 @@ func TestNewScheduler(t *testing.T) {
 	suite.Run(t, new(newSchedulerTestSuite))
 }
 
+func TestWithSchedulerMetrics(t *testing.T) {
+	suite.Run(t, new(withSchedulerMetricsTestSuite))
+}
+
+func TestWithJitter(t *testing.T) {
+	suite.Run(t, new(withJitterTestSuite))
+}
+
+func TestWithRandom(t *testing.T) {
+	suite.Run(t, new(withRandomTestSuite))
+}
+
+func TestWithFailureBackoff(t *testing.T) {
+	suite.Run(t, new(withFailureBackoffTestSuite))
+}
+
+func TestWithShutdownGrace(t *testing.T) {
+	suite.Run(t, new(withShutdownGraceTestSuite))
+}
+
+func TestWithWatch(t *testing.T) {
+	suite.Run(t, new(withWatchTestSuite))
+}
+
+func TestWithLeaderElection(t *testing.T) {
+	suite.Run(t, new(withLeaderElectionTestSuite))
+}
+
 func TestScheduler_Run(t *testing.T) {
 	suite.Run(t, new(schedulerRunTestSuite))
 }
 
+func TestScheduler_runContext(t *testing.T) {
+	suite.Run(t, new(schedulerRunContextTestSuite))
+}
+
+func TestScheduler_plan(t *testing.T) {
+	suite.Run(t, new(schedulerPlanTestSuite))
+}
+
+func TestScheduler_delay(t *testing.T) {
+	suite.Run(t, new(schedulerDelayTestSuite))
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	suite.Run(t, new(schedulerTriggerTestSuite))
+}
+
+func TestScheduler_Reload(t *testing.T) {
+	suite.Run(t, new(schedulerReloadTestSuite))
+}
+
+func TestScheduler_Status(t *testing.T) {
+	suite.Run(t, new(schedulerStatusTestSuite))
+}
+
 // --- Suites ---
 
 type newSchedulerTestSuite struct {
@@ -28,10 +82,96 @@ type newSchedulerTestSuite struct {
 }
 
 func (s *newSchedulerTestSuite) TestNewManager() {
-	s.Equal(
-		&Scheduler{nil, nil, nil},
-		NewScheduler(nil, nil, nil),
-	)
+	sc := NewScheduler(nil, nil, nil)
+	s.Nil(sc.schedule)
+	s.Nil(sc.manager)
+	s.Nil(sc.logger)
+	s.Nil(sc.metrics)
+	s.NotNil(sc.trigger)
+	s.NotNil(sc.reload)
+	s.NotNil(sc.random)
+	s.Equal(SchedulerStatus{}, sc.Status())
+}
+
+func (s *newSchedulerTestSuite) TestNewSchedulerWithOptions() {
+	metrics := new(MockMetrics)
+	sc := NewScheduler(nil, nil, nil, WithSchedulerMetrics(metrics))
+	s.Equal(metrics, sc.metrics)
+}
+
+type withSchedulerMetricsTestSuite struct {
+	suite.Suite
+}
+
+func (s *withSchedulerMetricsTestSuite) TestWithSchedulerMetrics() {
+	metrics := new(MockMetrics)
+	sc := new(Scheduler)
+	WithSchedulerMetrics(metrics)(sc)
+	s.Equal(metrics, sc.metrics)
+}
+
+type withJitterTestSuite struct {
+	suite.Suite
+}
+
+func (s *withJitterTestSuite) TestWithJitter() {
+	sc := new(Scheduler)
+	WithJitter(time.Second)(sc)
+	s.Equal(time.Second, sc.jitter)
+}
+
+type withRandomTestSuite struct {
+	suite.Suite
+}
+
+func (s *withRandomTestSuite) TestWithRandom() {
+	random := new(MockRandom)
+	sc := new(Scheduler)
+	WithRandom(random)(sc)
+	s.Equal(random, sc.random)
+}
+
+type withFailureBackoffTestSuite struct {
+	suite.Suite
+}
+
+func (s *withFailureBackoffTestSuite) TestWithFailureBackoff() {
+	sc := new(Scheduler)
+	WithFailureBackoff(time.Hour)(sc)
+	s.Equal(time.Hour, sc.backoff)
+}
+
+type withShutdownGraceTestSuite struct {
+	suite.Suite
+}
+
+func (s *withShutdownGraceTestSuite) TestWithShutdownGrace() {
+	sc := new(Scheduler)
+	WithShutdownGrace(time.Minute)(sc)
+	s.Equal(time.Minute, sc.grace)
+}
+
+type withWatchTestSuite struct {
+	suite.Suite
+}
+
+func (s *withWatchTestSuite) TestWithWatch() {
+	source := new(MockWatchableSource)
+	sc := new(Scheduler)
+	WithWatch(source, time.Second)(sc)
+	s.Equal(source, sc.source)
+	s.Equal(time.Second, sc.debounce)
+}
+
+type withLeaderElectionTestSuite struct {
+	suite.Suite
+}
+
+func (s *withLeaderElectionTestSuite) TestWithLeaderElection() {
+	elector := new(MockLeaderElector)
+	sc := new(Scheduler)
+	WithLeaderElection(elector)(sc)
+	s.Equal(elector, sc.elector)
 }
 
 type schedulerRunTestSuite struct {
@@ -46,26 +186,47 @@ func (s *schedulerRunTestSuite) SetupTest() {
 	s.manager = new(MockManagerInterface)
 	s.hook = hook
 	s.scheduler = &Scheduler{
-		ticker:  time.NewTicker(time.Microsecond * 100),
-		manager: s.manager,
-		logger:  logger,
+		schedule: IntervalSchedule(time.Microsecond * 100),
+		manager:  s.manager,
+		logger:   logger,
+		usage:    new(sync.WaitGroup),
 	}
 }
 
+func (s *schedulerRunTestSuite) TestInitialRun() {
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	s.scheduler.schedule = IntervalSchedule(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	s.Eventually(func() bool {
+		return !s.scheduler.Status().LastRunAt.IsZero()
+	}, time.Second, time.Millisecond)
+	cancel()
+	s.NoError(<-done)
+	s.Equal(`Initial manager run`, s.hook.AllEntries()[0].Message)
+	s.manager.AssertNumberOfCalls(s.T(), `Run`, 1)
+}
+
 func (s *schedulerRunTestSuite) TestWithManagerError() {
-	s.manager.On(`Run`, mock.Anything).Return(errors.New(`expected error`))
+	s.manager.On(`Run`, mock.Anything).Return(nil, errors.New(`expected error`))
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		s.scheduler.Run(ctx)
 	}()
 
-	<-time.Tick(time.Microsecond * 1000)
+	s.Eventually(func() bool {
+		entry := s.hook.LastEntry()
+		return entry != nil && entry.Message == `failed to process manager run: expected error`
+	}, time.Second, time.Millisecond)
 	cancel()
-	s.Equal(s.hook.LastEntry().Message, `failed to process manager run: expected error`)
 }
 
 func (s *schedulerRunTestSuite) TestWithoutManagerError() {
-	s.manager.On(`Run`, mock.Anything).Return(nil)
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		s.scheduler.Run(ctx)
@@ -73,26 +234,743 @@ func (s *schedulerRunTestSuite) TestWithoutManagerError() {
 
 	<-time.Tick(time.Microsecond * 100)
 	cancel()
-	s.Len(s.hook.AllEntries(), 0)
+	for _, entry := range s.hook.AllEntries() {
+		s.Equal(logrus.InfoLevel, entry.Level)
+	}
+}
+
+func (s *schedulerRunTestSuite) TestMetricsWithManagerError() {
+	s.manager.On(`Run`, mock.Anything).Return(nil, errors.New(`expected error`))
+	metrics := new(MockMetrics)
+	metrics.On(`RunFinished`, mock.AnythingOfType(`time.Duration`), false).Once()
+	s.scheduler.metrics = metrics
+
+	s.scheduler.run(context.Background(), nil)
+	metrics.AssertExpectations(s.T())
+}
+
+func (s *schedulerRunTestSuite) TestMetricsWithFailures() {
+	report := NewReport()
+	report.Add(``, `service`, ActionFailed, errors.New(`expected error`), 0)
+	report.Finish()
+	s.manager.On(`Run`, mock.Anything).Return(report, nil)
+	metrics := new(MockMetrics)
+	metrics.On(`RunFinished`, mock.AnythingOfType(`time.Duration`), false).Once()
+	s.scheduler.metrics = metrics
+
+	s.scheduler.run(context.Background(), nil)
+	metrics.AssertExpectations(s.T())
+}
+
+func (s *schedulerRunTestSuite) TestMetricsWithoutManagerError() {
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	metrics := new(MockMetrics)
+	metrics.On(`RunFinished`, mock.AnythingOfType(`time.Duration`), true).Once()
+	s.scheduler.metrics = metrics
+
+	s.scheduler.run(context.Background(), nil)
+	metrics.AssertExpectations(s.T())
+}
+
+func (s *schedulerRunTestSuite) TestErrorWatch() {
+	source := new(MockWatchableSource)
+	source.On(`Watch`, mock.Anything).Return(nil, errors.New(`expected error`))
+	s.scheduler.source = source
+
+	err := s.scheduler.Run(context.Background())
+	s.EqualError(err, `failed to watch source: expected error`)
+	s.manager.AssertNotCalled(s.T(), `Run`, mock.Anything)
+}
+
+func (s *schedulerRunTestSuite) TestWatch() {
+	notifications := make(chan struct{})
+	source := new(MockWatchableSource)
+	source.On(`Watch`, mock.Anything).Return((<-chan struct{})(notifications), nil)
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	s.scheduler.schedule = IntervalSchedule(time.Hour)
+	s.scheduler.source = source
+	s.scheduler.debounce = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	notifications <- struct{}{}
+	initial := s.scheduler.Status().LastRunAt
+	notifications <- struct{}{}
+	notifications <- struct{}{}
+	s.Eventually(func() bool {
+		return s.scheduler.Status().LastRunAt.After(initial)
+	}, time.Second, time.Millisecond)
+	close(notifications)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	s.NoError(<-done)
+	s.manager.AssertNumberOfCalls(s.T(), `Run`, 2)
+}
+
+func (s *schedulerRunTestSuite) TestLeaderWithoutElection() {
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	s.Eventually(func() bool {
+		return s.scheduler.Status().Leader
+	}, time.Second, time.Millisecond)
+	cancel()
+	s.NoError(<-done)
+	s.False(s.scheduler.Status().Leader)
+}
+
+func (s *schedulerRunTestSuite) TestErrorAcquire() {
+	elector := new(MockLeaderElector)
+	elector.On(`Acquire`, mock.Anything).Return(nil, errors.New(`expected error`))
+	s.scheduler.elector = elector
+
+	err := s.scheduler.Run(context.Background())
+	s.EqualError(err, `failed to acquire leadership: expected error`)
+	s.manager.AssertNotCalled(s.T(), `Run`, mock.Anything)
+	elector.AssertNotCalled(s.T(), `Release`)
+}
+
+func (s *schedulerRunTestSuite) TestAcquireCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	elector := new(MockLeaderElector)
+	elector.On(`Acquire`, ctx).Return(nil, context.Canceled)
+	s.scheduler.elector = elector
+
+	s.NoError(s.scheduler.Run(ctx))
+	s.manager.AssertNotCalled(s.T(), `Run`, mock.Anything)
+}
+
+func (s *schedulerRunTestSuite) TestLeadershipLost() {
+	lost := make(chan struct{})
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	elector := new(MockLeaderElector)
+	elector.On(`Acquire`, mock.Anything).Return((<-chan struct{})(lost), nil).Once()
+	elector.On(`Acquire`, mock.Anything).Return(nil, context.Canceled).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Once()
+	elector.On(`Release`).Return(nil).Once()
+	s.manager.On(`Run`, mock.Anything).Return(nil, context.Canceled).Run(func(args mock.Arguments) {
+		close(started)
+		<-args.Get(0).(context.Context).Done()
+		close(canceled)
+	}).Once()
+	s.scheduler.schedule = IntervalSchedule(time.Hour)
+	s.scheduler.elector = elector
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	<-started
+	s.True(s.scheduler.Status().Leader)
+	close(lost)
+	<-canceled
+	s.Eventually(func() bool {
+		return !s.scheduler.Status().Leader
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	s.EqualError(<-done, `final manager run failed: context canceled`)
+	elector.AssertExpectations(s.T())
+	s.Equal(1, s.scheduler.Status().ConsecutiveFailures)
+}
+
+func (s *schedulerRunTestSuite) TestShutdownGrace() {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	s.manager.On(`Run`, mock.Anything).Return(NewReport(), nil).Run(func(args mock.Arguments) {
+		close(started)
+		<-finish
+		s.NoError(args.Get(0).(context.Context).Err())
+	}).Once()
+	s.scheduler.schedule = IntervalSchedule(time.Millisecond)
+	s.scheduler.grace = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(finish)
+	s.NoError(<-done)
+	s.manager.AssertNumberOfCalls(s.T(), `Run`, 1)
+}
+
+func (s *schedulerRunTestSuite) TestShutdownGraceExpired() {
+	started := make(chan struct{})
+	s.manager.On(`Run`, mock.Anything).Return(nil, context.Canceled).Run(func(args mock.Arguments) {
+		close(started)
+		<-args.Get(0).(context.Context).Done()
+	}).Once()
+	s.scheduler.grace = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	s.EqualError(<-done, `final manager run failed: context canceled`)
+	var messages []string
+	for _, entry := range s.hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	s.Contains(messages, `Shutdown grace period expired, manager run is canceled`)
+}
+
+func (s *schedulerRunTestSuite) TestFinalRunWithFailures() {
+	report := NewReport()
+	report.Add(``, `service`, ActionFailed, errors.New(`expected error`), 0)
+	report.Finish()
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	s.manager.On(`Run`, mock.Anything).Return(report, nil).Run(func(args mock.Arguments) {
+		close(started)
+		<-finish
+	}).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.scheduler.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	close(finish)
+	s.EqualError(<-done, `final manager run finished with failures`)
+}
+
+func (s *schedulerRunTestSuite) TestCanceledBeforeStart() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.NoError(s.scheduler.Run(ctx))
+	s.manager.AssertNotCalled(s.T(), `Run`, mock.Anything)
+}
+
+type schedulerRunContextTestSuite struct {
+	suite.Suite
+	scheduler *Scheduler
+}
+
+func (s *schedulerRunContextTestSuite) SetupTest() {
+	logger, _ := test.NewNullLogger()
+	s.scheduler = &Scheduler{
+		logger: logger,
+		grace:  time.Minute,
+	}
+}
+
+func (s *schedulerRunContextTestSuite) TestFinished() {
+	runCtx, cancel := s.scheduler.runContext(context.Background(), nil)
+	s.NoError(runCtx.Err())
+	cancel()
+	s.Equal(context.Canceled, runCtx.Err())
+}
+
+func (s *schedulerRunContextTestSuite) TestLeadershipLost() {
+	lost := make(chan struct{})
+	runCtx, cancel := s.scheduler.runContext(context.Background(), lost)
+	defer cancel()
+	close(lost)
+	s.Eventually(func() bool {
+		return runCtx.Err() != nil
+	}, time.Second, time.Millisecond)
+}
+
+func (s *schedulerRunContextTestSuite) TestLeadershipLostDuringGrace() {
+	ctx, stop := context.WithCancel(context.Background())
+	lost := make(chan struct{})
+	runCtx, cancel := s.scheduler.runContext(ctx, lost)
+	defer cancel()
+	stop()
+	time.Sleep(10 * time.Millisecond)
+	s.NoError(runCtx.Err())
+	close(lost)
+	s.Eventually(func() bool {
+		return runCtx.Err() != nil
+	}, time.Second, time.Millisecond)
+}
+
+func (s *schedulerRunContextTestSuite) TestWithoutGrace() {
+	ctx, stop := context.WithCancel(context.Background())
+	s.scheduler.grace = 0
+	runCtx, cancel := s.scheduler.runContext(ctx, nil)
+	defer cancel()
+	stop()
+	s.Eventually(func() bool {
+		return runCtx.Err() != nil
+	}, time.Second, time.Millisecond)
+}
+
+type schedulerPlanTestSuite struct {
+	suite.Suite
+}
+
+func (s *schedulerPlanTestSuite) TestPlan() {
+	sc := &Scheduler{schedule: IntervalSchedule(time.Millisecond)}
+	timer, planned := sc.plan(time.Now())
+	defer timer.Stop()
+	s.NotNil(planned)
+	<-planned
+}
+
+func (s *schedulerPlanTestSuite) TestNoNextRun() {
+	logger, hook := test.NewNullLogger()
+	schedule := new(MockSchedule)
+	schedule.On(`Next`, mock.Anything).Return(time.Time{})
+	sc := &Scheduler{schedule: schedule, logger: logger}
+	timer, planned := sc.plan(time.Now())
+	defer timer.Stop()
+	s.Nil(planned)
+	s.Equal(`Schedule has no next manager run`, hook.LastEntry().Message)
+}
+
+type schedulerDelayTestSuite struct {
+	suite.Suite
+}
+
+func (s *schedulerDelayTestSuite) TestWithoutBackoff() {
+	sc := new(Scheduler)
+	s.Equal(time.Minute, sc.delay(time.Minute, 3))
+}
+
+func (s *schedulerDelayTestSuite) TestBackoff() {
+	sc := &Scheduler{backoff: time.Hour}
+	s.Equal(time.Minute, sc.delay(time.Minute, 0))
+	s.Equal(2*time.Minute, sc.delay(time.Minute, 1))
+	s.Equal(8*time.Minute, sc.delay(time.Minute, 3))
+	s.Equal(time.Hour, sc.delay(time.Minute, 10))
+}
+
+func (s *schedulerDelayTestSuite) TestBackoffLessThanDelay() {
+	sc := &Scheduler{backoff: time.Minute}
+	s.Equal(time.Hour, sc.delay(time.Hour, 3))
+}
+
+func (s *schedulerDelayTestSuite) TestJitter() {
+	random := new(MockRandom)
+	random.On(`Int63n`, int64(time.Second)).Return(int64(300 * time.Millisecond))
+	sc := &Scheduler{jitter: time.Second, random: random}
+	s.Equal(time.Minute+300*time.Millisecond, sc.delay(time.Minute, 0))
+	random.AssertNumberOfCalls(s.T(), `Int63n`, 1)
+}
+
+func (s *schedulerDelayTestSuite) TestJitterRange() {
+	sc := &Scheduler{jitter: time.Second, random: NewRandom()}
+	for i := 0; i < 100; i++ {
+		delay := sc.delay(time.Minute, 0)
+		s.GreaterOrEqual(int64(delay), int64(time.Minute))
+		s.Less(int64(delay), int64(time.Minute+time.Second))
+	}
+}
+
+type schedulerTriggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *schedulerTriggerTestSuite) TestTrigger() {
+	logger, hook := test.NewNullLogger()
+	manager := new(MockManagerInterface)
+	manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	sc := NewScheduler(IntervalSchedule(time.Hour), manager, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Run(ctx)
+
+	s.True(sc.Trigger())
+	s.Eventually(func() bool {
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == `Manager run was triggered` {
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+}
+
+func (s *schedulerTriggerTestSuite) TestPending() {
+	sc := NewScheduler(nil, nil, nil)
+	s.True(sc.Trigger())
+	s.False(sc.Trigger())
+}
+
+type schedulerReloadTestSuite struct {
+	suite.Suite
+}
+
+func (s *schedulerReloadTestSuite) TestReload() {
+	manager := new(MockManagerInterface)
+	source := new(MockWatchableSource)
+	sc := NewScheduler(nil, nil, nil)
+	sc.Reload(manager, source)
+	sc.Reload(manager, source)
+	s.Equal(manager, sc.manager)
+	s.Equal(source, sc.source)
+	s.Len(sc.reload, 1)
+}
+
+func (s *schedulerReloadTestSuite) TestRun() {
+	logger, hook := test.NewNullLogger()
+	var watched context.Context
+	previousSource := new(MockWatchableSource)
+	previousSource.On(`Watch`, mock.Anything).Return((<-chan struct{})(make(chan struct{})), nil).Run(func(args mock.Arguments) {
+		watched = args.Get(0).(context.Context)
+	}).Once()
+	previous := new(MockManagerInterface)
+	previous.On(`Run`, mock.Anything).Return(NewReport(), nil).Once()
+	source := new(MockWatchableSource)
+	source.On(`Watch`, mock.Anything).Return((<-chan struct{})(make(chan struct{})), nil).Once()
+	manager := new(MockManagerInterface)
+	manager.On(`Run`, mock.Anything).Return(NewReport(), nil).Once()
+	sc := NewScheduler(IntervalSchedule(time.Hour), previous, logger, WithWatch(previousSource, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sc.Run(ctx)
+	}()
+	s.Eventually(func() bool {
+		return !sc.Status().LastRunAt.IsZero()
+	}, time.Second, time.Millisecond)
+	initial := sc.Status().LastRunAt
+
+	sc.Reload(manager, source)
+	s.Eventually(func() bool {
+		return sc.Status().LastRunAt.After(initial)
+	}, time.Second, time.Millisecond)
+	cancel()
+	s.NoError(<-done)
+	s.Error(watched.Err())
+	previous.AssertExpectations(s.T())
+	previousSource.AssertExpectations(s.T())
+	manager.AssertExpectations(s.T())
+	source.AssertExpectations(s.T())
+
+	var messages []string
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	s.Contains(messages, `Manager run was triggered by reload`)
+}
+
+func (s *schedulerReloadTestSuite) TestReleasedIdle() {
+	sc := NewScheduler(nil, nil, nil)
+	released := sc.Reload(new(MockManagerInterface), nil)
+	s.Eventually(func() bool {
+		select {
+		case <-released:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func (s *schedulerReloadTestSuite) TestReleasedAfterRun() {
+	logger, _ := test.NewNullLogger()
+	var watched context.Context
+	previousSource := new(MockWatchableSource)
+	previousSource.On(`Watch`, mock.Anything).Return((<-chan struct{})(make(chan struct{})), nil).Run(func(args mock.Arguments) {
+		watched = args.Get(0).(context.Context)
+	}).Once()
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	previous := new(MockManagerInterface)
+	previous.On(`Run`, mock.Anything).Return(NewReport(), nil).Run(func(args mock.Arguments) {
+		close(started)
+		<-finish
+	}).Once()
+	manager := new(MockManagerInterface)
+	manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	sc := NewScheduler(IntervalSchedule(time.Hour), previous, logger, WithWatch(previousSource, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sc.Run(ctx)
+	}()
+	<-started
+
+	released := sc.Reload(manager, nil)
+	select {
+	case <-released:
+		s.Fail(`previous manager is released while run is in progress`)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(finish)
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		s.Fail(`previous manager is not released after run`)
+	}
+	s.Error(watched.Err())
+	cancel()
+	s.NoError(<-done)
+	previous.AssertExpectations(s.T())
+	previousSource.AssertExpectations(s.T())
+}
+
+func (s *schedulerReloadTestSuite) TestErrorWatch() {
+	logger, hook := test.NewNullLogger()
+	source := new(MockWatchableSource)
+	source.On(`Watch`, mock.Anything).Return(nil, errors.New(`expected error`)).Once()
+	manager := new(MockManagerInterface)
+	manager.On(`Run`, mock.Anything).Return(NewReport(), nil)
+	sc := NewScheduler(IntervalSchedule(time.Hour), manager, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sc.Run(ctx)
+	}()
+	s.Eventually(func() bool {
+		return !sc.Status().LastRunAt.IsZero()
+	}, time.Second, time.Millisecond)
+	initial := sc.Status().LastRunAt
+
+	sc.Reload(manager, source)
+	s.Eventually(func() bool {
+		return sc.Status().LastRunAt.After(initial)
+	}, time.Second, time.Millisecond)
+	cancel()
+	s.NoError(<-done)
+
+	var messages []string
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	s.Contains(messages, `failed to watch source: expected error`)
+}
+
+type schedulerStatusTestSuite struct {
+	suite.Suite
+	manager   *MockManagerInterface
+	scheduler *Scheduler
+}
+
+func (s *schedulerStatusTestSuite) SetupTest() {
+	logger, _ := test.NewNullLogger()
+	s.manager = new(MockManagerInterface)
+	s.scheduler = NewScheduler(nil, s.manager, logger)
+}
+
+func (s *schedulerStatusTestSuite) TestConsecutiveFailures() {
+	failed := NewReport()
+	failed.Add(``, `service`, ActionFailed, errors.New(`expected error`), 0)
+	failed.Finish()
+	s.manager.On(`Run`, mock.Anything).Return(nil, errors.New(`expected error`)).Once()
+	s.manager.On(`Run`, mock.Anything).Return(failed, nil).Once()
+
+	s.scheduler.run(context.Background(), nil)
+	status := s.scheduler.Status()
+	s.Equal(1, status.ConsecutiveFailures)
+	s.EqualError(status.LastError, `expected error`)
+	s.Nil(status.LastReport)
+
+	s.scheduler.run(context.Background(), nil)
+	status = s.scheduler.Status()
+	s.Equal(2, status.ConsecutiveFailures)
+	s.NoError(status.LastError)
+	s.Equal(failed, status.LastReport)
+	s.False(status.LastRunAt.IsZero())
+}
+
+func (s *schedulerStatusTestSuite) TestResetFailures() {
+	report := NewReport()
+	report.Finish()
+	s.manager.On(`Run`, mock.Anything).Return(nil, errors.New(`expected error`)).Once()
+	s.manager.On(`Run`, mock.Anything).Return(report, nil).Once()
+
+	s.scheduler.run(context.Background(), nil)
+	s.scheduler.run(context.Background(), nil)
+	status := s.scheduler.Status()
+	s.Equal(0, status.ConsecutiveFailures)
+	s.Equal(report, status.LastReport)
 }
 
 // --- Mocks ---
 
+// MockSchedule is an autogenerated mock type for the Schedule type
+type MockSchedule struct {
+	mock.Mock
+}
+
+// Next provides a mock function with given fields: _a0
+func (_m *MockSchedule) Next(_a0 time.Time) time.Time {
+	ret := _m.Called(_a0)
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func(time.Time) time.Time); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	return r0
+}
+
+// MockLeaderElector is an autogenerated mock type for the LeaderElector type
+type MockLeaderElector struct {
+	mock.Mock
+}
+
+// Acquire provides a mock function with given fields: ctx
+func (_m *MockLeaderElector) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan struct{}); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Release provides a mock function with given fields:
+func (_m *MockLeaderElector) Release() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockWatchableSource is an autogenerated mock type for the WatchableSource type
+type MockWatchableSource struct {
+	mock.Mock
+}
+
+// Fetch provides a mock function with given fields: ctx
+func (_m *MockWatchableSource) Fetch(ctx context.Context) (Services, error) {
+	ret := _m.Called(ctx)
+
+	var r0 Services
+	if rf, ok := ret.Get(0).(func(context.Context) Services); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Services)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Watch provides a mock function with given fields: ctx
+func (_m *MockWatchableSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan struct{}); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // MockManagerInterface is an autogenerated mock type for the ManagerInterface type
 type MockManagerInterface struct {
 	mock.Mock
 }
 
+// Plan provides a mock function with given fields: ctx
+func (_m *MockManagerInterface) Plan(ctx context.Context) ([]*Plan, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*Plan
+	if rf, ok := ret.Get(0).(func(context.Context) []*Plan); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Plan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Run provides a mock function with given fields: ctx
-func (_m *MockManagerInterface) Run(ctx context.Context) error {
+func (_m *MockManagerInterface) Run(ctx context.Context) (*Report, error) {
 	ret := _m.Called(ctx)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+	var r0 *Report
+	if rf, ok := ret.Get(0).(func(context.Context) *Report); ok {
 		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Report)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
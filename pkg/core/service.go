@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"reflect"
+	"sort"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cast"
@@ -26,6 +28,7 @@ type (
 		Tags    *[]string          `json:",omitempty"`
 		Meta    *map[string]string `json:",omitempty"`
 		Node    *Node              `json:",omitempty"`
+		Checks  *Checks            `json:",omitempty"`
 	}
 
 	// Services is simple helper for hold slice of Service's
@@ -44,6 +47,14 @@ func (s *Service) Validate(ctx context.Context, checks ...ValidationFunc) error
 	if s.Address == `` {
 		return errors.Errorf(`service "%s" field "address" is required and cannot be empty`, s.Name)
 	}
+	for index, check := range derefChecks(s.Checks) {
+		if check == nil {
+			return errors.Errorf(`service "%s" check #%d cannot be empty`, s.Name, index)
+		}
+		if err := check.Validate(); err != nil {
+			return errors.Wrapf(err, `service "%s" check #%d is invalid`, s.Name, index)
+		}
+	}
 	for _, check := range checks {
 		if err := check(ctx, s); err != nil {
 			return errors.Wrapf(err, `service "%s" custom check failed`, s.Name)
@@ -61,6 +72,93 @@ func (s *Service) RegistrationID() string {
 	return id
 }
 
+// Clone return deep copy of Service, so copy can be changed without changes of original Service
+func (s *Service) Clone() *Service {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	if s.ID != nil {
+		id := *s.ID
+		clone.ID = &id
+	}
+	if s.Port != nil {
+		port := *s.Port
+		clone.Port = &port
+	}
+	if s.Tags != nil {
+		tags := append([]string{}, *s.Tags...)
+		clone.Tags = &tags
+	}
+	clone.Meta = cloneMap(s.Meta)
+	if s.Node != nil {
+		node := *s.Node
+		if s.Node.Datacenter != nil {
+			datacenter := *s.Node.Datacenter
+			node.Datacenter = &datacenter
+		}
+		node.NodeMeta = cloneMap(s.Node.NodeMeta)
+		clone.Node = &node
+	}
+	if s.Checks != nil {
+		checks := s.Checks.Clone()
+		clone.Checks = &checks
+	}
+	return &clone
+}
+
+// Equal compares Service with other Service in canonical form, so Service fetched from Source can be compared
+// with Service fetched from Registry:
+// - Service.RegistrationID is used instead of Service.ID
+// - nil Service.Port is equal to zero port
+// - Service.Tags are compared as unordered set of unique values, nil is equal to empty list
+// - nil Service.Meta is equal to empty map
+// - Service.Node is compared by Node.Equal only when both Services define it, e.g. consul agent does not store node
+// - Service.Checks are compared by Checks.Equal
+func (s *Service) Equal(other *Service) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if s.RegistrationID() != other.RegistrationID() || s.Name != other.Name || s.Address != other.Address {
+		return false
+	}
+	if derefInt(s.Port) != derefInt(other.Port) {
+		return false
+	}
+	if !reflect.DeepEqual(canonicalTags(s.Tags), canonicalTags(other.Tags)) {
+		return false
+	}
+	if !equalMaps(s.Meta, other.Meta) {
+		return false
+	}
+	if !derefChecks(s.Checks).Equal(derefChecks(other.Checks)) {
+		return false
+	}
+	if s.Node == nil || other.Node == nil {
+		return true
+	}
+	return s.Node.Equal(other.Node)
+}
+
+// Equal compares Node with other Node in canonical form.
+// Node.Datacenter and Node.NodeMeta are compared only when both nodes define them,
+// because Registry can fill them with default values.
+func (n *Node) Equal(other *Node) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+	if n.Node != other.Node || n.Address != other.Address {
+		return false
+	}
+	if n.Datacenter != nil && other.Datacenter != nil && *n.Datacenter != *other.Datacenter {
+		return false
+	}
+	if n.NodeMeta != nil && other.NodeMeta != nil && !equalMaps(n.NodeMeta, other.NodeMeta) {
+		return false
+	}
+	return true
+}
+
 // IDs return slice of Service.RegistrationID
 func (s Services) IDs() []string {
 	ids := funk.Map(s, func(service *Service) string {
@@ -69,6 +167,19 @@ func (s Services) IDs() []string {
 	return cast.ToStringSlice(ids)
 }
 
+// Duplicates return list of Service.RegistrationID, which are used by more than one Service
+func (s Services) Duplicates() []string {
+	seen := make(map[string]int)
+	duplicates := make([]string, 0)
+	for _, id := range s.IDs() {
+		seen[id]++
+		if seen[id] == 2 {
+			duplicates = append(duplicates, id)
+		}
+	}
+	return duplicates
+}
+
 // Lookup return Service by Service.RegistrationID, if found
 func (s Services) Lookup(id string) *Service {
 	for _, service := range s {
@@ -78,3 +189,47 @@ func (s Services) Lookup(id string) *Service {
 	}
 	return nil
 }
+
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func derefChecks(checks *Checks) Checks {
+	if checks == nil {
+		return nil
+	}
+	return *checks
+}
+
+func canonicalTags(tags *[]string) []string {
+	result := make([]string, 0)
+	if tags != nil {
+		result = append(result, funk.UniqString(*tags)...)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func equalMaps(left *map[string]string, right *map[string]string) bool {
+	if left == nil || right == nil {
+		return (left == nil || len(*left) == 0) && (right == nil || len(*right) == 0)
+	}
+	if len(*left) == 0 && len(*right) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(*left, *right)
+}
+
+func cloneMap(values *map[string]string) *map[string]string {
+	if values == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(*values))
+	for key, value := range *values {
+		clone[key] = value
+	}
+	return &clone
+}
@@ -19,10 +19,26 @@ func TestService_RegistrationID(t *testing.T) {
 	suite.Run(t, new(serviceRegistrationIDTestSuite))
 }
 
+func TestService_Clone(t *testing.T) {
+	suite.Run(t, new(serviceCloneTestSuite))
+}
+
+func TestService_Equal(t *testing.T) {
+	suite.Run(t, new(serviceEqualTestSuite))
+}
+
+func TestNode_Equal(t *testing.T) {
+	suite.Run(t, new(nodeEqualTestSuite))
+}
+
 func TestServices_IDs(t *testing.T) {
 	suite.Run(t, new(servicesIDsTestSuite))
 }
 
+func TestServices_Duplicates(t *testing.T) {
+	suite.Run(t, new(servicesDuplicatesTestSuite))
+}
+
 func TestServices_Lookup(t *testing.T) {
 	suite.Run(t, new(servicesLookupSuite))
 }
@@ -57,6 +73,18 @@ func (s *serviceValidateTestSuite) TestCustomValidation() {
 	}))
 }
 
+func (s *serviceValidateTestSuite) TestInvalidCheck() {
+	s.service.Name = `service`
+	s.service.Address = `127.0.0.1`
+	s.service.Checks = &Checks{{Name: `check`}}
+	s.EqualError(
+		s.service.Validate(context.Background()),
+		`service "service" check #0 is invalid: check "check" must contain exactly one of fields "http", "tcp", "grpc" or "ttl"`,
+	)
+	s.service.Checks = &Checks{nil}
+	s.EqualError(s.service.Validate(context.Background()), `service "service" check #0 cannot be empty`)
+}
+
 func (s *serviceValidateTestSuite) TestValidationPassed() {
 	s.service.Name = `service`
 	s.service.Address = `127.0.0.1`
@@ -83,6 +111,143 @@ func (s serviceRegistrationIDTestSuite) TestIDFromID() {
 	s.Equal(*s.service.ID, s.service.RegistrationID())
 }
 
+type serviceCloneTestSuite struct {
+	suite.Suite
+}
+
+func (s *serviceCloneTestSuite) TestNil() {
+	var service *Service
+	s.Nil(service.Clone())
+}
+
+func (s *serviceCloneTestSuite) TestClone() {
+	service := &Service{
+		Name:    `service`,
+		Address: `127.0.0.1`,
+		ID:      ptr.String(`id`),
+		Port:    ptr.Int(80),
+		Tags:    &[]string{`tag`},
+		Meta:    &map[string]string{`key`: `value`},
+		Node: &Node{
+			Node:       `node`,
+			Address:    `127.0.0.1`,
+			Datacenter: ptr.String(`dc1`),
+			NodeMeta:   &map[string]string{`key`: `value`},
+		},
+		Checks: &Checks{{Name: `check`, TTL: ptr.String(`30s`)}},
+	}
+	clone := service.Clone()
+	s.Equal(service, clone)
+
+	*clone.ID = `other`
+	*clone.Port = 81
+	(*clone.Tags)[0] = `other`
+	(*clone.Meta)[`key`] = `other`
+	*clone.Node.Datacenter = `dc2`
+	(*clone.Node.NodeMeta)[`key`] = `other`
+	*(*clone.Checks)[0].TTL = `1m`
+	s.Equal(`id`, *service.ID)
+	s.Equal(80, *service.Port)
+	s.Equal([]string{`tag`}, *service.Tags)
+	s.Equal(map[string]string{`key`: `value`}, *service.Meta)
+	s.Equal(`dc1`, *service.Node.Datacenter)
+	s.Equal(map[string]string{`key`: `value`}, *service.Node.NodeMeta)
+	s.Equal(`30s`, *(*service.Checks)[0].TTL)
+}
+
+type serviceEqualTestSuite struct {
+	suite.Suite
+}
+
+func (s *serviceEqualTestSuite) TestNil() {
+	var service *Service
+	s.True(service.Equal(nil))
+	s.False(service.Equal(&Service{}))
+	s.False((&Service{}).Equal(nil))
+}
+
+func (s *serviceEqualTestSuite) TestCanonicalForm() {
+	emptyTags := []string{}
+	emptyMeta := map[string]string{}
+	s.True((&Service{Name: `name`, Address: `127.0.0.1`}).Equal(&Service{
+		Name:    `name`,
+		Address: `127.0.0.1`,
+		ID:      ptr.String(`name`),
+		Port:    ptr.Int(0),
+		Tags:    &emptyTags,
+		Meta:    &emptyMeta,
+	}))
+}
+
+func (s *serviceEqualTestSuite) TestTagsOrder() {
+	left := []string{`tag-1`, `tag-2`, `tag-1`}
+	right := []string{`tag-2`, `tag-1`}
+	s.True((&Service{Name: `name`, Tags: &left}).Equal(&Service{Name: `name`, Tags: &right}))
+}
+
+func (s *serviceEqualTestSuite) TestDifferent() {
+	base := Service{Name: `name`, Address: `127.0.0.1`}
+	tags := []string{`tag`}
+	meta := map[string]string{`key`: `value`}
+	for _, other := range []Service{
+		{Name: `other`, Address: `127.0.0.1`},
+		{Name: `name`, Address: `127.0.0.2`},
+		{Name: `name`, Address: `127.0.0.1`, ID: ptr.String(`id`)},
+		{Name: `name`, Address: `127.0.0.1`, Port: ptr.Int(80)},
+		{Name: `name`, Address: `127.0.0.1`, Tags: &tags},
+		{Name: `name`, Address: `127.0.0.1`, Meta: &meta},
+		{Name: `name`, Address: `127.0.0.1`, Checks: &Checks{{Name: `check`}}},
+	} {
+		other := other
+		s.False(base.Equal(&other))
+		s.False(other.Equal(&base))
+	}
+}
+
+func (s *serviceEqualTestSuite) TestNodeMissing() {
+	base := Service{Name: `name`, Address: `127.0.0.1`}
+	other := Service{Name: `name`, Address: `127.0.0.1`, Node: &Node{Node: `node`, Address: `127.0.0.1`}}
+	s.True(base.Equal(&other))
+	s.True(other.Equal(&base))
+}
+
+func (s *serviceEqualTestSuite) TestNodeDifferent() {
+	base := Service{Name: `name`, Address: `127.0.0.1`, Node: &Node{Node: `node`, Address: `127.0.0.1`}}
+	other := Service{Name: `name`, Address: `127.0.0.1`, Node: &Node{Node: `other`, Address: `127.0.0.1`}}
+	s.False(base.Equal(&other))
+	s.False(other.Equal(&base))
+}
+
+type nodeEqualTestSuite struct {
+	suite.Suite
+}
+
+func (s *nodeEqualTestSuite) TestNil() {
+	var node *Node
+	s.True(node.Equal(nil))
+	s.False(node.Equal(&Node{}))
+}
+
+func (s *nodeEqualTestSuite) TestOptionalFields() {
+	meta := map[string]string{`key`: `value`}
+	s.True((&Node{Node: `node`, Address: `127.0.0.1`}).Equal(&Node{
+		Node:       `node`,
+		Address:    `127.0.0.1`,
+		Datacenter: ptr.String(`dc-1`),
+		NodeMeta:   &meta,
+	}))
+}
+
+func (s *nodeEqualTestSuite) TestDifferent() {
+	otherMeta := map[string]string{`key`: `other`}
+	meta := map[string]string{`key`: `value`}
+	base := Node{Node: `node`, Address: `127.0.0.1`, Datacenter: ptr.String(`dc-1`), NodeMeta: &meta}
+	s.False(base.Equal(&Node{Node: `other`, Address: `127.0.0.1`}))
+	s.False(base.Equal(&Node{Node: `node`, Address: `127.0.0.2`}))
+	s.False(base.Equal(&Node{Node: `node`, Address: `127.0.0.1`, Datacenter: ptr.String(`dc-2`)}))
+	s.False(base.Equal(&Node{Node: `node`, Address: `127.0.0.1`, NodeMeta: &otherMeta}))
+}
+
 type servicesIDsTestSuite struct {
 	suite.Suite
 	services Services
@@ -104,6 +269,24 @@ func (s *servicesIDsTestSuite) TestNonEmptyList() {
 	s.Contains(ids, `id-1`)
 }
 
+type servicesDuplicatesTestSuite struct {
+	suite.Suite
+}
+
+func (s *servicesDuplicatesTestSuite) TestEmptyList() {
+	s.Empty(Services{}.Duplicates())
+}
+
+func (s *servicesDuplicatesTestSuite) TestDuplicates() {
+	services := Services{
+		{Name: `name-1`},
+		{Name: `name-2`, ID: ptr.String(`name-1`)},
+		{Name: `name-1`},
+		{Name: `name-3`},
+	}
+	s.Equal([]string{`name-1`}, services.Duplicates())
+}
+
 type servicesLookupSuite struct {
 	suite.Suite
 	services Services
@@ -10,4 +10,13 @@ type (
 	Source interface {
 		Fetch(ctx context.Context) (Services, error)
 	}
+
+	// WatchableSource is Source, which can notify about changes, e.g. with filesystem events or blocking queries.
+	// Watch must return channel, which receives value on every change and is closed when context.Context canceled.
+	// Notifications do not contain Services, Scheduler calls Manager.Run, which calls Source.Fetch as usual.
+	// Watch may return nil channel, if notifications are disabled for Source.
+	WatchableSource interface {
+		Source
+		Watch(ctx context.Context) (<-chan struct{}, error)
+	}
 )
@@ -0,0 +1,250 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agrea/ptr"
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/consul"
+	"github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+)
+
+const (
+	// consulServiceName is a name of Consul servers own service, which is never mirrored
+	consulServiceName = `consul`
+)
+
+type (
+	// Catalog interface provide common function for work with Consul HTTP API /v1/catalog
+	Catalog interface {
+		Services(*api.QueryOptions) (map[string][]string, *api.QueryMeta, error)
+		ServiceMultipleTags(string, []string, *api.QueryOptions) ([]*api.CatalogService, *api.QueryMeta, error)
+	}
+
+	// Query selects services from catalog
+	// - Services is a list of service names, empty list means all services except "consul"
+	// - Tags is a list of tags, which service instance must contain
+	// - Filter is a Consul filter expression for /v1/catalog/service endpoint, e.g. ServiceMeta.env == "prod"
+	// - ExcludeTag skips instances with tag, e.g. registered by pinchy itself, empty value means disabled
+	Query struct {
+		Services   []string
+		Tags       []string
+		Filter     string
+		ExcludeTag string
+	}
+
+	// Watch configures blocking queries for Source.Watch
+	// - Enabled enables notifications, Source.Watch returns nil channel otherwise
+	// - WaitTime is a maximum duration of single blocking query
+	// - RetryDelay is a delay before next blocking query after first failure, delay is doubled for every next failure
+	// - RetryMaxDelay is a maximum delay before next blocking query after failure, zero value means no limit
+	Watch struct {
+		Enabled       bool
+		WaitTime      time.Duration
+		RetryDelay    time.Duration
+		RetryMaxDelay time.Duration
+	}
+
+	// Source is implementation of core.Source interface
+	Source struct {
+		catalog Catalog
+		scope   consul.Scope
+		query   Query
+		watch   Watch
+		logger  core.LoggerInterface
+	}
+)
+
+// NewSource provide Source as core.Source implementation
+func NewSource(catalog Catalog, scope consul.Scope, query Query, watch Watch) *Source {
+	return &Source{
+		catalog: catalog,
+		scope:   scope,
+		query:   query,
+		watch:   watch,
+	}
+}
+
+// Fetch provide information about core.Services from Consul catalog
+// - call Catalog.Services and select service names by Query
+// - call Catalog.ServiceMultipleTags with Query tags and filter for every service
+// - validate core.Service
+// - return core.Services with node information
+func (s *Source) Fetch(ctx context.Context) (core.Services, error) {
+	s.logger.Infoln(`Fetch services from catalog`)
+	names, _, err := s.catalog.Services(s.scope.QueryOptions(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to fetch services info`)
+	}
+
+	result := make([]*core.Service, 0)
+	for _, name := range s.names(names) {
+		opts := s.scope.QueryOptions(ctx)
+		opts.Filter = s.filter()
+		items, _, err := s.catalog.ServiceMultipleTags(name, s.query.Tags, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to fetch service "%s" info`, name)
+		}
+		for index, item := range items {
+			service := toCoreService(item)
+			if err := service.Validate(ctx); err != nil {
+				s.logger.Warningln(errors.Wrapf(err, `Failed to validate service "%s" instance #%d`, name, index).Error())
+				continue
+			}
+			result = append(result, service)
+		}
+	}
+	s.logger.Infof(`Collected %d services from catalog`, len(result))
+	return result, nil
+}
+
+// Watch is implementation of core.WatchableSource interface.
+// Watch sends blocking queries to Catalog.Services until context.Context canceled and return channel with
+// notifications, which are sent, when index of catalog services is changed. Notifications are coalesced.
+// Failed queries are retried with exponential backoff from Watch.RetryDelay up to Watch.RetryMaxDelay.
+// Watch returns nil channel, if watching is disabled
+func (s *Source) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if !s.watch.Enabled {
+		return nil, nil
+	}
+	_, meta, err := s.catalog.Services(s.scope.QueryOptions(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to fetch services index`)
+	}
+
+	notifications := make(chan struct{}, 1)
+	go func() {
+		defer close(notifications)
+		index := nextIndex(0, meta.LastIndex)
+		failures := 0
+		for {
+			opts := s.scope.QueryOptions(ctx)
+			opts.WaitIndex = index
+			opts.WaitTime = s.watch.WaitTime
+			_, meta, err := s.catalog.Services(opts)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+				delay := s.watch.retryDelay(failures)
+				s.logger.Warningln(errors.Wrapf(err, `failed to watch catalog services, next query in %s`, delay).Error())
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(delay):
+				}
+				continue
+			}
+			failures = 0
+			next := nextIndex(index, meta.LastIndex)
+			if next == index {
+				continue
+			}
+			index = next
+			s.logger.Infoln(`Catalog services were changed`)
+			select {
+			case notifications <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	s.logger.Infoln(`Watching catalog services with blocking queries`)
+	return notifications, nil
+}
+
+// WithLogger is implementation of core.Loggable interface
+func (s *Source) WithLogger(logger core.LoggerInterface) {
+	s.logger = logger
+}
+
+// retryDelay return delay before next blocking query after passed count of consecutive failures, starting from 1
+func (w Watch) retryDelay(failures int) time.Duration {
+	return core.RetryPolicy{BaseDelay: w.RetryDelay, MaxDelay: w.RetryMaxDelay}.Delay(failures)
+}
+
+// nextIndex return index for next blocking query by index of previous query and index returned by Consul.
+// Index is reset, if it goes backwards, e.g. after snapshot restore, so next query does not block.
+// Index is never less than 1, because query with zero index never blocks and watch loop would spin
+func nextIndex(previous uint64, last uint64) uint64 {
+	if last < previous || last < 1 {
+		return 1
+	}
+	return last
+}
+
+// names return sorted service names selected by Query names and tags
+func (s *Source) names(services map[string][]string) []string {
+	result := make([]string, 0, len(services))
+	for name, tags := range services {
+		if len(s.query.Services) == 0 && name == consulServiceName {
+			continue
+		}
+		if len(s.query.Services) > 0 && !funk.ContainsString(s.query.Services, name) {
+			continue
+		}
+		if len(funk.SubtractString(s.query.Tags, tags)) > 0 {
+			continue
+		}
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// filter return Query filter expression combined with ExcludeTag expression
+func (s *Source) filter() string {
+	expressions := make([]string, 0, 2)
+	if s.query.Filter != `` {
+		expressions = append(expressions, fmt.Sprintf(`(%s)`, s.query.Filter))
+	}
+	if s.query.ExcludeTag != `` {
+		expressions = append(expressions, fmt.Sprintf(`("%s" not in ServiceTags)`, s.query.ExcludeTag))
+	}
+	return strings.Join(expressions, ` and `)
+}
+
+// toCoreService converts catalog service instance to core.Service. Node address is used, if service address is empty.
+// Node datacenter is not set, so services can be registered to another datacenter
+func toCoreService(item *api.CatalogService) *core.Service {
+	service := &core.Service{
+		Name:    item.ServiceName,
+		Address: item.ServiceAddress,
+		ID:      ptr.String(item.ServiceID),
+	}
+	if service.Address == `` {
+		service.Address = item.Address
+	}
+	if item.ServicePort != 0 {
+		service.Port = ptr.Int(item.ServicePort)
+	}
+	if len(item.ServiceTags) > 0 {
+		tags := append([]string{}, item.ServiceTags...)
+		service.Tags = &tags
+	}
+	if len(item.ServiceMeta) > 0 {
+		meta := make(map[string]string, len(item.ServiceMeta))
+		for key, value := range item.ServiceMeta {
+			meta[key] = value
+		}
+		service.Meta = &meta
+	}
+	service.Node = &core.Node{
+		Node:    item.Node,
+		Address: item.Address,
+	}
+	if len(item.NodeMeta) > 0 {
+		nodeMeta := make(map[string]string, len(item.NodeMeta))
+		for key, value := range item.NodeMeta {
+			nodeMeta[key] = value
+		}
+		service.Node.NodeMeta = &nodeMeta
+	}
+	return service
+}
@@ -0,0 +1,425 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agrea/ptr"
+	"github.com/hashicorp/consul/api"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/insidieux/pinchy/pkg/core/consul"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewSource(t *testing.T) {
+	suite.Run(t, new(newSourceTestSuite))
+}
+
+func TestSource_Fetch(t *testing.T) {
+	suite.Run(t, new(sourceFetchTestSuite))
+}
+
+func TestSource_Watch(t *testing.T) {
+	suite.Run(t, new(sourceWatchTestSuite))
+}
+
+func TestWatch_retryDelay(t *testing.T) {
+	suite.Run(t, new(watchRetryDelayTestSuite))
+}
+
+func Test_nextIndex(t *testing.T) {
+	suite.Run(t, new(nextIndexTestSuite))
+}
+
+func TestSource_WithLogger(t *testing.T) {
+	suite.Run(t, new(sourceWithLoggerTestSuite))
+}
+
+// --- Suites ---
+
+type newSourceTestSuite struct {
+	suite.Suite
+}
+
+func (s *newSourceTestSuite) TestNewSource() {
+	got := NewSource(nil, consul.Scope{Datacenter: `dc-1`}, Query{Filter: `filter`}, Watch{Enabled: true})
+	s.Implements((*core.Source)(nil), got)
+	s.Implements((*core.WatchableSource)(nil), got)
+	s.Equal(&Source{nil, consul.Scope{Datacenter: `dc-1`}, Query{Filter: `filter`}, Watch{Enabled: true}, nil}, got)
+}
+
+type sourceFetchTestSuite struct {
+	suite.Suite
+	catalog *MockCatalog
+	source  *Source
+	hook    *test.Hook
+}
+
+func (s *sourceFetchTestSuite) SetupTest() {
+	s.catalog = new(MockCatalog)
+	s.source = NewSource(s.catalog, consul.Scope{Datacenter: `dc-1`}, Query{}, Watch{})
+	s.source.logger, s.hook = test.NewNullLogger()
+}
+
+func (s *sourceFetchTestSuite) TestErrorCatalogServicesFetch() {
+	s.catalog.On(`Services`, mock.Anything).Return(nil, nil, errors.New(`expected error`))
+
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch services info: expected error`)
+}
+
+func (s *sourceFetchTestSuite) TestErrorCatalogServiceFetch() {
+	s.catalog.On(`Services`, mock.Anything).Return(map[string][]string{`name`: nil}, nil, nil)
+	s.catalog.On(`ServiceMultipleTags`, `name`, mock.Anything, mock.Anything).Return(nil, nil, errors.New(`expected error`))
+
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch service "name" info: expected error`)
+}
+
+func (s *sourceFetchTestSuite) TestSkipServiceValidationCase() {
+	s.catalog.On(`Services`, mock.Anything).Return(map[string][]string{`name`: nil}, nil, nil)
+	s.catalog.On(`ServiceMultipleTags`, `name`, mock.Anything, mock.Anything).Return([]*api.CatalogService{
+		{ServiceID: `id`, Node: `node-1`, Address: `10.0.0.1`},
+	}, nil, nil)
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{}, services)
+	s.Equal(logrus.WarnLevel, s.hook.Entries[1].Level)
+	s.Equal(`Failed to validate service "name" instance #0: service field "name" is required and cannot be empty`, s.hook.Entries[1].Message)
+}
+
+func (s *sourceFetchTestSuite) TestSuccess() {
+	s.catalog.On(`Services`, mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Datacenter == `dc-1` && opts.WaitIndex == 0
+	})).Return(map[string][]string{`web`: {`http`}, `consul`: nil}, &api.QueryMeta{LastIndex: 10}, nil)
+	s.catalog.On(`ServiceMultipleTags`, `web`, []string(nil), mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Datacenter == `dc-1` && opts.Filter == ``
+	})).Return([]*api.CatalogService{
+		{
+			ServiceName:    `web`,
+			ServiceID:      `web-1`,
+			ServiceAddress: `10.0.0.11`,
+			ServicePort:    80,
+			ServiceTags:    []string{`http`},
+			ServiceMeta:    map[string]string{`key`: `value`},
+			Node:           `node-1`,
+			Address:        `10.0.0.1`,
+			Datacenter:     `dc-1`,
+			NodeMeta:       map[string]string{`rack`: `r1`},
+		},
+		{
+			ServiceName: `web`,
+			ServiceID:   `web-2`,
+			Node:        `node-2`,
+			Address:     `10.0.0.2`,
+			Datacenter:  `dc-1`,
+		},
+	}, nil, nil)
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{
+			Name:    `web`,
+			ID:      ptr.String(`web-1`),
+			Address: `10.0.0.11`,
+			Port:    ptr.Int(80),
+			Tags:    &[]string{`http`},
+			Meta:    &map[string]string{`key`: `value`},
+			Node: &core.Node{
+				Node:     `node-1`,
+				Address:  `10.0.0.1`,
+				NodeMeta: &map[string]string{`rack`: `r1`},
+			},
+		},
+		{
+			Name:    `web`,
+			ID:      ptr.String(`web-2`),
+			Address: `10.0.0.2`,
+			Node: &core.Node{
+				Node:    `node-2`,
+				Address: `10.0.0.2`,
+			},
+		},
+	}, services)
+	s.catalog.AssertNotCalled(s.T(), `ServiceMultipleTags`, `consul`, mock.Anything, mock.Anything)
+	s.Equal(`Collected 2 services from catalog`, s.hook.LastEntry().Message)
+}
+
+func (s *sourceFetchTestSuite) TestQuery() {
+	s.source.query = Query{
+		Services:   []string{`web`, `api`, `consul`},
+		Tags:       []string{`http`, `public`},
+		Filter:     `ServiceMeta.env == "prod"`,
+		ExcludeTag: `pinchy`,
+	}
+	s.catalog.On(`Services`, mock.Anything).Return(map[string][]string{
+		`web`:    {`http`, `public`},
+		`api`:    {`http`},
+		`db`:     {`http`, `public`},
+		`consul`: {`http`, `public`},
+	}, nil, nil)
+	s.catalog.On(`ServiceMultipleTags`, mock.Anything, []string{`http`, `public`}, mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.Filter == `(ServiceMeta.env == "prod") and ("pinchy" not in ServiceTags)`
+	})).Return([]*api.CatalogService{}, nil, nil)
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{}, services)
+	s.catalog.AssertNumberOfCalls(s.T(), `ServiceMultipleTags`, 2)
+	s.catalog.AssertCalled(s.T(), `ServiceMultipleTags`, `consul`, mock.Anything, mock.Anything)
+	s.catalog.AssertCalled(s.T(), `ServiceMultipleTags`, `web`, mock.Anything, mock.Anything)
+}
+
+type sourceWatchTestSuite struct {
+	suite.Suite
+	catalog *MockCatalog
+	source  *Source
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *sourceWatchTestSuite) SetupTest() {
+	s.catalog = new(MockCatalog)
+	s.source = NewSource(s.catalog, consul.Scope{}, Query{}, Watch{
+		Enabled:    true,
+		WaitTime:   time.Minute,
+		RetryDelay: time.Millisecond,
+	})
+	s.source.logger, _ = test.NewNullLogger()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *sourceWatchTestSuite) TearDownTest() {
+	s.cancel()
+}
+
+func (s *sourceWatchTestSuite) waitIndex(index uint64) interface{} {
+	return mock.MatchedBy(func(opts *api.QueryOptions) bool {
+		return opts.WaitIndex == index
+	})
+}
+
+func (s *sourceWatchTestSuite) TestDisabled() {
+	s.source.watch.Enabled = false
+	notifications, err := s.source.Watch(s.ctx)
+	s.NoError(err)
+	s.Nil(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestErrorIndex() {
+	s.catalog.On(`Services`, mock.Anything).Return(nil, nil, errors.New(`expected error`))
+	notifications, err := s.source.Watch(s.ctx)
+	s.Nil(notifications)
+	s.EqualError(err, `failed to fetch services index: expected error`)
+}
+
+func (s *sourceWatchTestSuite) TestNotifyOnChange() {
+	block := make(chan time.Time)
+	s.catalog.On(`Services`, s.waitIndex(0)).Return(nil, &api.QueryMeta{LastIndex: 10}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(10)).Return(nil, &api.QueryMeta{LastIndex: 10}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(10)).Return(nil, nil, errors.New(`expected error`)).Once()
+	s.catalog.On(`Services`, s.waitIndex(10)).Return(nil, &api.QueryMeta{LastIndex: 11}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(11)).WaitUntil(block).Return(nil, &api.QueryMeta{LastIndex: 11}, nil)
+
+	notifications, err := s.source.Watch(s.ctx)
+	s.NoError(err)
+	select {
+	case <-notifications:
+	case <-time.After(time.Second):
+		s.Fail(`notification is not received`)
+	}
+	select {
+	case <-notifications:
+		s.Fail(`unexpected notification`)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.cancel()
+	close(block)
+	select {
+	case _, ok := <-notifications:
+		s.False(ok)
+	case <-time.After(time.Second):
+		s.Fail(`channel is not closed`)
+	}
+}
+
+func (s *sourceWatchTestSuite) TestResetIndex() {
+	block := make(chan time.Time)
+	reset := make(chan struct{})
+	s.catalog.On(`Services`, s.waitIndex(0)).Return(nil, &api.QueryMeta{LastIndex: 10}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(10)).Return(nil, &api.QueryMeta{LastIndex: 5}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(1)).Run(func(mock.Arguments) {
+		close(reset)
+	}).Return(nil, &api.QueryMeta{LastIndex: 5}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(5)).WaitUntil(block).Return(nil, &api.QueryMeta{LastIndex: 5}, nil)
+
+	notifications, err := s.source.Watch(s.ctx)
+	s.NoError(err)
+	select {
+	case <-reset:
+	case <-time.After(time.Second):
+		s.Fail(`index is not reset`)
+	}
+	s.cancel()
+	close(block)
+	for range notifications {
+	}
+}
+
+func (s *sourceWatchTestSuite) TestZeroIndex() {
+	blocked := make(chan struct{})
+	s.catalog.On(`Services`, s.waitIndex(0)).Return(nil, &api.QueryMeta{LastIndex: 0}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(1)).Return(nil, &api.QueryMeta{LastIndex: 0}, nil).Once()
+	s.catalog.On(`Services`, s.waitIndex(1)).Run(func(mock.Arguments) {
+		close(blocked)
+		<-s.ctx.Done()
+	}).Return(nil, &api.QueryMeta{LastIndex: 0}, nil).Once()
+
+	notifications, err := s.source.Watch(s.ctx)
+	s.NoError(err)
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		s.Fail(`query is not blocked`)
+	}
+	select {
+	case <-notifications:
+		s.Fail(`unexpected notification`)
+	default:
+	}
+	s.cancel()
+	for range notifications {
+	}
+}
+
+type watchRetryDelayTestSuite struct {
+	suite.Suite
+}
+
+func (s *watchRetryDelayTestSuite) TestBackoff() {
+	watch := Watch{RetryDelay: time.Second, RetryMaxDelay: 5 * time.Second}
+	for failures, expected := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  5 * time.Second,
+		10: 5 * time.Second,
+	} {
+		s.Equal(expected, watch.retryDelay(failures), failures)
+	}
+}
+
+type nextIndexTestSuite struct {
+	suite.Suite
+}
+
+func (s *nextIndexTestSuite) TestNextIndex() {
+	for _, tc := range []struct {
+		previous uint64
+		last     uint64
+		expected uint64
+	}{
+		{previous: 0, last: 10, expected: 10},
+		{previous: 10, last: 10, expected: 10},
+		{previous: 10, last: 11, expected: 11},
+		{previous: 10, last: 5, expected: 1},
+		{previous: 0, last: 0, expected: 1},
+		{previous: 1, last: 0, expected: 1},
+	} {
+		s.Equal(tc.expected, nextIndex(tc.previous, tc.last), tc)
+	}
+}
+
+type sourceWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *sourceWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	src := NewSource(nil, consul.Scope{}, Query{}, Watch{})
+	src.WithLogger(logger)
+	s.Equal(logger, src.logger)
+}
+
+// --- Mocks ---
+
+// MockCatalog is an autogenerated mock type for the Catalog type
+type MockCatalog struct {
+	mock.Mock
+}
+
+// ServiceMultipleTags provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockCatalog) ServiceMultipleTags(_a0 string, _a1 []string, _a2 *api.QueryOptions) ([]*api.CatalogService, *api.QueryMeta, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []*api.CatalogService
+	if rf, ok := ret.Get(0).(func(string, []string, *api.QueryOptions) []*api.CatalogService); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*api.CatalogService)
+		}
+	}
+
+	var r1 *api.QueryMeta
+	if rf, ok := ret.Get(1).(func(string, []string, *api.QueryOptions) *api.QueryMeta); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*api.QueryMeta)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, []string, *api.QueryOptions) error); ok {
+		r2 = rf(_a0, _a1, _a2)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Services provides a mock function with given fields: _a0
+func (_m *MockCatalog) Services(_a0 *api.QueryOptions) (map[string][]string, *api.QueryMeta, error) {
+	ret := _m.Called(_a0)
+
+	var r0 map[string][]string
+	if rf, ok := ret.Get(0).(func(*api.QueryOptions) map[string][]string); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]string)
+		}
+	}
+
+	var r1 *api.QueryMeta
+	if rf, ok := ret.Get(1).(func(*api.QueryOptions) *api.QueryMeta); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*api.QueryMeta)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*api.QueryOptions) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,129 @@
+package file
+
+import (
+	"context"
+	"sort"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+type (
+	// Globber tries to find names of files matched by pattern in filepath.Match format
+	Globber interface {
+		Glob(pattern string) ([]string, error)
+	}
+
+	// FsGlobber is implementation of Globber for afero.Fs
+	FsGlobber struct {
+		Fs afero.Fs
+	}
+
+	// Pattern is custom type for glob pattern of files, e.g. /etc/pinchy/services.d/*.yml
+	Pattern string
+
+	// MetaKey is a key of core.Service meta, which contains name of file with service. Empty MetaKey disables meta
+	MetaKey string
+
+	// DirSource is implementation of core.Source interface, which merges services from all files matched by Pattern
+	DirSource struct {
+		reader   Reader
+		globber  Globber
+		pattern  Pattern
+		metaKey  MetaKey
+		watch    Watch
+		renderer *Renderer
+		decoder  *Decoder
+		logger   core.LoggerInterface
+	}
+)
+
+// Glob is implementation of Globber interface
+func (g FsGlobber) Glob(pattern string) ([]string, error) {
+	return afero.Glob(g.Fs, pattern)
+}
+
+// NewDirSource provide DirSource as core.Source implementation
+func NewDirSource(reader Reader, globber Globber, pattern Pattern, metaKey MetaKey, watch Watch, renderer *Renderer, decoder *Decoder) *DirSource {
+	return &DirSource{
+		reader:   reader,
+		globber:  globber,
+		pattern:  pattern,
+		metaKey:  metaKey,
+		watch:    watch,
+		renderer: renderer,
+		decoder:  decoder,
+	}
+}
+
+// Fetch provide information about core.Services from all files matched by Pattern
+// - call Globber.Glob and sort file names
+// - call Reader.ReadFile, Renderer.Render and Decoder.Decode contents of every file
+// - validate core.Service and skip services with id already found in previous file
+// - set file name to core.Service meta by MetaKey
+// - return core.Services
+func (s *DirSource) Fetch(ctx context.Context) (core.Services, error) {
+	s.logger.Infof(`Searching files by pattern "%s"`, s.pattern)
+	filenames, err := s.globber.Glob(string(s.pattern))
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to search files by pattern "%s"`, s.pattern)
+	}
+	if len(filenames) == 0 {
+		return nil, errors.Errorf(`no files found by pattern "%s"`, s.pattern)
+	}
+	sort.Strings(filenames)
+
+	result := make([]*core.Service, 0)
+	origins := make(map[string]string)
+	for _, filename := range filenames {
+		s.logger.Infof(`Reading file "%s"`, filename)
+		contents, err := s.reader.ReadFile(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed read content from file "%s"`, filename)
+		}
+		contents, err = s.renderer.Render(filename, contents)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed render content from file "%s"`, filename)
+		}
+		items, err := s.decoder.Decode(filename, contents)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed unmarshal content from file "%s"`, filename)
+		}
+
+		for index, item := range items {
+			if err := item.Validate(ctx); err != nil {
+				s.logger.Warningln(errors.Wrapf(err, `Failed to validate service #%d from file "%s"`, index, filename).Error())
+				continue
+			}
+			id := item.RegistrationID()
+			if origin, ok := origins[id]; ok {
+				s.logger.Warningf(
+					`Service with id "%s" from file "%s" is skipped, because it is already defined in file "%s"`,
+					id,
+					filename,
+					origin,
+				)
+				continue
+			}
+			origins[id] = filename
+			if s.metaKey != `` {
+				meta := make(map[string]string)
+				if item.Meta != nil {
+					meta = *item.Meta
+				}
+				meta[string(s.metaKey)] = filename
+				item.Meta = &meta
+			}
+			result = append(result, item)
+		}
+	}
+
+	s.logger.Infof(`Collected %d services from %d files`, len(result), len(filenames))
+	return result, nil
+}
+
+// WithLogger is implementation of core.Loggable interface
+func (s *DirSource) WithLogger(logger core.LoggerInterface) {
+	s.logger = logger
+}
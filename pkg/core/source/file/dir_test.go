@@ -0,0 +1,210 @@
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestFsGlobber_Glob(t *testing.T) {
+	suite.Run(t, new(fsGlobberGlobTestSuite))
+}
+
+func TestNewDirSource(t *testing.T) {
+	suite.Run(t, new(newDirSourceTestSuite))
+}
+
+func TestDirSource_Fetch(t *testing.T) {
+	suite.Run(t, new(dirSourceFetchTestSuite))
+}
+
+func TestDirSource_WithLogger(t *testing.T) {
+	suite.Run(t, new(dirSourceWithLoggerTestSuite))
+}
+
+// --- Suites ---
+
+type fsGlobberGlobTestSuite struct {
+	suite.Suite
+}
+
+func (s *fsGlobberGlobTestSuite) TestGlob() {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	s.Require().NoError(fs.WriteFile(`/services.d/b.yml`, []byte(`[]`), 0644))
+	s.Require().NoError(fs.WriteFile(`/services.d/a.yml`, []byte(`[]`), 0644))
+	s.Require().NoError(fs.WriteFile(`/services.d/c.json`, []byte(`[]`), 0644))
+
+	names, err := FsGlobber{Fs: fs}.Glob(`/services.d/*.yml`)
+	s.NoError(err)
+	s.ElementsMatch([]string{`/services.d/a.yml`, `/services.d/b.yml`}, names)
+}
+
+type newDirSourceTestSuite struct {
+	suite.Suite
+}
+
+func (s *newDirSourceTestSuite) TestNewDirSource() {
+	got := NewDirSource(nil, nil, `*.yml`, `source-file`, true, nil, nil)
+	s.Implements((*core.Source)(nil), got)
+	s.Implements((*core.WatchableSource)(nil), got)
+	s.Equal(&DirSource{nil, nil, `*.yml`, `source-file`, true, nil, nil, nil}, got)
+}
+
+type dirSourceFetchTestSuite struct {
+	suite.Suite
+	source *DirSource
+	fs     afero.Afero
+	hook   *test.Hook
+}
+
+func (s *dirSourceFetchTestSuite) SetupTest() {
+	s.fs = afero.Afero{Fs: afero.NewMemMapFs()}
+	s.source = NewDirSource(s.fs, FsGlobber{Fs: s.fs}, `/services.d/*.yml`, `source-file`, false, NewRenderer(nil, false, false, false), NewDecoder(FormatAuto, nil))
+	s.source.logger, s.hook = test.NewNullLogger()
+}
+
+func (s *dirSourceFetchTestSuite) write(filename string, contents string) {
+	if err := s.fs.WriteFile(filename, []byte(contents), 0644); err != nil {
+		panic(errors.Wrap(err, `failed to write to in-memory file`))
+	}
+}
+
+func (s *dirSourceFetchTestSuite) TestErrorGlob() {
+	globber := new(MockGlobber)
+	globber.On(`Glob`, `/services.d/*.yml`).Return(nil, errors.New(`expected error`))
+	s.source.globber = globber
+
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to search files by pattern "/services.d/*.yml": expected error`)
+}
+
+func (s *dirSourceFetchTestSuite) TestErrorNoFiles() {
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `no files found by pattern "/services.d/*.yml"`)
+}
+
+func (s *dirSourceFetchTestSuite) TestErrorRead() {
+	globber := new(MockGlobber)
+	globber.On(`Glob`, `/services.d/*.yml`).Return([]string{`/services.d/missing.yml`}, nil)
+	s.source.globber = globber
+
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed read content from file "/services.d/missing.yml": open /services.d/missing.yml: file does not exist`)
+}
+
+func (s *dirSourceFetchTestSuite) TestErrorUnmarshal() {
+	s.write(`/services.d/a.yml`, `{"key": "value"}`)
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.Error(err)
+	s.Contains(err.Error(), `failed unmarshal content from file "/services.d/a.yml"`)
+}
+
+func (s *dirSourceFetchTestSuite) TestSkipServiceValidationCase() {
+	s.write(`/services.d/a.yml`, "- name: service\n")
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{}, services)
+	s.Equal(logrus.WarnLevel, s.hook.AllEntries()[2].Level)
+	s.Equal(
+		`Failed to validate service #0 from file "/services.d/a.yml": service "service" field "address" is required and cannot be empty`,
+		s.hook.AllEntries()[2].Message,
+	)
+}
+
+func (s *dirSourceFetchTestSuite) TestDuplicate() {
+	s.write(`/services.d/b.yml`, "- name: service\n  address: 127.0.0.2\n")
+	s.write(`/services.d/a.yml`, "- name: service\n  address: 127.0.0.1\n")
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `service`, Address: `127.0.0.1`, Meta: &map[string]string{`source-file`: `/services.d/a.yml`}},
+	}, services)
+
+	var warnings []string
+	for _, entry := range s.hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	s.Equal([]string{
+		`Service with id "service" from file "/services.d/b.yml" is skipped, because it is already defined in file "/services.d/a.yml"`,
+	}, warnings)
+}
+
+func (s *dirSourceFetchTestSuite) TestSuccess() {
+	s.write(`/services.d/payments.yml`, "- name: payments\n  address: 127.0.0.1\n  meta:\n    team: payments\n")
+	s.write(`/services.d/billing.yml`, "- name: billing\n  id: billing-1\n  address: 127.0.0.2\n- name: billing\n  id: billing-2\n  address: 127.0.0.3\n")
+	s.write(`/services.d/ignored.json`, `[{"name": "ignored", "address": "127.0.0.4"}]`)
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `billing`, ID: ptr.String(`billing-1`), Address: `127.0.0.2`, Meta: &map[string]string{`source-file`: `/services.d/billing.yml`}},
+		{Name: `billing`, ID: ptr.String(`billing-2`), Address: `127.0.0.3`, Meta: &map[string]string{`source-file`: `/services.d/billing.yml`}},
+		{Name: `payments`, Address: `127.0.0.1`, Meta: &map[string]string{`team`: `payments`, `source-file`: `/services.d/payments.yml`}},
+	}, services)
+	s.Equal(`Collected 3 services from 2 files`, s.hook.LastEntry().Message)
+}
+
+func (s *dirSourceFetchTestSuite) TestWithoutMeta() {
+	s.source.metaKey = ``
+	s.write(`/services.d/a.yml`, "- name: service\n  address: 127.0.0.1\n")
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `service`, Address: `127.0.0.1`}}, services)
+}
+
+type dirSourceWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *dirSourceWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	src := NewDirSource(nil, nil, ``, ``, false, nil, nil)
+	src.WithLogger(logger)
+	s.Equal(logger, src.logger)
+}
+
+// --- Mocks ---
+
+// MockGlobber is an autogenerated mock type for the Globber type
+type MockGlobber struct {
+	mock.Mock
+}
+
+// Glob provides a mock function with given fields: pattern
+func (_m *MockGlobber) Glob(pattern string) ([]string, error) {
+	ret := _m.Called(pattern)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(pattern)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(pattern)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
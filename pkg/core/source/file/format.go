@@ -0,0 +1,293 @@
+package file
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FormatAuto detects file format by file extension, yaml is used for unknown extensions
+	FormatAuto Format = ``
+	// FormatYAML is a list of services in yaml document
+	FormatYAML Format = `yaml`
+	// FormatJSON is a list of services in json document
+	FormatJSON Format = `json`
+	// FormatTOML is an array of tables "services" in toml document
+	FormatTOML Format = `toml`
+	// FormatHCL is a list of "service" blocks in hcl document
+	FormatHCL Format = `hcl`
+	// FormatCSV is a table with header row, every next row is a service
+	FormatCSV Format = `csv`
+
+	// CSVColumnIgnore is a CSVColumns value for skipping column
+	CSVColumnIgnore = `-`
+	// CSVColumnMetaPrefix is a CSVColumns value prefix for mapping column to service meta key
+	CSVColumnMetaPrefix = `meta.`
+)
+
+type (
+	// Format is custom type for config file format
+	Format string
+
+	// CSVColumns maps csv header to service field: name, id, address, port, tags, meta.<key> or "-" for skip column
+	CSVColumns map[string]string
+
+	// Decoder decodes file contents to list of core.Service according to Format
+	Decoder struct {
+		format  Format
+		columns CSVColumns
+	}
+)
+
+// Formats return list of supported formats
+func Formats() []Format {
+	return []Format{FormatYAML, FormatJSON, FormatTOML, FormatHCL, FormatCSV}
+}
+
+// ParseFormat checks format name is empty or one of supported formats
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	if format == FormatAuto {
+		return format, nil
+	}
+	for _, supported := range Formats() {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return ``, errors.Errorf(`unsupported format "%s"`, name)
+}
+
+// NewDecoder provide Decoder with forced format, FormatAuto enables detection by file extension
+func NewDecoder(format Format, columns CSVColumns) *Decoder {
+	return &Decoder{
+		format:  format,
+		columns: columns,
+	}
+}
+
+// Format return forced format or detects format by file extension
+func (d *Decoder) Format(filename string) Format {
+	if d.format != FormatAuto {
+		return d.format
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case `.json`:
+		return FormatJSON
+	case `.toml`:
+		return FormatTOML
+	case `.hcl`:
+		return FormatHCL
+	case `.csv`:
+		return FormatCSV
+	default:
+		return FormatYAML
+	}
+}
+
+// Decode unmarshal file contents to list of core.Service
+func (d *Decoder) Decode(filename string, contents []byte) ([]*core.Service, error) {
+	switch format := d.Format(filename); format {
+	case FormatYAML:
+		return decodeYAML(contents)
+	case FormatJSON:
+		return decodeJSON(contents)
+	case FormatTOML:
+		return decodeTOML(contents)
+	case FormatHCL:
+		return decodeHCL(contents)
+	case FormatCSV:
+		return decodeCSV(contents, d.columns)
+	default:
+		return nil, errors.Errorf(`unsupported format "%s"`, format)
+	}
+}
+
+func decodeYAML(contents []byte) ([]*core.Service, error) {
+	items := make([]*core.Service, 0)
+	if err := yaml.Unmarshal(contents, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func decodeJSON(contents []byte) ([]*core.Service, error) {
+	items := make([]*core.Service, 0)
+	if err := json.Unmarshal(contents, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func decodeTOML(contents []byte) ([]*core.Service, error) {
+	document := struct {
+		Services []*core.Service `toml:"services"`
+	}{}
+	if err := toml.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+	if document.Services == nil {
+		return make([]*core.Service, 0), nil
+	}
+	return document.Services, nil
+}
+
+// hclObjects is a list of service fields, which are objects, but decoded by hcl as list of blocks
+var hclObjects = map[string]bool{
+	`meta`:     true,
+	`node`:     true,
+	`nodemeta`: true,
+}
+
+func decodeHCL(contents []byte) ([]*core.Service, error) {
+	document := make(map[string]interface{})
+	if err := hcl.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+	blocks := make([]map[string]interface{}, 0)
+	if value, ok := document[`service`]; ok {
+		list, ok := value.([]map[string]interface{})
+		if !ok {
+			return nil, errors.New(`"service" must be a list of blocks`)
+		}
+		blocks = list
+	}
+	for _, block := range blocks {
+		normalizeHCL(block)
+	}
+	// hcl decodes blocks to generic structures only, so json is used for mapping them to services
+	encoded, err := json.Marshal(blocks)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(encoded)
+}
+
+// normalizeHCL merges repeated blocks of object fields to single object, e.g. "meta { ... }" to map
+func normalizeHCL(block map[string]interface{}) {
+	for key, value := range block {
+		list, ok := value.([]map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			normalizeHCL(item)
+		}
+		if !hclObjects[strings.ToLower(key)] {
+			continue
+		}
+		merged := make(map[string]interface{})
+		for _, item := range list {
+			for k, v := range item {
+				merged[k] = v
+			}
+		}
+		block[key] = merged
+	}
+}
+
+func decodeCSV(contents []byte, columns CSVColumns) ([]*core.Service, error) {
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return make([]*core.Service, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, len(header))
+	for index, name := range header {
+		field, err := csvField(strings.TrimSpace(name), columns)
+		if err != nil {
+			return nil, err
+		}
+		fields[index] = field
+	}
+
+	items := make([]*core.Service, 0)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, err := csvService(fields, record)
+		if err != nil {
+			return nil, errors.Wrapf(err, `row %d`, row)
+		}
+		items = append(items, item)
+	}
+}
+
+// csvField resolves service field for csv header case-insensitively, header is used as field if there is no mapping for it.
+// Field names and meta prefix are matched case-insensitively, meta key keeps original case
+func csvField(name string, columns CSVColumns) (string, error) {
+	field := name
+	for header, mapped := range columns {
+		if strings.EqualFold(header, name) {
+			field = mapped
+			break
+		}
+	}
+	lower := strings.ToLower(field)
+	switch {
+	case lower == `name`, lower == `id`, lower == `address`, lower == `port`, lower == `tags`, lower == CSVColumnIgnore:
+		return lower, nil
+	case strings.HasPrefix(lower, CSVColumnMetaPrefix) && len(field) > len(CSVColumnMetaPrefix):
+		return CSVColumnMetaPrefix + field[len(CSVColumnMetaPrefix):], nil
+	default:
+		return ``, errors.Errorf(`column "%s" is not mapped to service field`, name)
+	}
+}
+
+func csvService(fields []string, record []string) (*core.Service, error) {
+	item := new(core.Service)
+	for index, field := range fields {
+		value := strings.TrimSpace(record[index])
+		if value == `` || field == CSVColumnIgnore {
+			continue
+		}
+		switch field {
+		case `name`:
+			item.Name = value
+		case `id`:
+			item.ID = &value
+		case `address`:
+			item.Address = value
+		case `port`:
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.Errorf(`invalid port "%s"`, value)
+			}
+			item.Port = &port
+		case `tags`:
+			tags := make([]string, 0)
+			for _, tag := range strings.Split(value, `,`) {
+				if tag = strings.TrimSpace(tag); tag != `` {
+					tags = append(tags, tag)
+				}
+			}
+			item.Tags = &tags
+		default:
+			if item.Meta == nil {
+				item.Meta = &map[string]string{}
+			}
+			(*item.Meta)[strings.TrimPrefix(field, CSVColumnMetaPrefix)] = value
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,354 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestParseFormat(t *testing.T) {
+	suite.Run(t, new(parseFormatTestSuite))
+}
+
+func TestNewDecoder(t *testing.T) {
+	suite.Run(t, new(newDecoderTestSuite))
+}
+
+func TestDecoder_Format(t *testing.T) {
+	suite.Run(t, new(decoderFormatTestSuite))
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	suite.Run(t, new(decoderDecodeTestSuite))
+}
+
+// --- Suites ---
+
+type parseFormatTestSuite struct {
+	suite.Suite
+}
+
+func (s *parseFormatTestSuite) TestAuto() {
+	format, err := ParseFormat(``)
+	s.NoError(err)
+	s.Equal(FormatAuto, format)
+}
+
+func (s *parseFormatTestSuite) TestSupported() {
+	format, err := ParseFormat(`TOML`)
+	s.NoError(err)
+	s.Equal(FormatTOML, format)
+}
+
+func (s *parseFormatTestSuite) TestUnsupported() {
+	format, err := ParseFormat(`xml`)
+	s.Equal(Format(``), format)
+	s.EqualError(err, `unsupported format "xml"`)
+}
+
+type newDecoderTestSuite struct {
+	suite.Suite
+}
+
+func (s *newDecoderTestSuite) TestNewDecoder() {
+	got := NewDecoder(FormatCSV, CSVColumns{`host`: `address`})
+	s.Equal(&Decoder{FormatCSV, CSVColumns{`host`: `address`}}, got)
+}
+
+type decoderFormatTestSuite struct {
+	suite.Suite
+}
+
+func (s *decoderFormatTestSuite) TestDetect() {
+	decoder := NewDecoder(FormatAuto, nil)
+	s.Equal(FormatYAML, decoder.Format(`services.yml`))
+	s.Equal(FormatYAML, decoder.Format(`services.yaml`))
+	s.Equal(FormatYAML, decoder.Format(`services`))
+	s.Equal(FormatJSON, decoder.Format(`services.JSON`))
+	s.Equal(FormatTOML, decoder.Format(`services.toml`))
+	s.Equal(FormatHCL, decoder.Format(`services.hcl`))
+	s.Equal(FormatCSV, decoder.Format(`/path/to/services.csv`))
+}
+
+func (s *decoderFormatTestSuite) TestOverride() {
+	decoder := NewDecoder(FormatJSON, nil)
+	s.Equal(FormatJSON, decoder.Format(`services.yml`))
+}
+
+type decoderDecodeTestSuite struct {
+	suite.Suite
+}
+
+func (s *decoderDecodeTestSuite) expected() core.Services {
+	return core.Services{
+		{
+			Name:    `service-1`,
+			Address: `127.0.0.1`,
+			Port:    ptr.Int(80),
+			Tags:    &[]string{`a`, `b`},
+			Meta:    &map[string]string{`env`: `prod`},
+			Checks: &core.Checks{
+				{Name: `http`, HTTP: ptr.String(`http://127.0.0.1/health`), Interval: ptr.String(`10s`)},
+			},
+		},
+		{
+			Name:    `service-2`,
+			Address: `127.0.0.2`,
+			Node: &core.Node{
+				Node:     `node`,
+				Address:  `10.0.0.1`,
+				NodeMeta: &map[string]string{`rack`: `r1`},
+			},
+		},
+	}
+}
+
+func (s *decoderDecodeTestSuite) TestYAML() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.yml`, []byte(`
+- name: service-1
+  address: 127.0.0.1
+  port: 80
+  tags: [a, b]
+  meta:
+    env: prod
+  checks:
+    - name: http
+      http: http://127.0.0.1/health
+      interval: 10s
+- name: service-2
+  address: 127.0.0.2
+  node:
+    node: node
+    address: 10.0.0.1
+    nodemeta:
+      rack: r1
+`))
+	s.NoError(err)
+	s.Equal(s.expected(), core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestJSON() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.json`, []byte(`[
+  {
+    "name": "service-1",
+    "address": "127.0.0.1",
+    "port": 80,
+    "tags": ["a", "b"],
+    "meta": {"env": "prod"},
+    "checks": [{"name": "http", "http": "http://127.0.0.1/health", "interval": "10s"}]
+  },
+  {
+    "name": "service-2",
+    "address": "127.0.0.2",
+    "node": {"node": "node", "address": "10.0.0.1", "nodemeta": {"rack": "r1"}}
+  }
+]`))
+	s.NoError(err)
+	s.Equal(s.expected(), core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestJSONError() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.json`, []byte(`{"name": "service"}`))
+	s.Nil(services)
+	s.Error(err)
+}
+
+func (s *decoderDecodeTestSuite) TestTOML() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.toml`, []byte(`
+[[services]]
+name = "service-1"
+address = "127.0.0.1"
+port = 80
+tags = ["a", "b"]
+meta = { env = "prod" }
+
+  [[services.checks]]
+  name = "http"
+  http = "http://127.0.0.1/health"
+  interval = "10s"
+
+[[services]]
+name = "service-2"
+address = "127.0.0.2"
+
+  [services.node]
+  node = "node"
+  address = "10.0.0.1"
+  nodemeta = { rack = "r1" }
+`))
+	s.NoError(err)
+	s.Equal(s.expected(), core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestTOMLEmpty() {
+	services, err := NewDecoder(FormatTOML, nil).Decode(`services`, []byte(``))
+	s.NoError(err)
+	s.Equal([]*core.Service{}, services)
+}
+
+func (s *decoderDecodeTestSuite) TestTOMLError() {
+	services, err := NewDecoder(FormatTOML, nil).Decode(`services`, []byte(`[[services]`))
+	s.Nil(services)
+	s.Error(err)
+}
+
+func (s *decoderDecodeTestSuite) TestHCL() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.hcl`, []byte(`
+service {
+  name    = "service-1"
+  address = "127.0.0.1"
+  port    = 80
+  tags    = ["a", "b"]
+
+  meta {
+    env = "prod"
+  }
+
+  checks {
+    name     = "http"
+    http     = "http://127.0.0.1/health"
+    interval = "10s"
+  }
+}
+
+service {
+  name    = "service-2"
+  address = "127.0.0.2"
+
+  node {
+    node     = "node"
+    address  = "10.0.0.1"
+    nodemeta = { rack = "r1" }
+  }
+}
+`))
+	s.NoError(err)
+	s.Equal(s.expected(), core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestHCLEmpty() {
+	services, err := NewDecoder(FormatHCL, nil).Decode(`services`, []byte(``))
+	s.NoError(err)
+	s.Equal([]*core.Service{}, services)
+}
+
+func (s *decoderDecodeTestSuite) TestHCLNotBlocks() {
+	services, err := NewDecoder(FormatHCL, nil).Decode(`services`, []byte(`service = "name"`))
+	s.Nil(services)
+	s.EqualError(err, `"service" must be a list of blocks`)
+}
+
+func (s *decoderDecodeTestSuite) TestHCLError() {
+	services, err := NewDecoder(FormatHCL, nil).Decode(`services`, []byte(`service {`))
+	s.Nil(services)
+	s.Error(err)
+}
+
+func (s *decoderDecodeTestSuite) TestCSV() {
+	services, err := NewDecoder(FormatAuto, nil).Decode(`services.csv`, []byte(
+		"Name,Address,Port,Tags,meta.env\n"+
+			"service-1,127.0.0.1,80,\"a, b\",prod\n"+
+			"service-2,127.0.0.2,,,\n",
+	))
+	s.NoError(err)
+	s.Equal(core.Services{
+		{
+			Name:    `service-1`,
+			Address: `127.0.0.1`,
+			Port:    ptr.Int(80),
+			Tags:    &[]string{`a`, `b`},
+			Meta:    &map[string]string{`env`: `prod`},
+		},
+		{
+			Name:    `service-2`,
+			Address: `127.0.0.2`,
+		},
+	}, core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestCSVColumns() {
+	decoder := NewDecoder(FormatCSV, CSVColumns{
+		`Hostname`: `name`,
+		`IP`:       `address`,
+		`Service`:  `id`,
+		`Rack`:     `meta.rack`,
+		`Comment`:  CSVColumnIgnore,
+	})
+	services, err := decoder.Decode(`inventory.txt`, []byte(
+		"Hostname,IP,Service,Rack,Comment\n"+
+			"host-1,10.0.0.1,host-1-exporter,r1,spare\n",
+	))
+	s.NoError(err)
+	s.Equal(core.Services{
+		{
+			Name:    `host-1`,
+			Address: `10.0.0.1`,
+			ID:      ptr.String(`host-1-exporter`),
+			Meta:    &map[string]string{`rack`: `r1`},
+		},
+	}, core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestCSVMetaCase() {
+	decoder := NewDecoder(FormatCSV, CSVColumns{
+		`Team`:      `meta.OwnerTeam`,
+		`cost-unit`: `Meta.CostUnit`,
+	})
+	services, err := decoder.Decode(`services.csv`, []byte(
+		"NAME,Address,Meta.DeployEnv,team,Cost-Unit\n"+
+			"service-1,127.0.0.1,prod,Platform,CU-1\n",
+	))
+	s.NoError(err)
+	s.Equal(core.Services{
+		{
+			Name:    `service-1`,
+			Address: `127.0.0.1`,
+			Meta: &map[string]string{
+				`DeployEnv`: `prod`,
+				`OwnerTeam`: `Platform`,
+				`CostUnit`:  `CU-1`,
+			},
+		},
+	}, core.Services(services))
+}
+
+func (s *decoderDecodeTestSuite) TestCSVEmpty() {
+	services, err := NewDecoder(FormatCSV, nil).Decode(`services`, []byte(``))
+	s.NoError(err)
+	s.Equal([]*core.Service{}, services)
+}
+
+func (s *decoderDecodeTestSuite) TestCSVUnknownColumn() {
+	services, err := NewDecoder(FormatCSV, nil).Decode(`services`, []byte("name,address,owner\n"))
+	s.Nil(services)
+	s.EqualError(err, `column "owner" is not mapped to service field`)
+}
+
+func (s *decoderDecodeTestSuite) TestCSVInvalidPort() {
+	services, err := NewDecoder(FormatCSV, nil).Decode(`services`, []byte(
+		"name,address,port\n"+
+			"service-1,127.0.0.1,80\n"+
+			"service-2,127.0.0.2,http\n",
+	))
+	s.Nil(services)
+	s.EqualError(err, `row 3: invalid port "http"`)
+}
+
+func (s *decoderDecodeTestSuite) TestCSVError() {
+	services, err := NewDecoder(FormatCSV, nil).Decode(`services`, []byte(
+		"name,address\n"+
+			"service-1\n",
+	))
+	s.Nil(services)
+	s.Error(err)
+}
+
+func (s *decoderDecodeTestSuite) TestUnsupported() {
+	services, err := NewDecoder(Format(`xml`), nil).Decode(`services.xml`, []byte(``))
+	s.Nil(services)
+	s.EqualError(err, `unsupported format "xml"`)
+}
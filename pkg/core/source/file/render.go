@@ -0,0 +1,208 @@
+package file
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+type (
+	// LookupEnv tries to find environment variable value by name, e.g. os.LookupEnv
+	LookupEnv func(name string) (string, bool)
+
+	// Interpolate enables replacing of ${NAME} placeholders with environment variables
+	Interpolate bool
+
+	// Template enables rendering of file contents as text/template before variables interpolation
+	Template bool
+
+	// AllowMissing replaces missing environment variables with empty string instead of error
+	AllowMissing bool
+
+	// Renderer prepares file contents before decoding
+	// - renders contents as text/template with helper functions, if Template is enabled
+	// - interpolates ${NAME}, ${NAME:-default} (default if unset or empty) and ${NAME-default} (default if unset),
+	//   $${ is replaced with literal ${, if Interpolate is enabled
+	// Template is rendered first, so variable values are never parsed as template
+	Renderer struct {
+		lookup       LookupEnv
+		interpolate  Interpolate
+		template     Template
+		allowMissing AllowMissing
+	}
+)
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// NewRenderer provide Renderer for file contents
+func NewRenderer(lookup LookupEnv, interpolate Interpolate, template Template, allowMissing AllowMissing) *Renderer {
+	return &Renderer{
+		lookup:       lookup,
+		interpolate:  interpolate,
+		template:     template,
+		allowMissing: allowMissing,
+	}
+}
+
+// Render renders template and interpolates environment variables, errors contain file name, line and column
+func (r *Renderer) Render(filename string, contents []byte) ([]byte, error) {
+	rendered := string(contents)
+	if r.template {
+		t, err := template.New(filename).Option(`missingkey=error`).Funcs(r.funcs()).Parse(rendered)
+		if err != nil {
+			return nil, err
+		}
+		b := new(strings.Builder)
+		if err := t.Execute(b, nil); err != nil {
+			return nil, err
+		}
+		rendered = b.String()
+	}
+	if !r.interpolate {
+		return []byte(rendered), nil
+	}
+	interpolated, err := r.interpolateVariables(filename, rendered)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(interpolated), nil
+}
+
+func (r *Renderer) interpolateVariables(filename string, contents string) (string, error) {
+	b := new(strings.Builder)
+	line, column := 1, 1
+	for index := 0; index < len(contents); {
+		rest := contents[index:]
+		switch {
+		case strings.HasPrefix(rest, `$${`):
+			b.WriteString(`${`)
+			index += 3
+			column += 3
+			continue
+		case strings.HasPrefix(rest, `${`):
+			end := strings.IndexAny(rest, "}\n")
+			if end < 0 || rest[end] != '}' {
+				return ``, errors.Errorf(`%s:%d:%d: unterminated variable`, filename, line, column)
+			}
+			value, err := r.variable(rest[2:end])
+			if err != nil {
+				return ``, errors.Wrapf(err, `%s:%d:%d`, filename, line, column)
+			}
+			b.WriteString(value)
+			index += end + 1
+			column += utf8.RuneCountInString(rest[:end+1])
+			continue
+		}
+		char, size := utf8.DecodeRuneInString(rest)
+		b.WriteString(rest[:size])
+		index += size
+		column++
+		if char == '\n' {
+			line, column = line+1, 1
+		}
+	}
+	return b.String(), nil
+}
+
+// variable resolves expression between ${ and }
+func (r *Renderer) variable(expression string) (string, error) {
+	name := variableName.FindString(expression)
+	if name == `` {
+		return ``, errors.Errorf(`invalid variable "${%s}"`, expression)
+	}
+	value, ok := r.lookup(name)
+	switch modifier := expression[len(name):]; {
+	case modifier == ``:
+	case strings.HasPrefix(modifier, `:-`):
+		if value == `` {
+			return modifier[2:], nil
+		}
+	case strings.HasPrefix(modifier, `-`):
+		if !ok {
+			return modifier[1:], nil
+		}
+	default:
+		return ``, errors.Errorf(`invalid variable "${%s}"`, expression)
+	}
+	if !ok && !bool(r.allowMissing) {
+		return ``, errors.Errorf(`variable "%s" is not set`, name)
+	}
+	return value, nil
+}
+
+// funcs return helper functions for templates
+func (r *Renderer) funcs() template.FuncMap {
+	return template.FuncMap{
+		// env returns variable value or optional default value if variable is not set,
+		// value is escaped, so it is not interpolated after rendering
+		`env`: func(name string, defaults ...string) (string, error) {
+			if value, ok := r.lookup(name); ok {
+				if r.interpolate {
+					value = strings.ReplaceAll(value, `${`, `$${`)
+				}
+				return value, nil
+			}
+			if len(defaults) > 0 {
+				return defaults[0], nil
+			}
+			if r.allowMissing {
+				return ``, nil
+			}
+			return ``, errors.Errorf(`variable "%s" is not set`, name)
+		},
+		// default returns value or fallback if value is empty
+		`default`: func(fallback string, value string) string {
+			if value == `` {
+				return fallback
+			}
+			return value
+		},
+		// split splits string by separator and trims spaces, empty items are skipped
+		`split`: func(separator string, value string) []string {
+			result := make([]string, 0)
+			for _, item := range strings.Split(value, separator) {
+				if item = strings.TrimSpace(item); item != `` {
+					result = append(result, item)
+				}
+			}
+			return result
+		},
+		`join`: func(separator string, items []string) string {
+			return strings.Join(items, separator)
+		},
+		// seq returns list of numbers from 1 to count, count can be string, e.g. from env
+		`seq`: func(count interface{}) ([]int, error) {
+			n, err := cast.ToIntE(count)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]int, 0)
+			for i := 1; i <= n; i++ {
+				result = append(result, i)
+			}
+			return result, nil
+		},
+		`add`: func(a, b interface{}) (int, error) {
+			x, err := cast.ToIntE(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := cast.ToIntE(b)
+			if err != nil {
+				return 0, err
+			}
+			return x + y, nil
+		},
+		`lower`:   strings.ToLower,
+		`upper`:   strings.ToUpper,
+		`trim`:    strings.TrimSpace,
+		`replace`: func(old, new, value string) string { return strings.ReplaceAll(value, old, new) },
+		`quote`: func(value string) string {
+			return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+		},
+	}
+}
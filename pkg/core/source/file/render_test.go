@@ -0,0 +1,185 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewRenderer(t *testing.T) {
+	suite.Run(t, new(newRendererTestSuite))
+}
+
+func TestRenderer_Render(t *testing.T) {
+	suite.Run(t, new(rendererRenderTestSuite))
+}
+
+// --- Suites ---
+
+type newRendererTestSuite struct {
+	suite.Suite
+}
+
+func (s *newRendererTestSuite) TestNewRenderer() {
+	got := NewRenderer(nil, true, true, true)
+	s.Equal(&Renderer{nil, true, true, true}, got)
+}
+
+type rendererRenderTestSuite struct {
+	suite.Suite
+	env LookupEnv
+}
+
+func (s *rendererRenderTestSuite) SetupTest() {
+	variables := map[string]string{
+		`ENV`:   `prod`,
+		`EMPTY`: ``,
+		`HOSTS`: `10.0.0.1, 10.0.0.2`,
+		`COUNT`: `2`,
+	}
+	s.env = func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+}
+
+func (s *rendererRenderTestSuite) TestInterpolate() {
+	got, err := NewRenderer(s.env, true, false, false).Render(`services.yml`, []byte(
+		"- name: service-${ENV}\n"+
+			"  address: ${MISSING:-127.0.0.1}\n"+
+			"  meta: {a: \"${EMPTY:-default}\", b: \"${EMPTY-default}\", c: \"${MISSING-default}\", d: \"$${ENV}\", e: \"$ENV\"}\n",
+	))
+	s.NoError(err)
+	s.Equal(
+		"- name: service-prod\n"+
+			"  address: 127.0.0.1\n"+
+			"  meta: {a: \"default\", b: \"\", c: \"default\", d: \"${ENV}\", e: \"$ENV\"}\n",
+		string(got),
+	)
+}
+
+func (s *rendererRenderTestSuite) TestInterpolateMissing() {
+	got, err := NewRenderer(s.env, true, false, false).Render(`services.yml`, []byte(
+		"- name: сервис\n"+
+			"  address: ${ENV}-${MISSING}\n",
+	))
+	s.Nil(got)
+	s.EqualError(err, `services.yml:2:19: variable "MISSING" is not set`)
+}
+
+func (s *rendererRenderTestSuite) TestInterpolateAllowMissing() {
+	got, err := NewRenderer(s.env, true, false, true).Render(`services.yml`, []byte(`address: "${MISSING}"`))
+	s.NoError(err)
+	s.Equal(`address: ""`, string(got))
+}
+
+func (s *rendererRenderTestSuite) TestInterpolateUnterminated() {
+	got, err := NewRenderer(s.env, true, false, false).Render(`services.yml`, []byte("name: ${ENV\naddress: 127.0.0.1}"))
+	s.Nil(got)
+	s.EqualError(err, `services.yml:1:7: unterminated variable`)
+}
+
+func (s *rendererRenderTestSuite) TestInterpolateInvalid() {
+	got, err := NewRenderer(s.env, true, false, false).Render(`services.yml`, []byte("name: ${ENV:default}"))
+	s.Nil(got)
+	s.EqualError(err, `services.yml:1:7: invalid variable "${ENV:default}"`)
+
+	got, err = NewRenderer(s.env, true, false, false).Render(`services.yml`, []byte("name: ${1ENV}"))
+	s.Nil(got)
+	s.EqualError(err, `services.yml:1:7: invalid variable "${1ENV}"`)
+}
+
+func (s *rendererRenderTestSuite) TestInterpolateDisabled() {
+	got, err := NewRenderer(s.env, false, false, false).Render(`services.yml`, []byte("# ${ comment\nname: ${ENV}"))
+	s.NoError(err)
+	s.Equal("# ${ comment\nname: ${ENV}", string(got))
+}
+
+func (s *rendererRenderTestSuite) TestTemplateDisabled() {
+	got, err := NewRenderer(s.env, false, false, false).Render(`services.yml`, []byte(`name: "{{ env "ENV" }}"`))
+	s.NoError(err)
+	s.Equal(`name: "{{ env "ENV" }}"`, string(got))
+}
+
+func (s *rendererRenderTestSuite) TestTemplate() {
+	got, err := NewRenderer(s.env, false, true, false).Render(`services.yml`, []byte(`
+{{- range $index, $host := split "," (env "HOSTS") }}
+- name: {{ env "ENV" | upper }}-node-{{ add $index 1 }}
+  address: {{ $host }}
+  tags: [{{ join "," (split "," "a,b") }}]
+{{- end }}
+{{- range seq (env "COUNT") }}
+- name: worker-{{ . }}
+  address: {{ env "MISSING" "127.0.0.1" }}
+  meta: {env: {{ env "EMPTY" | default "dev" | quote }}}
+{{- end }}
+`))
+	s.NoError(err)
+	s.Equal(`
+- name: PROD-node-1
+  address: 10.0.0.1
+  tags: [a,b]
+- name: PROD-node-2
+  address: 10.0.0.2
+  tags: [a,b]
+- name: worker-1
+  address: 127.0.0.1
+  meta: {env: "dev"}
+- name: worker-2
+  address: 127.0.0.1
+  meta: {env: "dev"}
+`, string(got))
+}
+
+func (s *rendererRenderTestSuite) TestTemplateMissing() {
+	got, err := NewRenderer(s.env, false, true, false).Render(`services.yml`, []byte("- name: a\n  address: {{ env \"MISSING\" }}\n"))
+	s.Nil(got)
+	s.Error(err)
+	s.Contains(err.Error(), `services.yml:2:14`)
+	s.Contains(err.Error(), `variable "MISSING" is not set`)
+}
+
+func (s *rendererRenderTestSuite) TestTemplateAllowMissing() {
+	got, err := NewRenderer(s.env, false, true, true).Render(`services.yml`, []byte(`address: "{{ env "MISSING" }}"`))
+	s.NoError(err)
+	s.Equal(`address: ""`, string(got))
+}
+
+func (s *rendererRenderTestSuite) TestTemplateParseError() {
+	got, err := NewRenderer(s.env, false, true, false).Render(`services.yml`, []byte("- name: a\n  address: {{ range }}\n"))
+	s.Nil(got)
+	s.Error(err)
+	s.Contains(err.Error(), `services.yml:2`)
+}
+
+func (s *rendererRenderTestSuite) TestTemplateSeqError() {
+	got, err := NewRenderer(s.env, false, true, false).Render(`services.yml`, []byte(`{{ range seq "many" }}{{ end }}`))
+	s.Nil(got)
+	s.Error(err)
+}
+
+func (s *rendererRenderTestSuite) TestTemplateBeforeInterpolate() {
+	variables := map[string]string{
+		`ENV`:      `prod`,
+		`TEMPLATE`: `{{ env "ENV" }}`,
+		`LITERAL`:  `${ENV}`,
+	}
+	env := func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+	got, err := NewRenderer(env, true, true, false).Render(`services.yml`, []byte(
+		"- name: ${TEMPLATE}\n"+
+			"  address: {{ env \"LITERAL\" }}\n"+
+			"  meta: {env: \"{{ env \"ENV\" }}-${ENV}\"}\n",
+	))
+	s.NoError(err)
+	s.Equal(
+		"- name: {{ env \"ENV\" }}\n"+
+			"  address: ${ENV}\n"+
+			"  meta: {env: \"prod-prod\"}\n",
+		string(got),
+	)
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/insidieux/pinchy/pkg/core"
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v3"
 )
 
 type (
@@ -17,25 +16,35 @@ type (
 	// Path is custom type for file path string
 	Path string
 
+	// Watch enables notifications about file changes with Source.Watch
+	Watch bool
+
 	// Source is implementation of core.Source interface
 	Source struct {
 		reader   Reader
 		filename Path
+		watch    Watch
+		renderer *Renderer
+		decoder  *Decoder
 		logger   core.LoggerInterface
 	}
 )
 
 // NewSource provide Source as core.Source implementation
-func NewSource(reader Reader, filename Path) *Source {
+func NewSource(reader Reader, filename Path, watch Watch, renderer *Renderer, decoder *Decoder) *Source {
 	return &Source{
 		reader:   reader,
 		filename: filename,
+		watch:    watch,
+		renderer: renderer,
+		decoder:  decoder,
 	}
 }
 
 // Fetch provide information about core.Services from file
 // - call Reader.ReadFile
-// - yaml.Unmarshal contents
+// - Renderer.Render contents
+// - Decoder.Decode contents by file format
 // - validate core.Service
 // - return core.Services
 func (s *Source) Fetch(ctx context.Context) (core.Services, error) {
@@ -45,9 +54,15 @@ func (s *Source) Fetch(ctx context.Context) (core.Services, error) {
 		return nil, errors.Wrap(err, `failed read content from config file`)
 	}
 
-	s.logger.Infoln(`Decoding yml config`)
-	items := make([]*core.Service, 0)
-	if err := yaml.Unmarshal(contents, &items); err != nil {
+	s.logger.Infoln(`Rendering config`)
+	contents, err = s.renderer.Render(string(s.filename), contents)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed render content from config file`)
+	}
+
+	s.logger.Infof(`Decoding %s config`, s.decoder.Format(string(s.filename)))
+	items, err := s.decoder.Decode(string(s.filename), contents)
+	if err != nil {
 		return nil, errors.Wrap(err, `failed unmarshal content from config file`)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/agrea/ptr"
 	"github.com/insidieux/pinchy/pkg/core"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -34,9 +35,10 @@ type newSourceTestSuite struct {
 }
 
 func (s *newSourceTestSuite) TestNewSource() {
-	got := NewSource(nil, `filename`)
+	got := NewSource(nil, `filename`, false, nil, nil)
 	s.Implements((*core.Source)(nil), got)
-	s.Equal(&Source{nil, `filename`, nil}, got)
+	s.Implements((*core.WatchableSource)(nil), got)
+	s.Equal(&Source{nil, `filename`, false, nil, nil, nil}, got)
 }
 
 type sourceFetchTestSuite struct {
@@ -48,7 +50,7 @@ type sourceFetchTestSuite struct {
 
 func (s *sourceFetchTestSuite) SetupTest() {
 	s.reader = afero.Afero{Fs: afero.NewMemMapFs()}
-	s.source = NewSource(s.reader, `filename`)
+	s.source = NewSource(s.reader, `filename`, false, NewRenderer(nil, false, false, false), NewDecoder(FormatAuto, nil))
 	s.source.logger, s.hook = test.NewNullLogger()
 }
 
@@ -59,6 +61,17 @@ func (s *sourceFetchTestSuite) TestErrorRead() {
 	s.EqualError(err, `failed read content from config file: open filename: file does not exist`)
 }
 
+func (s *sourceFetchTestSuite) TestErrorRender() {
+	s.source.renderer = NewRenderer(nil, true, false, false)
+	if err := s.reader.WriteFile(string(s.source.filename), []byte(`- name: ${}`), 0644); err != nil {
+		panic(errors.Wrap(err, `failed to write to in-memory file`))
+	}
+
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed render content from config file: filename:1:9: invalid variable "${}"`)
+}
+
 func (s *sourceFetchTestSuite) TestErrorUnmarshal() {
 	inMemoryFile, err := s.reader.Create(string(s.source.filename))
 	if err != nil {
@@ -128,12 +141,40 @@ func (s *sourceFetchTestSuite) TestSuccess() {
 	s.Equal(expected, services)
 }
 
+func (s *sourceFetchTestSuite) TestChecks() {
+	if err := s.reader.WriteFile(string(s.source.filename), []byte(`
+- name: service
+  address: 127.0.0.1
+  checks:
+    - name: http
+      http: http://127.0.0.1/health
+      interval: 10s
+      tlsskipverify: true
+    - name: ttl
+      ttl: 30s
+      deregistercriticalserviceafter: 10m
+`), 0644); err != nil {
+		panic(errors.Wrap(err, `failed to write to in-memory file`))
+	}
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{
+		Name:    `service`,
+		Address: `127.0.0.1`,
+		Checks: &core.Checks{
+			{Name: `http`, HTTP: ptr.String(`http://127.0.0.1/health`), Interval: ptr.String(`10s`), TLSSkipVerify: true},
+			{Name: `ttl`, TTL: ptr.String(`30s`), DeregisterCriticalServiceAfter: ptr.String(`10m`)},
+		},
+	}}, services)
+}
+
 type sourceWithLoggerTestSuite struct {
 	suite.Suite
 }
 
 func (s *sourceWithLoggerTestSuite) TestWithLogger() {
 	logger, _ := test.NewNullLogger()
-	src := NewSource(nil, `filename`)
+	src := NewSource(nil, `filename`, false, nil, nil)
 	src.WithLogger(logger)
 }
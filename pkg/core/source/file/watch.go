@@ -0,0 +1,121 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+// Watch is implementation of core.WatchableSource interface.
+// Watch starts watching file for changes until context.Context canceled and return channel with notifications.
+// Parent directory is watched instead of file, so atomic rename-replace and symlink swap (e.g. Kubernetes ConfigMap)
+// are detected too. Notifications are coalesced: channel is buffered and never blocks watcher.
+// Watch returns nil channel, if watching is disabled by Watch flag
+func (s *Source) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if !s.watch {
+		return nil, nil
+	}
+	filename, err := filepath.Abs(string(s.filename))
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to resolve absolute path of file "%s"`, s.filename)
+	}
+
+	target, _ := filepath.EvalSymlinks(filename)
+	notifications, err := watchDirectory(ctx, s.logger, filepath.Dir(filename), func(event fsnotify.Event) bool {
+		current, _ := filepath.EvalSymlinks(filename)
+		changed := filepath.Clean(event.Name) == filename && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+		if !changed && (current == `` || current == target) {
+			return false
+		}
+		target = current
+		s.logger.Infof(`File "%s" was changed`, s.filename)
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to watch directory of file "%s"`, s.filename)
+	}
+	s.logger.Infof(`Watching file "%s" for changes`, s.filename)
+	return notifications, nil
+}
+
+// Watch is implementation of core.WatchableSource interface.
+// Watch starts watching directory of Pattern until context.Context canceled and return channel with notifications.
+// Creating, changing, removing and renaming of files matched by Pattern are detected, as well as
+// Kubernetes ConfigMap updates, which swap hidden "..data" symlink. Notifications are coalesced.
+// Watch returns nil channel, if watching is disabled by Watch flag
+func (s *DirSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if !s.watch {
+		return nil, nil
+	}
+	pattern, err := filepath.Abs(string(s.pattern))
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to resolve absolute path of pattern "%s"`, s.pattern)
+	}
+	dir := filepath.Dir(pattern)
+	if strings.ContainsAny(dir, `*?[\`) {
+		return nil, errors.Errorf(`pattern "%s" cannot be watched, because directory contains wildcards`, s.pattern)
+	}
+
+	notifications, err := watchDirectory(ctx, s.logger, dir, func(event fsnotify.Event) bool {
+		name := filepath.Clean(event.Name)
+		matched, _ := filepath.Match(pattern, name)
+		if !matched && !strings.HasPrefix(filepath.Base(name), `..`) {
+			return false
+		}
+		s.logger.Infof(`File "%s" matched by pattern "%s" was changed`, name, s.pattern)
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to watch directory of pattern "%s"`, s.pattern)
+	}
+	s.logger.Infof(`Watching files matched by pattern "%s" for changes`, s.pattern)
+	return notifications, nil
+}
+
+// watchDirectory starts watching directory until context.Context canceled.
+// Notification is sent for every event accepted by changed func
+func watchDirectory(ctx context.Context, logger core.LoggerInterface, dir string, changed func(fsnotify.Event) bool) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create file watcher`)
+	}
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	notifications := make(chan struct{}, 1)
+	go func() {
+		defer close(notifications)
+		defer func() {
+			_ = watcher.Close()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !changed(event) {
+					continue
+				}
+				select {
+				case notifications <- struct{}{}:
+				default:
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorln(errors.Wrapf(err, `failed to watch directory "%s"`, dir).Error())
+			}
+		}
+	}()
+	return notifications, nil
+}
@@ -0,0 +1,211 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestSource_Watch(t *testing.T) {
+	suite.Run(t, new(sourceWatchTestSuite))
+}
+
+func TestDirSource_Watch(t *testing.T) {
+	suite.Run(t, new(dirSourceWatchTestSuite))
+}
+
+// --- Suites ---
+
+type sourceWatchTestSuite struct {
+	suite.Suite
+	dir      string
+	filename string
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func (s *sourceWatchTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir(``, `pinchy-watch`)
+	s.Require().NoError(err)
+	s.dir = dir
+	s.filename = filepath.Join(dir, `services.yml`)
+	s.Require().NoError(ioutil.WriteFile(s.filename, []byte(`[]`), 0600))
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *sourceWatchTestSuite) TearDownTest() {
+	s.cancel()
+	_ = os.RemoveAll(s.dir)
+}
+
+func (s *sourceWatchTestSuite) watch() <-chan struct{} {
+	source := NewSource(nil, Path(s.filename), true, nil, nil)
+	source.logger, _ = test.NewNullLogger()
+	notifications, err := source.Watch(s.ctx)
+	s.Require().NoError(err)
+	s.Require().NotNil(notifications)
+	return notifications
+}
+
+func (s *sourceWatchTestSuite) assertNotified(notifications <-chan struct{}) {
+	select {
+	case <-notifications:
+	case <-time.After(2 * time.Second):
+		s.Fail(`notification was not received`)
+	}
+}
+
+func (s *sourceWatchTestSuite) TestDisabled() {
+	notifications, err := NewSource(nil, Path(s.filename), false, nil, nil).Watch(s.ctx)
+	s.NoError(err)
+	s.Nil(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestErrorMissingDirectory() {
+	source := NewSource(nil, Path(filepath.Join(s.dir, `missing`, `services.yml`)), true, nil, nil)
+	notifications, err := source.Watch(s.ctx)
+	s.Nil(notifications)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to watch directory of file`)
+}
+
+func (s *sourceWatchTestSuite) TestWrite() {
+	notifications := s.watch()
+	s.Require().NoError(ioutil.WriteFile(s.filename, []byte(`[{}]`), 0600))
+	s.assertNotified(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestRenameReplace() {
+	notifications := s.watch()
+	tmp := filepath.Join(s.dir, `.services.yml.tmp`)
+	s.Require().NoError(ioutil.WriteFile(tmp, []byte(`[{}]`), 0600))
+	s.Require().NoError(os.Rename(tmp, s.filename))
+	s.assertNotified(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestSymlinkSwap() {
+	s.Require().NoError(os.Mkdir(filepath.Join(s.dir, `v1`), 0700))
+	s.Require().NoError(os.Mkdir(filepath.Join(s.dir, `v2`), 0700))
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.dir, `v1`, `services.yml`), []byte(`[]`), 0600))
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.dir, `v2`, `services.yml`), []byte(`[{}]`), 0600))
+	s.Require().NoError(os.Symlink(`v1`, filepath.Join(s.dir, `data`)))
+	s.Require().NoError(os.Remove(s.filename))
+	s.Require().NoError(os.Symlink(filepath.Join(`data`, `services.yml`), s.filename))
+
+	notifications := s.watch()
+	tmp := filepath.Join(s.dir, `data.tmp`)
+	s.Require().NoError(os.Symlink(`v2`, tmp))
+	s.Require().NoError(os.Rename(tmp, filepath.Join(s.dir, `data`)))
+	s.assertNotified(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestOtherFile() {
+	notifications := s.watch()
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.dir, `other.yml`), []byte(`[]`), 0600))
+	select {
+	case <-notifications:
+		s.Fail(`unexpected notification`)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (s *sourceWatchTestSuite) TestCanceled() {
+	notifications := s.watch()
+	s.cancel()
+	select {
+	case _, ok := <-notifications:
+		s.False(ok)
+	case <-time.After(2 * time.Second):
+		s.Fail(`channel was not closed`)
+	}
+}
+
+type dirSourceWatchTestSuite struct {
+	suite.Suite
+	dir    string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *dirSourceWatchTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir(``, `pinchy-watch`)
+	s.Require().NoError(err)
+	s.dir = dir
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *dirSourceWatchTestSuite) TearDownTest() {
+	s.cancel()
+	_ = os.RemoveAll(s.dir)
+}
+
+func (s *dirSourceWatchTestSuite) watch() <-chan struct{} {
+	source := NewDirSource(nil, nil, Pattern(filepath.Join(s.dir, `*.yml`)), ``, true, nil, nil)
+	source.logger, _ = test.NewNullLogger()
+	notifications, err := source.Watch(s.ctx)
+	s.Require().NoError(err)
+	s.Require().NotNil(notifications)
+	return notifications
+}
+
+func (s *dirSourceWatchTestSuite) TestDisabled() {
+	notifications, err := NewDirSource(nil, nil, Pattern(filepath.Join(s.dir, `*.yml`)), ``, false, nil, nil).Watch(s.ctx)
+	s.NoError(err)
+	s.Nil(notifications)
+}
+
+func (s *dirSourceWatchTestSuite) TestErrorWildcardDirectory() {
+	source := NewDirSource(nil, nil, Pattern(filepath.Join(s.dir, `*`, `services.yml`)), ``, true, nil, nil)
+	notifications, err := source.Watch(s.ctx)
+	s.Nil(notifications)
+	s.Error(err)
+	s.Contains(err.Error(), `cannot be watched, because directory contains wildcards`)
+}
+
+func (s *dirSourceWatchTestSuite) TestErrorMissingDirectory() {
+	source := NewDirSource(nil, nil, Pattern(filepath.Join(s.dir, `missing`, `*.yml`)), ``, true, nil, nil)
+	notifications, err := source.Watch(s.ctx)
+	s.Nil(notifications)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to watch directory of pattern`)
+}
+
+func (s *dirSourceWatchTestSuite) TestCreate() {
+	notifications := s.watch()
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.dir, `payments.yml`), []byte(`[]`), 0600))
+	select {
+	case <-notifications:
+	case <-time.After(2 * time.Second):
+		s.Fail(`notification was not received`)
+	}
+}
+
+func (s *dirSourceWatchTestSuite) TestRemove() {
+	filename := filepath.Join(s.dir, `payments.yml`)
+	s.Require().NoError(ioutil.WriteFile(filename, []byte(`[]`), 0600))
+	notifications := s.watch()
+	s.Require().NoError(os.Remove(filename))
+	select {
+	case <-notifications:
+	case <-time.After(2 * time.Second):
+		s.Fail(`notification was not received`)
+	}
+}
+
+func (s *dirSourceWatchTestSuite) TestOtherFile() {
+	notifications := s.watch()
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.dir, `other.json`), []byte(`[]`), 0600))
+	select {
+	case <-notifications:
+		s.Fail(`unexpected notification`)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	headerAccept          = `Accept`
+	headerContentType     = `Content-Type`
+	headerETag            = `ETag`
+	headerLastModified    = `Last-Modified`
+	headerIfNoneMatch     = `If-None-Match`
+	headerIfModifiedSince = `If-Modified-Since`
+
+	acceptedContentTypes = `application/json, application/yaml;q=0.9, text/yaml;q=0.9, */*;q=0.1`
+)
+
+type (
+	// Client sends HTTP requests. Implemented by http.Client
+	Client interface {
+		Do(req *http.Request) (*http.Response, error)
+	}
+
+	// URL is custom type for address of HTTP endpoint with services list
+	URL string
+
+	// Headers are added to every request, e.g. Authorization header
+	Headers map[string]string
+
+	// Source is implementation of core.Source interface
+	Source struct {
+		client  Client
+		url     URL
+		headers Headers
+		logger  core.LoggerInterface
+		cache   cache
+		mu      sync.Mutex
+	}
+
+	// cache contains services of last response with validators for conditional request
+	cache struct {
+		etag         string
+		lastModified string
+		services     core.Services
+	}
+)
+
+// NewSource provide Source as core.Source implementation
+func NewSource(client Client, url URL, headers Headers) *Source {
+	return &Source{
+		client:  client,
+		url:     url,
+		headers: headers,
+	}
+}
+
+// Fetch provide information about core.Services from HTTP endpoint
+// - send GET request with If-None-Match and If-Modified-Since headers, if previous response contained ETag or Last-Modified
+// - return cached core.Services, if endpoint responded with 304 Not Modified
+// - json.Unmarshal or yaml.Unmarshal body according to Content-Type
+// - validate core.Service
+// - return core.Services
+func (s *Source) Fetch(ctx context.Context) (core.Services, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, string(s.url), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create request`)
+	}
+	request.Header.Set(headerAccept, acceptedContentTypes)
+	for key, value := range s.headers {
+		request.Header.Set(key, value)
+	}
+	if s.cache.etag != `` {
+		request.Header.Set(headerIfNoneMatch, s.cache.etag)
+	}
+	if s.cache.lastModified != `` {
+		request.Header.Set(headerIfModifiedSince, s.cache.lastModified)
+	}
+
+	s.logger.Infof(`Sending request to "%s"`, s.url)
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to send request`)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && s.cache.services != nil {
+		s.logger.Infoln(`Services are not modified, using services from previous response`)
+		return s.cached(), nil
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, errors.Errorf(`unexpected response status "%s"`, response.Status)
+	}
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to read response body`)
+	}
+
+	items := make([]*core.Service, 0)
+	if isJSON(response.Header.Get(headerContentType)) {
+		s.logger.Infoln(`Decoding json response`)
+		err = json.Unmarshal(contents, &items)
+	} else {
+		s.logger.Infoln(`Decoding yml response`)
+		err = yaml.Unmarshal(contents, &items)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, `failed unmarshal response body`)
+	}
+
+	s.logger.Infoln(`Collecting services list with service validation`)
+	result := make([]*core.Service, 0)
+	for index, item := range items {
+		if err := item.Validate(ctx); err != nil {
+			s.logger.Warningln(errors.Wrapf(err, `Failed to validate service #%d`, index).Error())
+			continue
+		}
+		result = append(result, item)
+	}
+
+	s.cache = cache{
+		etag:         response.Header.Get(headerETag),
+		lastModified: response.Header.Get(headerLastModified),
+	}
+	if s.cache.etag != `` || s.cache.lastModified != `` {
+		s.cache.services = result
+		return s.cached(), nil
+	}
+	return result, nil
+}
+
+// WithLogger is implementation of core.Loggable interface
+func (s *Source) WithLogger(logger core.LoggerInterface) {
+	s.logger = logger
+}
+
+// cached return copy of cached core.Services, so cache is not changed by transformers
+func (s *Source) cached() core.Services {
+	result := make(core.Services, 0, len(s.cache.services))
+	for _, service := range s.cache.services {
+		result = append(result, service.Clone())
+	}
+	return result
+}
+
+// isJSON return true for application/json and application/*+json media types
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == `application/json` || strings.HasSuffix(mediaType, `+json`)
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewSource(t *testing.T) {
+	suite.Run(t, new(newSourceTestSuite))
+}
+
+func TestSource_Fetch(t *testing.T) {
+	suite.Run(t, new(sourceFetchTestSuite))
+}
+
+func TestSource_WithLogger(t *testing.T) {
+	suite.Run(t, new(sourceWithLoggerTestSuite))
+}
+
+// --- Suites ---
+
+type newSourceTestSuite struct {
+	suite.Suite
+}
+
+func (s *newSourceTestSuite) TestNewSource() {
+	client := http.DefaultClient
+	headers := Headers{`key`: `value`}
+	got := NewSource(client, `http://127.0.0.1`, headers)
+	s.Implements((*core.Source)(nil), got)
+	s.Implements((*core.Loggable)(nil), got)
+	s.Equal(client, got.client)
+	s.Equal(URL(`http://127.0.0.1`), got.url)
+	s.Equal(headers, got.headers)
+}
+
+type sourceFetchTestSuite struct {
+	suite.Suite
+	handler http.HandlerFunc
+	server  *httptest.Server
+	source  *Source
+	hook    *test.Hook
+}
+
+func (s *sourceFetchTestSuite) SetupTest() {
+	s.handler = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.handler(w, r)
+	}))
+	s.source = NewSource(s.server.Client(), URL(s.server.URL), Headers{`Authorization`: `Bearer token`})
+	s.source.logger, s.hook = test.NewNullLogger()
+}
+
+func (s *sourceFetchTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *sourceFetchTestSuite) TestErrorRequest() {
+	s.source.url = "http://127.0.0.1:80/\x7f"
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to create request`)
+}
+
+func (s *sourceFetchTestSuite) TestErrorSend() {
+	s.server.Close()
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to send request`)
+}
+
+func (s *sourceFetchTestSuite) TestErrorStatus() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `unexpected response status "500 Internal Server Error"`)
+}
+
+func (s *sourceFetchTestSuite) TestErrorUnmarshal() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, `application/json`)
+		_, _ = w.Write([]byte(`{"key": "value"}`))
+	}
+	services, err := s.source.Fetch(context.Background())
+	s.Nil(services)
+	s.Error(err)
+	s.Contains(err.Error(), `failed unmarshal response body`)
+}
+
+func (s *sourceFetchTestSuite) TestSkipServiceValidationCase() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, `application/json`)
+		_, _ = w.Write([]byte(`[{"name": "service"}]`))
+	}
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{}, services)
+	s.Equal(logrus.WarnLevel, s.hook.LastEntry().Level)
+	s.Equal(`Failed to validate service #0: service "service" field "address" is required and cannot be empty`, s.hook.LastEntry().Message)
+}
+
+func (s *sourceFetchTestSuite) TestSuccessJSON() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodGet, r.Method)
+		s.Equal(`Bearer token`, r.Header.Get(`Authorization`))
+		s.Equal(acceptedContentTypes, r.Header.Get(headerAccept))
+		w.Header().Set(headerContentType, `application/json; charset=utf-8`)
+		_, _ = w.Write([]byte(`[{"Name": "service-1", "Address": "127.0.0.1"}, {"name": "service-2", "address": "127.0.0.2"}]`))
+	}
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+		{Name: `service-2`, Address: `127.0.0.2`},
+	}, services)
+}
+
+func (s *sourceFetchTestSuite) TestSuccessYAML() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, `application/yaml`)
+		_, _ = w.Write([]byte("- name: service\n  address: 127.0.0.1\n"))
+	}
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `service`, Address: `127.0.0.1`}}, services)
+}
+
+func (s *sourceFetchTestSuite) TestNotModified() {
+	requests := 0
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get(headerIfNoneMatch) == `"v1"` && r.Header.Get(headerIfModifiedSince) == `Fri, 01 Jan 2021 00:00:00 GMT` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(headerETag, `"v1"`)
+		w.Header().Set(headerLastModified, `Fri, 01 Jan 2021 00:00:00 GMT`)
+		_, _ = w.Write([]byte("- name: service\n  address: 127.0.0.1\n"))
+	}
+	expected := core.Services{{Name: `service`, Address: `127.0.0.1`}}
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(expected, services)
+	services[0].Name = `changed`
+
+	services, err = s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(expected, services)
+	s.Equal(2, requests)
+	s.Equal(`Services are not modified, using services from previous response`, s.hook.LastEntry().Message)
+}
+
+func (s *sourceFetchTestSuite) TestModified() {
+	version := `"v1"`
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerIfNoneMatch) == version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(headerETag, version)
+		_, _ = w.Write([]byte("- name: service-" + version[2:3] + "\n  address: 127.0.0.1\n"))
+	}
+
+	services, err := s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `service-1`, Address: `127.0.0.1`}}, services)
+
+	version = `"v2"`
+	services, err = s.source.Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `service-2`, Address: `127.0.0.1`}}, services)
+	s.Equal(`"v2"`, s.source.cache.etag)
+}
+
+func (s *sourceFetchTestSuite) TestWithoutValidators() {
+	s.handler = func(w http.ResponseWriter, r *http.Request) {
+		s.Empty(r.Header.Get(headerIfNoneMatch))
+		s.Empty(r.Header.Get(headerIfModifiedSince))
+		_, _ = w.Write([]byte("- name: service\n  address: 127.0.0.1\n"))
+	}
+	for i := 0; i < 2; i++ {
+		_, err := s.source.Fetch(context.Background())
+		s.NoError(err)
+	}
+	s.Nil(s.source.cache.services)
+}
+
+type sourceWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *sourceWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	source := NewSource(nil, ``, nil)
+	source.WithLogger(logger)
+	s.Equal(logger, source.logger)
+}
@@ -0,0 +1,181 @@
+package multi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PrecedenceFirst means Service from Member listed earlier wins
+	PrecedenceFirst Precedence = `first`
+	// PrecedenceLast means Service from Member listed later wins, e.g. overlays override base list
+	PrecedenceLast Precedence = `last`
+)
+
+type (
+	// Precedence describes which Member wins, when several members return Service with same core.Service RegistrationID
+	Precedence string
+
+	// Strict is custom type for strict mode flag. In strict mode Source returns error for duplicated services
+	Strict bool
+
+	// Member is named core.Source, which is merged by Source
+	Member struct {
+		Name   string
+		Source core.Source
+	}
+
+	// Source is implementation of core.Source interface, which merges services from several members
+	Source struct {
+		members    []Member
+		precedence Precedence
+		strict     Strict
+		logger     core.LoggerInterface
+	}
+
+	// DuplicateError contains information about all duplicated services found during Source.Fetch
+	DuplicateError []string
+
+	fetchResult struct {
+		services core.Services
+		err      error
+	}
+)
+
+// NewSource provide Source as core.Source implementation
+func NewSource(members []Member, precedence Precedence, strict Strict) (*Source, error) {
+	switch precedence {
+	case PrecedenceFirst, PrecedenceLast:
+	default:
+		return nil, errors.Errorf(`unknown precedence "%s", available values: %s, %s`, precedence, PrecedenceFirst, PrecedenceLast)
+	}
+	return &Source{
+		members:    members,
+		precedence: precedence,
+		strict:     strict,
+	}, nil
+}
+
+// Fetch provide merged information about core.Services from all members
+// - call Fetch of every member concurrently
+// - merge services by core.Service RegistrationID according to Precedence
+// - report duplicated services within one member or across several members
+func (s *Source) Fetch(ctx context.Context) (core.Services, error) {
+	s.logger.Infof(`Fetching services from %d sources`, len(s.members))
+	results := make([]fetchResult, len(s.members))
+	wg := new(sync.WaitGroup)
+	for index, member := range s.members {
+		wg.Add(1)
+		go func(index int, member Member) {
+			defer wg.Done()
+			services, err := member.Source.Fetch(ctx)
+			results[index] = fetchResult{services, err}
+		}(index, member)
+	}
+	wg.Wait()
+
+	for index, result := range results {
+		if result.err != nil {
+			return nil, errors.Wrapf(result.err, `failed to fetch services from source "%s"`, s.members[index].Name)
+		}
+	}
+
+	order := make([]int, 0, len(s.members))
+	for index := range s.members {
+		if s.precedence == PrecedenceLast {
+			index = len(s.members) - index - 1
+		}
+		order = append(order, index)
+	}
+
+	var duplicates DuplicateError
+	owners := make(map[string]string)
+	merged := make(core.Services, 0)
+	for _, index := range order {
+		name := s.members[index].Name
+		for _, service := range results[index].services {
+			id := service.RegistrationID()
+			owner, found := owners[id]
+			if !found {
+				owners[id] = name
+				merged = append(merged, service)
+				continue
+			}
+			var message string
+			if owner == name {
+				message = fmt.Sprintf(`service "%s" is defined more than once in source "%s", first definition is used`, id, name)
+			} else {
+				message = fmt.Sprintf(`service "%s" is defined in sources "%s" and "%s", definition from "%s" is used`, id, owner, name, owner)
+			}
+			s.logger.Warningln(message)
+			duplicates = append(duplicates, message)
+		}
+	}
+
+	if s.strict && len(duplicates) > 0 {
+		return nil, errors.Wrap(duplicates, `duplicated services found`)
+	}
+	return merged, nil
+}
+
+// Watch is implementation of core.WatchableSource interface. Notifications of all watchable members are merged,
+// channel is closed when all member channels are closed. Watch returns nil channel, if no member notifies about changes
+func (s *Source) Watch(ctx context.Context) (<-chan struct{}, error) {
+	channels := make([]<-chan struct{}, 0, len(s.members))
+	for _, member := range s.members {
+		ws, ok := member.Source.(core.WatchableSource)
+		if !ok {
+			continue
+		}
+		notifications, err := ws.Watch(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to watch source "%s"`, member.Name)
+		}
+		if notifications != nil {
+			channels = append(channels, notifications)
+		}
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	merged := make(chan struct{}, 1)
+	wg := new(sync.WaitGroup)
+	for _, notifications := range channels {
+		wg.Add(1)
+		go func(notifications <-chan struct{}) {
+			defer wg.Done()
+			for range notifications {
+				select {
+				case merged <- struct{}{}:
+				default:
+				}
+			}
+		}(notifications)
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged, nil
+}
+
+// WithLogger is implementation of core.Loggable interface. Logger is passed to all members
+func (s *Source) WithLogger(logger core.LoggerInterface) {
+	s.logger = logger
+	for _, member := range s.members {
+		if loggable, ok := member.Source.(core.Loggable); ok {
+			loggable.WithLogger(logger)
+		}
+	}
+}
+
+// Error is implementation of error interface
+func (e DuplicateError) Error() string {
+	return strings.Join(e, `; `)
+}
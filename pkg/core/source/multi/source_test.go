@@ -0,0 +1,290 @@
+package multi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewSource(t *testing.T) {
+	suite.Run(t, new(newSourceTestSuite))
+}
+
+func TestSource_Fetch(t *testing.T) {
+	suite.Run(t, new(sourceFetchTestSuite))
+}
+
+func TestSource_WithLogger(t *testing.T) {
+	suite.Run(t, new(sourceWithLoggerTestSuite))
+}
+
+func TestSource_Watch(t *testing.T) {
+	suite.Run(t, new(sourceWatchTestSuite))
+}
+
+func TestDuplicateError_Error(t *testing.T) {
+	suite.Run(t, new(duplicateErrorErrorTestSuite))
+}
+
+// --- Suites ---
+
+type newSourceTestSuite struct {
+	suite.Suite
+}
+
+func (s *newSourceTestSuite) TestNewSource() {
+	got, err := NewSource(nil, PrecedenceFirst, true)
+	s.NoError(err)
+	s.Implements((*core.Source)(nil), got)
+	s.Implements((*core.WatchableSource)(nil), got)
+	s.Equal(&Source{nil, PrecedenceFirst, true, nil}, got)
+}
+
+func (s *newSourceTestSuite) TestUnknownPrecedence() {
+	got, err := NewSource(nil, `unknown`, false)
+	s.Nil(got)
+	s.EqualError(err, `unknown precedence "unknown", available values: first, last`)
+}
+
+type sourceFetchTestSuite struct {
+	suite.Suite
+	base    *MockSource
+	overlay *MockSource
+	hook    *test.Hook
+}
+
+func (s *sourceFetchTestSuite) SetupTest() {
+	s.base = new(MockSource)
+	s.overlay = new(MockSource)
+}
+
+func (s *sourceFetchTestSuite) newSource(precedence Precedence, strict Strict) *Source {
+	source, err := NewSource([]Member{{`base`, s.base}, {`overlay`, s.overlay}}, precedence, strict)
+	if err != nil {
+		panic(errors.Wrap(err, `failed to create source`))
+	}
+	source.logger, s.hook = test.NewNullLogger()
+	return source
+}
+
+func (s *sourceFetchTestSuite) TestErrorMemberFetch() {
+	s.base.On(`Fetch`, mock.Anything).Return(core.Services{}, nil)
+	s.overlay.On(`Fetch`, mock.Anything).Return(nil, errors.New(`expected error`))
+
+	services, err := s.newSource(PrecedenceLast, false).Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `failed to fetch services from source "overlay": expected error`)
+}
+
+func (s *sourceFetchTestSuite) TestPrecedenceLast() {
+	s.base.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+		{Name: `service-2`, Address: `127.0.0.1`},
+	}, nil)
+	s.overlay.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-2`, Address: `127.0.0.2`},
+	}, nil)
+
+	services, err := s.newSource(PrecedenceLast, false).Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `service-2`, Address: `127.0.0.2`},
+		{Name: `service-1`, Address: `127.0.0.1`},
+	}, services)
+	s.Equal(logrus.WarnLevel, s.hook.LastEntry().Level)
+	s.Equal(`service "service-2" is defined in sources "overlay" and "base", definition from "overlay" is used`, s.hook.LastEntry().Message)
+}
+
+func (s *sourceFetchTestSuite) TestPrecedenceFirst() {
+	s.base.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+	}, nil)
+	s.overlay.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.2`},
+	}, nil)
+
+	services, err := s.newSource(PrecedenceFirst, false).Fetch(context.Background())
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `service-1`, Address: `127.0.0.1`}}, services)
+}
+
+func (s *sourceFetchTestSuite) TestStrict() {
+	s.base.On(`Fetch`, mock.Anything).Return(core.Services{
+		{Name: `service-1`, Address: `127.0.0.1`},
+		{Name: `service-1`, Address: `127.0.0.2`},
+	}, nil)
+	s.overlay.On(`Fetch`, mock.Anything).Return(core.Services{}, nil)
+
+	services, err := s.newSource(PrecedenceFirst, true).Fetch(context.Background())
+	s.Nil(services)
+	s.EqualError(err, `duplicated services found: service "service-1" is defined more than once in source "base", first definition is used`)
+}
+
+type sourceWithLoggerTestSuite struct {
+	suite.Suite
+}
+
+func (s *sourceWithLoggerTestSuite) TestWithLogger() {
+	logger, _ := test.NewNullLogger()
+	member := new(MockLoggableSource)
+	member.On(`WithLogger`, logger).Return()
+	src, _ := NewSource([]Member{{`member`, member}}, PrecedenceFirst, false)
+	src.WithLogger(logger)
+	s.Equal(logger, src.logger)
+	member.AssertCalled(s.T(), `WithLogger`, logger)
+}
+
+type sourceWatchTestSuite struct {
+	suite.Suite
+}
+
+func (s *sourceWatchTestSuite) TestNotWatchable() {
+	src, err := NewSource([]Member{{Name: `base`, Source: new(MockSource)}}, PrecedenceLast, false)
+	s.NoError(err)
+	notifications, err := src.Watch(context.Background())
+	s.NoError(err)
+	s.Nil(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestDisabled() {
+	watchable := new(MockWatchableSource)
+	watchable.On(`Watch`, mock.Anything).Return(nil, nil)
+	src, err := NewSource([]Member{{Name: `base`, Source: watchable}}, PrecedenceLast, false)
+	s.NoError(err)
+	notifications, err := src.Watch(context.Background())
+	s.NoError(err)
+	s.Nil(notifications)
+}
+
+func (s *sourceWatchTestSuite) TestErrorWatch() {
+	watchable := new(MockWatchableSource)
+	watchable.On(`Watch`, mock.Anything).Return(nil, errors.New(`expected error`))
+	src, err := NewSource([]Member{{Name: `base`, Source: watchable}}, PrecedenceLast, false)
+	s.NoError(err)
+	notifications, err := src.Watch(context.Background())
+	s.Nil(notifications)
+	s.EqualError(err, `failed to watch source "base": expected error`)
+}
+
+func (s *sourceWatchTestSuite) TestMerge() {
+	first := make(chan struct{})
+	second := make(chan struct{})
+	firstSource := new(MockWatchableSource)
+	firstSource.On(`Watch`, mock.Anything).Return((<-chan struct{})(first), nil)
+	secondSource := new(MockWatchableSource)
+	secondSource.On(`Watch`, mock.Anything).Return((<-chan struct{})(second), nil)
+	src, err := NewSource([]Member{
+		{Name: `first`, Source: firstSource},
+		{Name: `static`, Source: new(MockSource)},
+		{Name: `second`, Source: secondSource},
+	}, PrecedenceLast, false)
+	s.NoError(err)
+
+	notifications, err := src.Watch(context.Background())
+	s.NoError(err)
+	s.NotNil(notifications)
+
+	first <- struct{}{}
+	s.True(s.receive(notifications))
+	second <- struct{}{}
+	s.True(s.receive(notifications))
+
+	close(first)
+	close(second)
+	s.False(s.receive(notifications))
+}
+
+func (s *sourceWatchTestSuite) receive(notifications <-chan struct{}) bool {
+	select {
+	case _, ok := <-notifications:
+		return ok
+	case <-time.After(time.Second):
+		s.FailNow(`notification was not received`)
+	}
+	return false
+}
+
+type duplicateErrorErrorTestSuite struct {
+	suite.Suite
+}
+
+func (s *duplicateErrorErrorTestSuite) TestError() {
+	s.Equal(`message 1; message 2`, DuplicateError{`message 1`, `message 2`}.Error())
+}
+
+// --- Mocks ---
+
+// MockSource is an autogenerated mock type for the Source type
+type MockSource struct {
+	mock.Mock
+}
+
+// Fetch provides a mock function with given fields: ctx
+func (_m *MockSource) Fetch(ctx context.Context) (core.Services, error) {
+	ret := _m.Called(ctx)
+
+	var r0 core.Services
+	if rf, ok := ret.Get(0).(func(context.Context) core.Services); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(core.Services)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockWatchableSource is an autogenerated mock type for the WatchableSource type
+type MockWatchableSource struct {
+	MockSource
+}
+
+// Watch provides a mock function with given fields: ctx
+func (_m *MockWatchableSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan struct{}); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockLoggableSource is a mock type for the Source type, which implements core.Loggable
+type MockLoggableSource struct {
+	MockSource
+}
+
+// WithLogger provides a mock function with given fields: logger
+func (_m *MockLoggableSource) WithLogger(logger core.LoggerInterface) {
+	_m.Called(logger)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Transformer rewrites Services fetched from Source before they are compared with Services fetched from Registry.
+	// Transformer can change, add or drop Services, returned Services must be valid.
+	// Transformer must not change passed Services in place, because Source can return same Services on every Fetch.
+	Transformer interface {
+		Transform(ctx context.Context, services Services) (Services, error)
+	}
+
+	// TransformerFunc is an adapter to allow the use of ordinary functions as Transformer
+	TransformerFunc func(ctx context.Context, services Services) (Services, error)
+
+	// TransformerChain is Transformer, which calls all Transformers one by one in passed order
+	TransformerChain []Transformer
+)
+
+// Transform is implementation of Transformer interface
+func (f TransformerFunc) Transform(ctx context.Context, services Services) (Services, error) {
+	return f(ctx, services)
+}
+
+// Transform calls every Transformer with result of previous one and validates result
+func (c TransformerChain) Transform(ctx context.Context, services Services) (Services, error) {
+	for index, transformer := range c {
+		var err error
+		services, err = transformer.Transform(ctx, services)
+		if err != nil {
+			return nil, errors.Wrapf(err, `transformer #%d failed`, index+1)
+		}
+	}
+	for _, service := range services {
+		if err := service.Validate(ctx); err != nil {
+			return nil, errors.Wrap(err, `transformed service is invalid`)
+		}
+	}
+	return services, nil
+}
@@ -0,0 +1,51 @@
+package transformer
+
+import (
+	"context"
+	"path"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Drop is core.Transformer implementation, which drops Services with core.Service Name or
+	// core.Service RegistrationID matched by one of glob patterns (see path.Match for syntax)
+	Drop struct {
+		patterns []string
+	}
+)
+
+// NewDrop provide Drop as core.Transformer implementation
+func NewDrop(patterns []string) (*Drop, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ``); err != nil {
+			return nil, errors.Wrapf(err, `drop pattern "%s" is invalid`, pattern)
+		}
+	}
+	return &Drop{
+		patterns: patterns,
+	}, nil
+}
+
+// Transform is implementation of core.Transformer interface
+func (d *Drop) Transform(_ context.Context, services core.Services) (core.Services, error) {
+	result := make(core.Services, 0, len(services))
+	for _, service := range services {
+		if !d.match(service) {
+			result = append(result, service)
+		}
+	}
+	return result, nil
+}
+
+func (d *Drop) match(service *core.Service) bool {
+	for _, pattern := range d.patterns {
+		for _, value := range []string{service.Name, service.RegistrationID()} {
+			if matched, _ := path.Match(pattern, value); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewDrop(t *testing.T) {
+	suite.Run(t, new(newDropTestSuite))
+}
+
+func TestDrop_Transform(t *testing.T) {
+	suite.Run(t, new(dropTransformTestSuite))
+}
+
+// --- Suites ---
+
+type newDropTestSuite struct {
+	suite.Suite
+}
+
+func (s *newDropTestSuite) TestNewDrop() {
+	got, err := NewDrop([]string{`service-*`})
+	s.NoError(err)
+	s.Implements((*core.Transformer)(nil), got)
+	s.Equal(&Drop{[]string{`service-*`}}, got)
+}
+
+func (s *newDropTestSuite) TestInvalidPattern() {
+	got, err := NewDrop([]string{`[`})
+	s.Nil(got)
+	s.EqualError(err, `drop pattern "[" is invalid: syntax error in pattern`)
+}
+
+type dropTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *dropTransformTestSuite) TestTransform() {
+	drop, _ := NewDrop([]string{`legacy-*`, `*-canary`})
+	services, err := drop.Transform(context.Background(), core.Services{
+		{Name: `legacy-api`},
+		{Name: `api`, ID: ptr.String(`api-canary`)},
+		{Name: `api`},
+	})
+	s.NoError(err)
+	s.Equal(core.Services{{Name: `api`}}, services)
+}
@@ -0,0 +1,60 @@
+package transformer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Meta is core.Transformer implementation, which sets core.Service Meta values rendered from text/template.
+	// core.Service is passed to template as data, e.g. "{{ .Name }}-{{ .Port }}". Existing meta values are overwritten
+	Meta struct {
+		keys      []string
+		templates map[string]*template.Template
+	}
+)
+
+// NewMeta provide Meta as core.Transformer implementation for map of meta key and template
+func NewMeta(values map[string]string) (*Meta, error) {
+	m := &Meta{
+		keys:      make([]string, 0, len(values)),
+		templates: make(map[string]*template.Template, len(values)),
+	}
+	for key, value := range values {
+		t, err := template.New(key).Option(`missingkey=error`).Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, `meta "%s" template is invalid`, key)
+		}
+		m.keys = append(m.keys, key)
+		m.templates[key] = t
+	}
+	sort.Strings(m.keys)
+	return m, nil
+}
+
+// Transform is implementation of core.Transformer interface
+func (m *Meta) Transform(_ context.Context, services core.Services) (core.Services, error) {
+	result := make(core.Services, 0, len(services))
+	for _, service := range services {
+		service = service.Clone()
+		meta := make(map[string]string)
+		if service.Meta != nil {
+			meta = *service.Meta
+		}
+		for _, key := range m.keys {
+			b := new(strings.Builder)
+			if err := m.templates[key].Execute(b, service); err != nil {
+				return nil, errors.Wrapf(err, `failed to render meta "%s" for service "%s"`, key, service.RegistrationID())
+			}
+			meta[key] = b.String()
+		}
+		service.Meta = &meta
+		result = append(result, service)
+	}
+	return result, nil
+}
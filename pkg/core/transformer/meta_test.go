@@ -0,0 +1,66 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNewMeta(t *testing.T) {
+	suite.Run(t, new(newMetaTestSuite))
+}
+
+func TestMeta_Transform(t *testing.T) {
+	suite.Run(t, new(metaTransformTestSuite))
+}
+
+// --- Suites ---
+
+type newMetaTestSuite struct {
+	suite.Suite
+}
+
+func (s *newMetaTestSuite) TestNewMeta() {
+	got, err := NewMeta(map[string]string{`b`: `{{ .Name }}`, `a`: `value`})
+	s.NoError(err)
+	s.Implements((*core.Transformer)(nil), got)
+	s.Equal([]string{`a`, `b`}, got.keys)
+}
+
+func (s *newMetaTestSuite) TestInvalidTemplate() {
+	got, err := NewMeta(map[string]string{`key`: `{{ .Name`})
+	s.Nil(got)
+	s.Error(err)
+	s.Contains(err.Error(), `meta "key" template is invalid`)
+}
+
+type metaTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *metaTransformTestSuite) TestTransform() {
+	original := core.Services{
+		{Name: `api`, Address: `127.0.0.1`, Port: ptr.Int(80)},
+		{Name: `db`, Address: `127.0.0.2`, Meta: &map[string]string{`url`: `old`, `key`: `value`}},
+	}
+	meta, _ := NewMeta(map[string]string{`url`: `http://{{ .Address }}{{ with .Port }}:{{ . }}{{ end }}`})
+	services, err := meta.Transform(context.Background(), original)
+	s.NoError(err)
+	s.Equal(map[string]string{`url`: `http://127.0.0.1:80`}, *services[0].Meta)
+	s.Equal(map[string]string{`url`: `http://127.0.0.2`, `key`: `value`}, *services[1].Meta)
+	s.Nil(original[0].Meta)
+	s.Equal(`old`, (*original[1].Meta)[`url`])
+}
+
+func (s *metaTransformTestSuite) TestError() {
+	meta, _ := NewMeta(map[string]string{`key`: `{{ .Unknown }}`})
+	services, err := meta.Transform(context.Background(), core.Services{{Name: `api`}})
+	s.Nil(services)
+	s.Error(err)
+	s.Contains(err.Error(), `failed to render meta "key" for service "api"`)
+}
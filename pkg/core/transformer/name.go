@@ -0,0 +1,27 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/insidieux/pinchy/pkg/core"
+)
+
+type (
+	// NamePrefix is core.Transformer implementation, which adds prefix to core.Service Name and core.Service ID
+	NamePrefix string
+)
+
+// Transform is implementation of core.Transformer interface
+func (p NamePrefix) Transform(_ context.Context, services core.Services) (core.Services, error) {
+	result := make(core.Services, 0, len(services))
+	for _, service := range services {
+		service = service.Clone()
+		service.Name = string(p) + service.Name
+		if service.ID != nil {
+			id := string(p) + *service.ID
+			service.ID = &id
+		}
+		result = append(result, service)
+	}
+	return result, nil
+}
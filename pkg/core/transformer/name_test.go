@@ -0,0 +1,37 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestNamePrefix_Transform(t *testing.T) {
+	suite.Run(t, new(namePrefixTransformTestSuite))
+}
+
+// --- Suites ---
+
+type namePrefixTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *namePrefixTransformTestSuite) TestTransform() {
+	original := core.Services{
+		{Name: `api`},
+		{Name: `db`, ID: ptr.String(`db-1`)},
+	}
+	services, err := NamePrefix(`prod-`).Transform(context.Background(), original)
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `prod-api`},
+		{Name: `prod-db`, ID: ptr.String(`prod-db-1`)},
+	}, services)
+	s.Equal(`api`, original[0].Name)
+	s.Equal(`db-1`, *original[1].ID)
+}
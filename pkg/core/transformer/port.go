@@ -0,0 +1,26 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/insidieux/pinchy/pkg/core"
+)
+
+type (
+	// DefaultPort is core.Transformer implementation, which sets core.Service Port for Services without port
+	DefaultPort int
+)
+
+// Transform is implementation of core.Transformer interface
+func (p DefaultPort) Transform(_ context.Context, services core.Services) (core.Services, error) {
+	result := make(core.Services, 0, len(services))
+	for _, service := range services {
+		if service.Port == nil || *service.Port == 0 {
+			service = service.Clone()
+			port := int(p)
+			service.Port = &port
+		}
+		result = append(result, service)
+	}
+	return result, nil
+}
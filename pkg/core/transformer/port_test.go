@@ -0,0 +1,39 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agrea/ptr"
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestDefaultPort_Transform(t *testing.T) {
+	suite.Run(t, new(defaultPortTransformTestSuite))
+}
+
+// --- Suites ---
+
+type defaultPortTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *defaultPortTransformTestSuite) TestTransform() {
+	original := core.Services{
+		{Name: `api`},
+		{Name: `zero`, Port: ptr.Int(0)},
+		{Name: `db`, Port: ptr.Int(5432)},
+	}
+	services, err := DefaultPort(80).Transform(context.Background(), original)
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `api`, Port: ptr.Int(80)},
+		{Name: `zero`, Port: ptr.Int(80)},
+		{Name: `db`, Port: ptr.Int(5432)},
+	}, services)
+	s.Nil(original[0].Port)
+	s.Equal(0, *original[1].Port)
+}
@@ -0,0 +1,33 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/thoas/go-funk"
+)
+
+type (
+	// Tags is core.Transformer implementation, which adds tags to core.Service Tags. Existing tags are not duplicated
+	Tags []string
+)
+
+// Transform is implementation of core.Transformer interface
+func (t Tags) Transform(_ context.Context, services core.Services) (core.Services, error) {
+	result := make(core.Services, 0, len(services))
+	for _, service := range services {
+		service = service.Clone()
+		tags := make([]string, 0)
+		if service.Tags != nil {
+			tags = append(tags, *service.Tags...)
+		}
+		for _, tag := range t {
+			if !funk.ContainsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		service.Tags = &tags
+		result = append(result, service)
+	}
+	return result, nil
+}
@@ -0,0 +1,36 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/insidieux/pinchy/pkg/core"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestTags_Transform(t *testing.T) {
+	suite.Run(t, new(tagsTransformTestSuite))
+}
+
+// --- Suites ---
+
+type tagsTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *tagsTransformTestSuite) TestTransform() {
+	original := core.Services{
+		{Name: `api`},
+		{Name: `db`, Tags: &[]string{`env:prod`, `db`}},
+	}
+	services, err := Tags{`env:prod`, `team:core`}.Transform(context.Background(), original)
+	s.NoError(err)
+	s.Equal(core.Services{
+		{Name: `api`, Tags: &[]string{`env:prod`, `team:core`}},
+		{Name: `db`, Tags: &[]string{`env:prod`, `db`, `team:core`}},
+	}, services)
+	s.Nil(original[0].Tags)
+	s.Equal([]string{`env:prod`, `db`}, *original[1].Tags)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Tests ---
+
+func TestTransformerFunc_Transform(t *testing.T) {
+	suite.Run(t, new(transformerFuncTransformTestSuite))
+}
+
+func TestTransformerChain_Transform(t *testing.T) {
+	suite.Run(t, new(transformerChainTransformTestSuite))
+}
+
+// --- Suites ---
+
+type transformerFuncTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *transformerFuncTransformTestSuite) TestTransform() {
+	f := TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+		return services[1:], nil
+	})
+	services, err := f.Transform(context.Background(), Services{{Name: `service-1`}, {Name: `service-2`}})
+	s.NoError(err)
+	s.Equal(Services{{Name: `service-2`}}, services)
+}
+
+type transformerChainTransformTestSuite struct {
+	suite.Suite
+}
+
+func (s *transformerChainTransformTestSuite) TestOrder() {
+	chain := TransformerChain{
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return append(services, &Service{Name: `service-2`, Address: `127.0.0.1`}), nil
+		}),
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return services[1:], nil
+		}),
+	}
+	services, err := chain.Transform(context.Background(), Services{{Name: `service-1`, Address: `127.0.0.1`}})
+	s.NoError(err)
+	s.Equal(Services{{Name: `service-2`, Address: `127.0.0.1`}}, services)
+}
+
+func (s *transformerChainTransformTestSuite) TestError() {
+	chain := TransformerChain{
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return nil, errors.New(`expected error`)
+		}),
+	}
+	services, err := chain.Transform(context.Background(), Services{})
+	s.Nil(services)
+	s.EqualError(err, `transformer #1 failed: expected error`)
+}
+
+func (s *transformerChainTransformTestSuite) TestInvalidService() {
+	chain := TransformerChain{
+		TransformerFunc(func(ctx context.Context, services Services) (Services, error) {
+			return Services{{Name: `service-1`}}, nil
+		}),
+	}
+	services, err := chain.Transform(context.Background(), Services{})
+	s.Nil(services)
+	s.EqualError(err, `transformed service is invalid: service "service-1" field "address" is required and cannot be empty`)
+}